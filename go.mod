@@ -2,4 +2,7 @@ module github.com/amarbel-llc/piers
 
 go 1.25.6
 
-require github.com/amarbel-llc/purse-first/libs/go-mcp v0.0.1
+require (
+	github.com/amarbel-llc/purse-first/libs/go-mcp v0.0.1
+	github.com/yuin/goldmark v1.8.6
+)
@@ -0,0 +1,178 @@
+package docindex
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/google"
+)
+
+// Text is the concatenated text of a document body, with each text run
+// remembered at its Docs index so that byte offsets into the Go string can
+// be converted to and from document indices. Runs are not always
+// contiguous in the document — table and section markers or inline objects
+// occupy indices without contributing text — so each run keeps its own
+// start index.
+type Text struct {
+	text  string
+	spans []span
+}
+
+type span struct {
+	byteStart, byteEnd int
+	index              int
+}
+
+// New collects the text runs of body, including those inside tables.
+func New(body *google.DocumentBody) *Text {
+	t := &Text{}
+	if body == nil {
+		return t
+	}
+	var sb strings.Builder
+	t.collect(&sb, body.Content)
+	t.text = sb.String()
+	return t
+}
+
+func (t *Text) collect(sb *strings.Builder, content []google.ContentElement) {
+	for _, el := range content {
+		if el.Paragraph != nil {
+			for _, pe := range el.Paragraph.Elements {
+				if pe.TextRun == nil || pe.TextRun.Content == "" {
+					continue
+				}
+				start := sb.Len()
+				sb.WriteString(pe.TextRun.Content)
+				t.spans = append(t.spans, span{byteStart: start, byteEnd: sb.Len(), index: pe.StartIndex})
+			}
+		}
+		if el.Table != nil {
+			for _, row := range el.Table.TableRows {
+				for _, cell := range row.TableCells {
+					t.collect(sb, cell.Content)
+				}
+			}
+		}
+	}
+}
+
+// String returns the collected text.
+func (t *Text) String() string { return t.text }
+
+// Index returns the Docs index of the character starting at byteOffset. An
+// offset at the end of a run maps to the index just past that run.
+func (t *Text) Index(byteOffset int) int {
+	if len(t.spans) == 0 {
+		return 0
+	}
+	i := sort.Search(len(t.spans), func(i int) bool { return t.spans[i].byteEnd > byteOffset })
+	if i == len(t.spans) {
+		i--
+	}
+	s := t.spans[i]
+	return s.index + UTF16Offset(t.text[s.byteStart:s.byteEnd], byteOffset-s.byteStart)
+}
+
+// Range converts the byte range [start, end) of the text to Docs indices.
+// The end is resolved against the run containing the last byte, so a match
+// ending a run does not jump over whatever follows it in the document.
+func (t *Text) Range(start, end int) (int, int) {
+	if end <= start {
+		i := t.Index(start)
+		return i, i
+	}
+	lastRune := prevRuneStart(t.text, end)
+	return t.Index(start), t.Index(lastRune) + UTF16Len(t.text[lastRune:end])
+}
+
+// Offset returns the byte offset of the character at Docs index. It reports
+// false when the index is not inside a text run or falls between the
+// halves of a surrogate pair.
+func (t *Text) Offset(index int) (int, bool) {
+	for _, s := range t.spans {
+		run := t.text[s.byteStart:s.byteEnd]
+		if index >= s.index && index < s.index+UTF16Len(run) {
+			b, ok := ByteOffset(run, index-s.index)
+			return s.byteStart + b, ok
+		}
+	}
+	return 0, false
+}
+
+// SplitsCharacter reports whether index falls between the two halves of a
+// surrogate pair, where the Docs API rejects inserts and range edges.
+func (t *Text) SplitsCharacter(index int) bool {
+	for _, s := range t.spans {
+		run := t.text[s.byteStart:s.byteEnd]
+		if index > s.index && index < s.index+UTF16Len(run) {
+			_, ok := ByteOffset(run, index-s.index)
+			return !ok
+		}
+	}
+	return false
+}
+
+// Slice returns the text between Docs indices [start, end), skipping
+// anything that is not text.
+func (t *Text) Slice(start, end int) string {
+	var sb strings.Builder
+	for _, s := range t.spans {
+		run := t.text[s.byteStart:s.byteEnd]
+		runEnd := s.index + UTF16Len(run)
+		if runEnd <= start || s.index >= end {
+			continue
+		}
+		from, ok := ByteOffset(run, max(start, s.index)-s.index)
+		if !ok {
+			// start falls inside a surrogate pair; begin after it.
+			from, _ = ByteOffset(run, max(start, s.index)-s.index+1)
+		}
+		var to int
+		to, ok = ByteOffset(run, min(end, runEnd)-s.index)
+		if !ok {
+			// end falls inside a surrogate pair; leave that character out.
+			to, _ = ByteOffset(run, min(end, runEnd)-s.index-1)
+		}
+		sb.WriteString(run[from:to])
+	}
+	return sb.String()
+}
+
+// Find returns the Docs range of the instance-th (1-based) occurrence of
+// s in the text.
+func (t *Text) Find(s string, instance int) (start, end int, ok bool) {
+	return t.FindAfter(s, 0, instance)
+}
+
+// FindAfter is like Find but only counts occurrences that start at or
+// after the Docs index from.
+func (t *Text) FindAfter(s string, from, instance int) (start, end int, ok bool) {
+	if s == "" || instance < 1 {
+		return 0, 0, false
+	}
+	offset := 0
+	for {
+		i := strings.Index(t.text[offset:], s)
+		if i < 0 {
+			return 0, 0, false
+		}
+		offset += i
+		start, end = t.Range(offset, offset+len(s))
+		if start >= from {
+			instance--
+			if instance == 0 {
+				return start, end, true
+			}
+		}
+		offset += len(s)
+	}
+}
+
+func prevRuneStart(s string, end int) int {
+	i := end - 1
+	for i > 0 && s[i]&0xC0 == 0x80 {
+		i--
+	}
+	return i
+}
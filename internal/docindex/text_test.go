@@ -0,0 +1,103 @@
+package docindex
+
+import (
+	"testing"
+
+	"github.com/amarbel-llc/piers/internal/google"
+)
+
+// sample is laid out the way the Docs API reports it:
+//
+//	1   "Hi 😀 日本\n"      (😀 takes indices 4-5)
+//	10  table start, row at 11, cell at 12
+//	13  "表😀\n"            (cell paragraph)
+//	17  table end
+//	18  "end\n"
+func sample() *google.DocumentBody {
+	run := func(start int, content string) google.ContentElement {
+		end := start + UTF16Len(content)
+		return google.ContentElement{StartIndex: start, EndIndex: end, Paragraph: &google.Paragraph{
+			Elements: []google.ParagraphElement{{StartIndex: start, EndIndex: end, TextRun: &google.TextRun{Content: content}}},
+		}}
+	}
+	return &google.DocumentBody{Content: []google.ContentElement{
+		run(1, "Hi 😀 日本\n"),
+		{StartIndex: 10, EndIndex: 18, Table: &google.Table{TableRows: []google.TableRow{{
+			TableCells: []google.TableCell{{Content: []google.ContentElement{run(13, "表😀\n")}}},
+		}}}},
+		run(18, "end\n"),
+	}}
+}
+
+func TestTextFind(t *testing.T) {
+	text := New(sample())
+	if got, want := text.String(), "Hi 😀 日本\n表😀\nend\n"; got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		find       string
+		instance   int
+		start, end int
+	}{
+		{"Hi", 1, 1, 3},
+		{"😀", 1, 4, 6},
+		{"日本", 1, 7, 9},
+		{"表", 1, 13, 14},
+		{"😀", 2, 14, 16},
+		{"end", 1, 18, 21},
+		{"本\n", 1, 8, 10},
+	}
+	for _, tt := range tests {
+		start, end, ok := text.Find(tt.find, tt.instance)
+		if !ok || start != tt.start || end != tt.end {
+			t.Errorf("Find(%q, %d) = %d, %d, %v, want %d, %d", tt.find, tt.instance, start, end, ok, tt.start, tt.end)
+		}
+	}
+	if _, _, ok := text.Find("😀", 3); ok {
+		t.Error("Find of a missing instance succeeded")
+	}
+	if start, end, ok := text.FindAfter("😀", 6, 1); !ok || start != 14 || end != 16 {
+		t.Errorf("FindAfter(😀, 6, 1) = %d, %d, %v, want 14, 16", start, end, ok)
+	}
+}
+
+func TestTextSlice(t *testing.T) {
+	text := New(sample())
+	tests := []struct {
+		start, end int
+		want       string
+	}{
+		{1, 3, "Hi"},
+		{4, 6, "😀"},
+		{4, 5, ""}, // ends inside the emoji
+		{5, 9, " 日本"},
+		{7, 16, "日本\n表😀"},
+		{1, 100, "Hi 😀 日本\n表😀\nend\n"},
+	}
+	for _, tt := range tests {
+		if got := text.Slice(tt.start, tt.end); got != tt.want {
+			t.Errorf("Slice(%d, %d) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestTextOffsets(t *testing.T) {
+	text := New(sample())
+	for index, splits := range map[int]bool{4: false, 5: true, 6: false, 14: false, 15: true, 11: false} {
+		if got := text.SplitsCharacter(index); got != splits {
+			t.Errorf("SplitsCharacter(%d) = %v, want %v", index, got, splits)
+		}
+	}
+
+	off, ok := text.Offset(7)
+	if !ok || text.String()[off:off+3] != "日" {
+		t.Errorf("Offset(7) = %d, %v, want the start of 日", off, ok)
+	}
+	if _, ok := text.Offset(11); ok {
+		t.Error("Offset of a table marker should fail")
+	}
+	if got := text.Index(off); got != 7 {
+		t.Errorf("Index(%d) = %d, want 7", off, got)
+	}
+}
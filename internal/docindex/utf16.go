@@ -0,0 +1,76 @@
+// Package docindex maps between Go strings and Google Docs indices. Docs
+// counts positions in UTF-16 code units, so a character outside the Basic
+// Multilingual Plane (most emoji) occupies two indices while taking four
+// bytes in Go, and a CJK character occupies one index but three bytes.
+package docindex
+
+import "unicode/utf8"
+
+// UTF16Len returns the length of s in UTF-16 code units.
+func UTF16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeUnits(r)
+	}
+	return n
+}
+
+// UTF16Offset converts a byte offset in s to a UTF-16 offset. An offset
+// inside a multibyte rune counts that rune as not yet reached.
+func UTF16Offset(s string, byteOffset int) int {
+	n := 0
+	for i, r := range s {
+		if i >= byteOffset {
+			break
+		}
+		n += runeUnits(r)
+	}
+	return n
+}
+
+// ByteOffset converts a UTF-16 offset in s to a byte offset. It reports
+// false when the offset is past the end of s or falls between the two
+// halves of a surrogate pair.
+func ByteOffset(s string, utf16Offset int) (int, bool) {
+	n := 0
+	for i, r := range s {
+		if n == utf16Offset {
+			return i, true
+		}
+		n += runeUnits(r)
+		if n > utf16Offset {
+			return 0, false
+		}
+	}
+	if n == utf16Offset {
+		return len(s), true
+	}
+	return 0, false
+}
+
+// RuneCount returns the number of characters in s.
+func RuneCount(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// TruncateRunes returns the first n characters of s, never splitting a
+// multibyte character.
+func TruncateRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+func runeUnits(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return 2
+	}
+	return 1
+}
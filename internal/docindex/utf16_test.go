@@ -0,0 +1,58 @@
+package docindex
+
+import "testing"
+
+func TestUTF16Len(t *testing.T) {
+	tests := map[string]int{
+		"":        0,
+		"abc":     3,
+		"日本語":     3,
+		"😀":       2,
+		"a😀b":     4,
+		"👍🏽":      4, // thumbs up + skin tone modifier
+		"e\u0301": 2, // combining accent
+	}
+	for s, want := range tests {
+		if got := UTF16Len(s); got != want {
+			t.Errorf("UTF16Len(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestOffsets(t *testing.T) {
+	s := "a😀日b"
+	// bytes: a=0, 😀=1..4, 日=5..7, b=8; UTF-16: a=0, 😀=1,2, 日=3, b=4
+	for _, tt := range []struct{ bytes, units int }{{0, 0}, {1, 1}, {5, 3}, {8, 4}, {9, 5}} {
+		if got := UTF16Offset(s, tt.bytes); got != tt.units {
+			t.Errorf("UTF16Offset(%d) = %d, want %d", tt.bytes, got, tt.units)
+		}
+		if got, ok := ByteOffset(s, tt.units); !ok || got != tt.bytes {
+			t.Errorf("ByteOffset(%d) = %d, %v, want %d", tt.units, got, ok, tt.bytes)
+		}
+	}
+	if _, ok := ByteOffset(s, 2); ok {
+		t.Error("ByteOffset inside a surrogate pair should fail")
+	}
+	if _, ok := ByteOffset(s, 6); ok {
+		t.Error("ByteOffset past the end should fail")
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 3, "hel"},
+		{"日本語テキスト", 3, "日本語"},
+		{"😀😀😀", 2, "😀😀"},
+		{"short", 10, "short"},
+		{"abc", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := TruncateRunes(tt.s, tt.n); got != tt.want {
+			t.Errorf("TruncateRunes(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,783 @@
+// Package doctemplate fills Mustache-style placeholders in a Google Docs
+// body, producing the batchUpdate requests that turn a copied template into
+// a finished document.
+//
+// The syntax is:
+//
+//	{{name}}               replaced with the value of name (dotted paths reach into objects)
+//	{{image:name}}         replaced with an image; the value is a URL or {"url", "width", "height"}
+//	{{#name}}...{{/name}}  repeated for each item of a list, or kept once when name is truthy
+//	{{^name}}...{{/name}}  kept only when name is missing, false, empty or an empty list
+//	{{.}}                  the current list item
+//
+// A section whose markers sit alone in their own paragraphs repeats those
+// paragraphs; one whose markers fall in the same table row repeats the row;
+// any other section is rendered within its paragraph.
+package doctemplate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/markdown"
+)
+
+var tokenPattern = regexp.MustCompile(`\{\{\s*([#^/]|image:)?\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// Report describes what Render could not fill.
+type Report struct {
+	// Unmatched lists placeholder names with no value in the data.
+	Unmatched []string
+	// Errors lists template syntax problems, such as unclosed sections.
+	Errors []string
+}
+
+// Options configure Render.
+type Options struct {
+	// TabID targets a specific tab; empty means the first tab.
+	TabID string
+	// Lists are the document's lists, used to choose bullet presets for
+	// repeated list paragraphs.
+	Lists map[string]google.List
+}
+
+// Render returns the requests that fill the template in body with data.
+// Placeholders without a value are left in place and reported.
+func Render(body *google.DocumentBody, data map[string]any, opts Options) ([]google.Request, Report) {
+	r := &renderer{opts: opts, unmatched: map[string]bool{}}
+	if body != nil {
+		r.container(body.Content, &scope{value: data})
+	}
+
+	// Each op only touches indices from its position on, so applying them
+	// last to first keeps every recorded index valid.
+	sort.SliceStable(r.ops, func(i, j int) bool { return r.ops[i].index > r.ops[j].index })
+	var requests []google.Request
+	for _, o := range r.ops {
+		requests = append(requests, o.requests...)
+	}
+	return requests, r.report()
+}
+
+// Text fills the placeholders and sections in a plain string, such as a
+// file name pattern.
+func Text(s string, data map[string]any) (string, Report) {
+	r := &renderer{unmatched: map[string]bool{}}
+	out := r.text(s, &scope{value: data})
+	return out, r.report()
+}
+
+// RenderEach returns the requests that replace the whole body with one
+// filled copy of it per item, separated by page breaks. Only paragraphs
+// can be repeated this way; a body holding tables is reported as an error
+// and left unchanged.
+func RenderEach(body *google.DocumentBody, items []map[string]any, opts Options) ([]google.Request, Report) {
+	r := &renderer{opts: opts, unmatched: map[string]bool{}}
+	if body == nil || len(items) == 0 {
+		return nil, r.report()
+	}
+	var paragraphs []*google.Paragraph
+	start := -1
+	for _, el := range body.Content {
+		switch {
+		case el.Paragraph != nil:
+			if start < 0 {
+				start = el.StartIndex
+			}
+			paragraphs = append(paragraphs, el.Paragraph)
+		case el.SectionBreak == nil:
+			r.errorf("only paragraphs can be repeated for each item; remove tables and tables of contents from the template")
+			return nil, r.report()
+		}
+	}
+	if start < 0 {
+		return nil, r.report()
+	}
+
+	// Each copy's start, relative to the body's, so that page breaks can
+	// be added in front of every copy after the first.
+	var rendered []styledParagraph
+	var offsets []int
+	offset := 0
+	for _, item := range items {
+		offsets = append(offsets, offset)
+		for _, p := range r.styled(paragraphs, &scope{value: item}, nil) {
+			for _, run := range p.runs {
+				offset += docindex.UTF16Len(run.text)
+			}
+			rendered = append(rendered, p)
+		}
+	}
+
+	// The body's final newline cannot be deleted, so the copies are
+	// inserted in front of it without one of their own.
+	end := body.Content[len(body.Content)-1].EndIndex - 1
+	var requests []google.Request
+	if end > start {
+		requests = append(requests, google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+			Range: google.Range{StartIndex: start, EndIndex: end, TabID: opts.TabID},
+		}})
+	}
+	requests = append(requests, r.insertStyled(start, rendered, false)...)
+	for i := len(offsets) - 1; i > 0; i-- {
+		requests = append(requests, google.Request{InsertPageBreak: &google.InsertPageBreakRequest{
+			Location: google.Location{Index: start + offsets[i], TabID: opts.TabID},
+		}})
+	}
+	return requests, r.report()
+}
+
+// --- Data ---
+
+// scope is one level of the context stack: the root data, then each
+// section's current item.
+type scope struct {
+	value  any
+	parent *scope
+}
+
+func (s *scope) lookup(path string) (any, bool) {
+	if path == "." {
+		return s.value, true
+	}
+	parts := strings.Split(path, ".")
+	for sc := s; sc != nil; sc = sc.parent {
+		if v, ok := dig(sc.value, parts); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func dig(v any, parts []string) (any, bool) {
+	for _, p := range parts {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[p]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func truthy(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	}
+	return true
+}
+
+func format(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// iterations returns the scopes a section renders with: one per list item,
+// one for any other truthy value, or one for a falsy value in an inverted
+// section.
+func iterations(t token, v any, s *scope) []*scope {
+	if t.kind == "^" {
+		if truthy(v) {
+			return nil
+		}
+		return []*scope{s}
+	}
+	if !truthy(v) {
+		return nil
+	}
+	if list, ok := v.([]any); ok {
+		scopes := make([]*scope, len(list))
+		for i, item := range list {
+			scopes[i] = &scope{value: item, parent: s}
+		}
+		return scopes
+	}
+	return []*scope{{value: v, parent: s}}
+}
+
+// --- Tokens ---
+
+type token struct {
+	start, end int
+	kind       string // "", "#", "^", "/" or "image:"
+	name       string
+}
+
+func (t token) opens() bool { return t.kind == "#" || t.kind == "^" }
+
+func parseTokens(s string) []token {
+	var tokens []token
+	for _, m := range tokenPattern.FindAllStringSubmatchIndex(s, -1) {
+		t := token{start: m[0], end: m[1], name: s[m[4]:m[5]]}
+		if m[2] >= 0 {
+			t.kind = s[m[2]:m[3]]
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+// matchClose returns the index of the token closing the section opened by
+// tokens[i], or -1.
+func matchClose(tokens []token, i int) int {
+	depth := 0
+	for j := i + 1; j < len(tokens); j++ {
+		switch {
+		case tokens[j].opens() && tokens[j].name == tokens[i].name:
+			depth++
+		case tokens[j].kind == "/" && tokens[j].name == tokens[i].name:
+			if depth == 0 {
+				return j
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// balanced reports whether every section in s closes within s.
+func balanced(s string) bool {
+	tokens := parseTokens(s)
+	for i := 0; i < len(tokens); i++ {
+		switch {
+		case tokens[i].opens():
+			j := matchClose(tokens, i)
+			if j < 0 {
+				return false
+			}
+			i = j
+		case tokens[i].kind == "/":
+			return false
+		}
+	}
+	return true
+}
+
+// --- Rendering ---
+
+type op struct {
+	index    int
+	requests []google.Request
+}
+
+type renderer struct {
+	opts      Options
+	ops       []op
+	unmatched map[string]bool
+	errors    []string
+}
+
+func (r *renderer) report() Report {
+	var report Report
+	for name := range r.unmatched {
+		report.Unmatched = append(report.Unmatched, name)
+	}
+	sort.Strings(report.Unmatched)
+	report.Errors = r.errors
+	return report
+}
+
+func (r *renderer) errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *renderer) lookup(s *scope, name string) (any, bool) {
+	v, ok := s.lookup(name)
+	if !ok {
+		r.unmatched[name] = true
+	}
+	return v, ok
+}
+
+func (r *renderer) add(index int, requests ...google.Request) {
+	r.ops = append(r.ops, op{index: index, requests: requests})
+}
+
+// text renders s as a string, for content that is rebuilt rather than
+// edited in place. Image placeholders cannot be rendered as text and are
+// left as they are.
+func (r *renderer) text(s string, sc *scope) string {
+	tokens := parseTokens(s)
+	var sb strings.Builder
+	pos := 0
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		sb.WriteString(s[pos:t.start])
+		pos = t.end
+		switch {
+		case t.opens():
+			j := matchClose(tokens, i)
+			if j < 0 {
+				r.errorf("section %q is not closed", t.name)
+				sb.WriteString(s[t.start:t.end])
+				continue
+			}
+			v, _ := r.lookup(sc, t.name)
+			for _, item := range iterations(t, v, sc) {
+				sb.WriteString(r.text(s[t.end:tokens[j].start], item))
+			}
+			pos = tokens[j].end
+			i = j
+		case t.kind == "/":
+			r.errorf("section %q is closed without being opened", t.name)
+			sb.WriteString(s[t.start:t.end])
+		case t.kind == "image:":
+			r.errorf("image %q cannot be placed inside a repeated section", t.name)
+			sb.WriteString(s[t.start:t.end])
+		default:
+			if v, ok := r.lookup(sc, t.name); ok {
+				sb.WriteString(format(v))
+			} else {
+				sb.WriteString(s[t.start:t.end])
+			}
+		}
+	}
+	sb.WriteString(s[pos:])
+	return sb.String()
+}
+
+// container fills the elements of the body or of a table cell.
+func (r *renderer) container(content []google.ContentElement, sc *scope) {
+	for i := 0; i < len(content); i++ {
+		el := content[i]
+		switch {
+		case el.Paragraph != nil:
+			if open, ok := markerParagraph(el.Paragraph); ok && open.opens() {
+				if j := closingParagraph(content, i, open.name); j > 0 {
+					r.block(content, i, j, open, sc)
+					i = j
+					continue
+				}
+			}
+			r.paragraph(el, sc)
+		case el.Table != nil:
+			for ri, row := range el.Table.TableRows {
+				if open, ok := rowSection(row); ok {
+					r.row(el, ri, open, sc)
+					continue
+				}
+				for _, cell := range row.TableCells {
+					r.container(cell.Content, sc)
+				}
+			}
+		}
+	}
+}
+
+// markerParagraph returns the token a paragraph consists of, if it holds
+// nothing but a single tag.
+func markerParagraph(p *google.Paragraph) (token, bool) {
+	text := strings.TrimSpace(paragraphText(p))
+	tokens := parseTokens(text)
+	if len(tokens) != 1 || tokens[0].start != 0 || tokens[0].end != len(text) {
+		return token{}, false
+	}
+	return tokens[0], true
+}
+
+// closingParagraph finds the paragraph closing the section opened at
+// content[open], or -1.
+func closingParagraph(content []google.ContentElement, open int, name string) int {
+	depth := 0
+	for j := open + 1; j < len(content); j++ {
+		if content[j].Paragraph == nil {
+			continue
+		}
+		t, ok := markerParagraph(content[j].Paragraph)
+		switch {
+		case !ok || t.name != name:
+		case t.opens():
+			depth++
+		case t.kind == "/" && depth == 0:
+			return j
+		case t.kind == "/":
+			depth--
+		}
+	}
+	return -1
+}
+
+// rowSection returns the section a table row repeats: one opened by the
+// first tag of the row's first cell and closed by the last tag of its last
+// cell.
+func rowSection(row google.TableRow) (token, bool) {
+	cellText := func(cell google.TableCell) string {
+		var sb strings.Builder
+		for _, el := range cell.Content {
+			if el.Paragraph != nil {
+				sb.WriteString(paragraphText(el.Paragraph))
+			}
+		}
+		return sb.String()
+	}
+	if len(row.TableCells) == 0 {
+		return token{}, false
+	}
+	first := parseTokens(cellText(row.TableCells[0]))
+	last := parseTokens(cellText(row.TableCells[len(row.TableCells)-1]))
+	if len(first) == 0 || len(last) == 0 || !first[0].opens() {
+		return token{}, false
+	}
+	if closing := last[len(last)-1]; closing.kind != "/" || closing.name != first[0].name {
+		return token{}, false
+	}
+	return first[0], true
+}
+
+func paragraphText(p *google.Paragraph) string {
+	var sb strings.Builder
+	for _, pe := range p.Elements {
+		if pe.TextRun != nil {
+			sb.WriteString(pe.TextRun.Content)
+		}
+	}
+	return sb.String()
+}
+
+// paragraph fills the placeholders of a paragraph in place.
+func (r *renderer) paragraph(el google.ContentElement, sc *scope) {
+	text := docindex.New(&google.DocumentBody{Content: []google.ContentElement{el}})
+	s := text.String()
+	tokens := parseTokens(s)
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.opens():
+			j := matchClose(tokens, i)
+			if j < 0 {
+				r.errorf("section %q is not closed", t.name)
+				continue
+			}
+			var rendered strings.Builder
+			v, _ := r.lookup(sc, t.name)
+			for _, item := range iterations(t, v, sc) {
+				rendered.WriteString(r.text(s[t.end:tokens[j].start], item))
+			}
+			start, end := text.Range(t.start, tokens[j].end)
+			r.replace(start, end, rendered.String())
+			i = j
+		case t.kind == "/":
+			r.errorf("section %q is closed without being opened", t.name)
+		case t.kind == "image:":
+			v, ok := r.lookup(sc, t.name)
+			if !ok {
+				continue
+			}
+			start, end := text.Range(t.start, t.end)
+			r.image(start, end, v)
+		default:
+			v, ok := r.lookup(sc, t.name)
+			if !ok {
+				continue
+			}
+			start, end := text.Range(t.start, t.end)
+			r.replace(start, end, format(v))
+		}
+	}
+}
+
+// replace swaps [start, end) for text. The text goes in after the range's
+// first character so it takes on the placeholder's own formatting rather
+// than that of whatever precedes it.
+func (r *renderer) replace(start, end int, text string) {
+	rng := func(s, e int) google.Request {
+		return google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+			Range: google.Range{StartIndex: s, EndIndex: e, TabID: r.opts.TabID},
+		}}
+	}
+	if text == "" {
+		r.add(start, rng(start, end))
+		return
+	}
+	n := docindex.UTF16Len(text)
+	r.add(start,
+		google.Request{InsertText: &google.InsertTextRequest{
+			Location: google.Location{Index: start + 1, TabID: r.opts.TabID},
+			Text:     text,
+		}},
+		rng(start+1+n, end+n),
+		rng(start, start+1),
+	)
+}
+
+func (r *renderer) image(start, end int, v any) {
+	var uri string
+	var size *google.Size
+	switch v := v.(type) {
+	case string:
+		uri = v
+	case map[string]any:
+		uri = format(v["url"])
+		w, _ := v["width"].(float64)
+		h, _ := v["height"].(float64)
+		if w > 0 && h > 0 {
+			size = &google.Size{Width: google.Points(w), Height: google.Points(h)}
+		}
+	}
+	if uri == "" {
+		r.errorf("image value must be a URL or an object with a url")
+		return
+	}
+	r.add(start,
+		google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+			Range: google.Range{StartIndex: start, EndIndex: end, TabID: r.opts.TabID},
+		}},
+		google.Request{InsertInlineImage: &google.InsertInlineImageRequest{
+			Location:   google.Location{Index: start, TabID: r.opts.TabID},
+			URI:        uri,
+			ObjectSize: size,
+		}},
+	)
+}
+
+// block rebuilds the paragraphs between the marker paragraphs content[open]
+// and content[close] once per iteration, in place of the whole section.
+func (r *renderer) block(content []google.ContentElement, open, close int, t token, sc *scope) {
+	var paragraphs []*google.Paragraph
+	for _, el := range content[open+1 : close] {
+		if el.Paragraph == nil {
+			r.errorf("section %q repeats a table; use a table-row section instead", t.name)
+			return
+		}
+		paragraphs = append(paragraphs, el.Paragraph)
+	}
+	v, _ := r.lookup(sc, t.name)
+	var rendered []styledParagraph
+	for _, item := range iterations(t, v, sc) {
+		rendered = append(rendered, r.styled(paragraphs, item, nil)...)
+	}
+
+	start, end := content[open].StartIndex, content[close].EndIndex
+	if close == len(content)-1 {
+		// The last paragraph of the body or a cell cannot be deleted.
+		end--
+	}
+	requests := []google.Request{{DeleteContentRange: &google.DeleteContentRangeRequest{
+		Range: google.Range{StartIndex: start, EndIndex: end, TabID: r.opts.TabID},
+	}}}
+	r.add(start, append(requests, r.insertStyled(start, rendered, true)...)...)
+}
+
+// row repeats the table's row rowIndex once per iteration by adding empty
+// rows below it and filling every copy, the original included. The op is
+// placed at the row's first cell so that edits to earlier rows, which move
+// it, are applied after it.
+func (r *renderer) row(el google.ContentElement, rowIndex int, t token, sc *scope) {
+	row := el.Table.TableRows[rowIndex]
+	location := google.TableCellLocation{
+		TableStartLocation: google.Location{Index: el.StartIndex, TabID: r.opts.TabID},
+		RowIndex:           rowIndex,
+	}
+	rowStart := row.TableCells[0].Content[0].StartIndex
+	v, _ := r.lookup(sc, t.name)
+	items := iterations(t, v, sc)
+	if len(items) == 0 {
+		r.add(rowStart, google.Request{DeleteTableRow: &google.DeleteTableRowRequest{TableCellLocation: location}})
+		return
+	}
+
+	// The section's own markers are dropped from the copies.
+	strip := func(s string) string {
+		tokens := parseTokens(s)
+		for i := len(tokens) - 1; i >= 0; i-- {
+			if tok := tokens[i]; tok.name == t.name && (tok.opens() || tok.kind == "/") {
+				s = s[:tok.start] + s[tok.end:]
+			}
+		}
+		return s
+	}
+	cells := make([][]*google.Paragraph, len(row.TableCells))
+	for c, cell := range row.TableCells {
+		for _, cel := range cell.Content {
+			if cel.Paragraph != nil {
+				cells[c] = append(cells[c], cel.Paragraph)
+			}
+		}
+	}
+
+	var requests []google.Request
+	for range items[1:] {
+		requests = append(requests, google.Request{InsertTableRow: &google.InsertTableRowRequest{TableCellLocation: location, InsertBelow: true}})
+	}
+	// A new row is a row marker followed by, for each cell, a cell marker
+	// and an empty paragraph.
+	last := row.TableCells[len(row.TableCells)-1].Content
+	rowEnd := last[len(last)-1].EndIndex
+	rowLength := 1 + 2*len(row.TableCells)
+	for k := len(items) - 1; k >= 1; k-- {
+		copyStart := rowEnd + (k-1)*rowLength
+		for c := len(cells) - 1; c >= 0; c-- {
+			requests = append(requests, r.insertStyled(copyStart+2+2*c, r.styled(cells[c], items[k], strip), false)...)
+		}
+	}
+	for c := len(row.TableCells) - 1; c >= 0; c-- {
+		content := row.TableCells[c].Content
+		start, end := content[0].StartIndex, content[len(content)-1].EndIndex-1
+		if end > start {
+			requests = append(requests, google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+				Range: google.Range{StartIndex: start, EndIndex: end, TabID: r.opts.TabID},
+			}})
+		}
+		requests = append(requests, r.insertStyled(start, r.styled(cells[c], items[0], strip), false)...)
+	}
+	r.add(rowStart, requests...)
+}
+
+// --- Rebuilt content ---
+
+type styledRun struct {
+	text  string
+	style *google.TextStyle
+}
+
+type styledParagraph struct {
+	runs   []styledRun
+	style  *google.ParagraphStyle
+	bullet *google.Bullet
+}
+
+// styled renders paragraphs with sc, keeping each run's formatting. A
+// paragraph with a section spanning runs is rendered as a single run in
+// its first run's style. Images and footnote references are not copied.
+func (r *renderer) styled(paragraphs []*google.Paragraph, sc *scope, strip func(string) string) []styledParagraph {
+	var out []styledParagraph
+	for _, p := range paragraphs {
+		sp := styledParagraph{style: p.ParagraphStyle, bullet: p.Bullet}
+		var runs []*google.TextRun
+		split := true
+		for _, pe := range p.Elements {
+			if pe.TextRun != nil {
+				runs = append(runs, pe.TextRun)
+				split = split && balanced(pe.TextRun.Content)
+			}
+		}
+		if !split && len(runs) > 0 {
+			runs = []*google.TextRun{{Content: paragraphText(p), TextStyle: runs[0].TextStyle}}
+		}
+		for _, run := range runs {
+			content := run.Content
+			if strip != nil {
+				content = strip(content)
+			}
+			sp.runs = append(sp.runs, styledRun{text: r.text(content, sc), style: run.TextStyle})
+		}
+		out = append(out, sp)
+	}
+	return out
+}
+
+// insertStyled inserts paragraphs at index and restores their formatting.
+// Without trailingNewline the last paragraph's newline is dropped so it
+// joins the paragraph already at index, as in an empty table cell.
+func (r *renderer) insertStyled(index int, paragraphs []styledParagraph, trailingNewline bool) []google.Request {
+	var sb strings.Builder
+	var formats []google.Request
+	pos := index
+	type span struct {
+		start, end int
+		preset     string
+	}
+	var bulleted []span
+	for pi, p := range paragraphs {
+		start := pos
+		for ri, run := range p.runs {
+			text := run.text
+			if !trailingNewline && pi == len(paragraphs)-1 && ri == len(p.runs)-1 {
+				text = strings.TrimSuffix(text, "\n")
+			}
+			n := docindex.UTF16Len(text)
+			if n > 0 {
+				style := google.TextStyle{}
+				if run.style != nil {
+					style = *run.style
+				}
+				formats = append(formats, google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+					Range: google.Range{StartIndex: pos, EndIndex: pos + n, TabID: r.opts.TabID}, TextStyle: style, Fields: "*",
+				}})
+			}
+			sb.WriteString(text)
+			pos += n
+		}
+		end := max(pos, start+1)
+		style, fields := paragraphStyle(p.style)
+		formats = append(formats, google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+			Range: google.Range{StartIndex: start, EndIndex: end, TabID: r.opts.TabID}, ParagraphStyle: style, Fields: fields,
+		}})
+		if p.bullet != nil {
+			bulleted = append(bulleted, span{start, end, markdown.BulletPreset(r.opts.Lists, p.bullet)})
+		}
+	}
+	if sb.Len() == 0 {
+		return nil
+	}
+	for i := 0; i < len(bulleted); {
+		j := i
+		for j+1 < len(bulleted) && bulleted[j+1].start == bulleted[j].end && bulleted[j+1].preset == bulleted[i].preset {
+			j++
+		}
+		formats = append(formats, google.Request{CreateParagraphBullets: &google.CreateParagraphBulletsRequest{
+			Range:        google.Range{StartIndex: bulleted[i].start, EndIndex: bulleted[j].end, TabID: r.opts.TabID},
+			BulletPreset: bulleted[i].preset,
+		}})
+		i = j + 1
+	}
+	insert := google.Request{InsertText: &google.InsertTextRequest{
+		Location: google.Location{Index: index, TabID: r.opts.TabID},
+		Text:     sb.String(),
+	}}
+	return append([]google.Request{insert}, formats...)
+}
+
+// paragraphStyle copies a paragraph's style for an update request, with
+// the field mask naming every property it sets.
+func paragraphStyle(s *google.ParagraphStyle) (google.ParagraphStyle, string) {
+	if s == nil {
+		return google.ParagraphStyle{NamedStyleType: "NORMAL_TEXT"}, "namedStyleType"
+	}
+	style := *s
+	style.HeadingID = ""
+	if style.NamedStyleType == "" {
+		style.NamedStyleType = "NORMAL_TEXT"
+	}
+	fields := []string{"namedStyleType"}
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"alignment", style.Alignment != ""},
+		{"indentStart", style.IndentStart != nil},
+		{"indentEnd", style.IndentEnd != nil},
+		{"indentFirstLine", style.IndentFirstLine != nil},
+		{"spaceAbove", style.SpaceAbove != nil},
+		{"spaceBelow", style.SpaceBelow != nil},
+		{"keepWithNext", style.KeepWithNext != nil},
+		{"borderLeft", style.BorderLeft != nil},
+		{"borderBottom", style.BorderBottom != nil},
+	} {
+		if f.set {
+			fields = append(fields, f.name)
+		}
+	}
+	return style, strings.Join(fields, ",")
+}
@@ -0,0 +1,242 @@
+package doctemplate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amarbel-llc/piers/internal/google"
+)
+
+// paragraphs lays out ASCII paragraphs from index 1 the way the Docs API
+// reports them.
+func paragraphs(texts ...string) *google.DocumentBody {
+	body := &google.DocumentBody{}
+	index := 1
+	for _, text := range texts {
+		end := index + len(text)
+		body.Content = append(body.Content, google.ContentElement{StartIndex: index, EndIndex: end, Paragraph: &google.Paragraph{
+			Elements: []google.ParagraphElement{{StartIndex: index, EndIndex: end, TextRun: &google.TextRun{Content: text}}},
+		}})
+		index = end
+	}
+	return body
+}
+
+// apply runs the text edits in requests against the body's text, which
+// starts at index 1.
+func apply(t *testing.T, body *google.DocumentBody, requests []google.Request) string {
+	t.Helper()
+	var sb strings.Builder
+	for _, el := range body.Content {
+		sb.WriteString(paragraphText(el.Paragraph))
+	}
+	text := sb.String()
+	for _, r := range requests {
+		switch {
+		case r.InsertText != nil:
+			i := r.InsertText.Location.Index - 1
+			text = text[:i] + r.InsertText.Text + text[i:]
+		case r.DeleteContentRange != nil:
+			rng := r.DeleteContentRange.Range
+			text = text[:rng.StartIndex-1] + text[rng.EndIndex-1:]
+		case r.InsertInlineImage != nil:
+			i := r.InsertInlineImage.Location.Index - 1
+			text = text[:i] + "[img]" + text[i:]
+		}
+	}
+	return text
+}
+
+func render(t *testing.T, data map[string]any, texts ...string) (string, Report) {
+	t.Helper()
+	body := paragraphs(texts...)
+	requests, report := Render(body, data, Options{})
+	return apply(t, body, requests), report
+}
+
+func TestPlaceholders(t *testing.T) {
+	got, report := render(t, map[string]any{
+		"name":     "Ada",
+		"customer": map[string]any{"city": "London"},
+		"total":    12.5,
+	}, "Dear {{name}} of {{ customer.city }},\n", "You owe {{total}}. {{missing}}\n")
+	if want := "Dear Ada of London,\nYou owe 12.5. {{missing}}\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(report.Unmatched, []string{"missing"}) {
+		t.Errorf("unmatched = %v, want [missing]", report.Unmatched)
+	}
+}
+
+func TestReplaceKeepsPlaceholderFormatting(t *testing.T) {
+	requests, _ := Render(paragraphs("Hi {{name}}\n"), map[string]any{"name": "Bo"}, Options{})
+	// The value goes in after the placeholder's first brace, then the
+	// rest of the placeholder and the brace are deleted.
+	if ins := requests[0].InsertText; ins == nil || ins.Location.Index != 5 || ins.Text != "Bo" {
+		t.Fatalf("first request = %+v, want insert of Bo at 5", requests[0])
+	}
+}
+
+func TestInlineSections(t *testing.T) {
+	tests := []struct {
+		data map[string]any
+		want string
+	}{
+		{map[string]any{"vip": true, "tags": []any{"a", "b"}}, "Hi VIP! [a][b]\n"},
+		{map[string]any{"vip": false, "tags": []any{}}, "Hi! none\n"},
+	}
+	for _, tt := range tests {
+		got, _ := render(t, tt.data, "Hi{{#vip}} VIP{{/vip}}! {{#tags}}[{{.}}]{{/tags}}{{^tags}}none{{/tags}}\n")
+		if got != tt.want {
+			t.Errorf("data %v: got %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestBlockSections(t *testing.T) {
+	template := []string{"Items:\n", "{{#items}}\n", "- {{name}} x{{qty}}\n", "{{/items}}\n", "{{^items}}\n", "None.\n", "{{/items}}\n", "End\n"}
+	items := []any{
+		map[string]any{"name": "a", "qty": 1.0},
+		map[string]any{"name": "b", "qty": 2.0},
+	}
+	got, report := render(t, map[string]any{"items": items}, template...)
+	if want := "Items:\n- a x1\n- b x2\nEnd\n"; got != want {
+		t.Errorf("list: got %q, want %q", got, want)
+	}
+	if len(report.Unmatched) != 0 || len(report.Errors) != 0 {
+		t.Errorf("report = %+v, want empty", report)
+	}
+
+	got, _ = render(t, map[string]any{"items": []any{}}, template...)
+	if want := "Items:\nNone.\nEnd\n"; got != want {
+		t.Errorf("empty list: got %q, want %q", got, want)
+	}
+}
+
+func TestBlockSectionAtEndOfBody(t *testing.T) {
+	got, _ := render(t, map[string]any{"x": true}, "A\n", "{{#x}}\n", "B\n", "{{/x}}\n")
+	if want := "A\nB\n\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBlockSectionRestoresStyles(t *testing.T) {
+	body := paragraphs("{{#items}}\n", "{{.}}\n", "{{/items}}\n", "End\n")
+	body.Content[1].Paragraph.ParagraphStyle = &google.ParagraphStyle{NamedStyleType: "HEADING_2", HeadingID: "h.x"}
+	body.Content[1].Paragraph.Elements[0].TextRun.TextStyle = &google.TextStyle{Bold: google.Bool(true)}
+	requests, _ := Render(body, map[string]any{"items": []any{"one", "two"}}, Options{})
+
+	var headings, bold int
+	for _, r := range requests {
+		if p := r.UpdateParagraphStyle; p != nil && p.ParagraphStyle.NamedStyleType == "HEADING_2" {
+			if p.ParagraphStyle.HeadingID != "" {
+				t.Error("heading ID copied into an update request")
+			}
+			headings++
+		}
+		if s := r.UpdateTextStyle; s != nil && google.Flag(s.TextStyle.Bold) {
+			bold++
+		}
+	}
+	if headings != 2 || bold != 2 {
+		t.Errorf("got %d headings and %d bold runs, want 2 of each", headings, bold)
+	}
+}
+
+func TestTableRows(t *testing.T) {
+	cell := func(start int, text string) google.TableCell {
+		return google.TableCell{StartIndex: start - 1, EndIndex: start + len(text), Content: []google.ContentElement{{
+			StartIndex: start, EndIndex: start + len(text), Paragraph: &google.Paragraph{Elements: []google.ParagraphElement{
+				{StartIndex: start, EndIndex: start + len(text), TextRun: &google.TextRun{Content: text}},
+			}},
+		}}}
+	}
+	// Table at 10: a header row, then a row repeated over "rows".
+	body := &google.DocumentBody{Content: []google.ContentElement{{StartIndex: 10, EndIndex: 62, Table: &google.Table{TableRows: []google.TableRow{
+		{TableCells: []google.TableCell{cell(13, "Name\n"), cell(19, "Qty\n")}},
+		{TableCells: []google.TableCell{cell(25, "{{#rows}}{{name}}\n"), cell(44, "{{qty}}{{/rows}}\n")}},
+	}}}}}
+
+	rows := []any{map[string]any{"name": "a", "qty": 1.0}, map[string]any{"name": "b", "qty": 2.0}}
+	requests, report := Render(body, map[string]any{"rows": rows}, Options{})
+	if len(report.Unmatched) != 0 || len(report.Errors) != 0 {
+		t.Errorf("report = %+v, want empty", report)
+	}
+	var edits []string
+	for _, r := range requests {
+		switch {
+		case r.InsertTableRow != nil:
+			edits = append(edits, fmt.Sprintf("row below %d", r.InsertTableRow.TableCellLocation.RowIndex))
+		case r.InsertText != nil:
+			edits = append(edits, fmt.Sprintf("%s@%d", r.InsertText.Text, r.InsertText.Location.Index))
+		case r.DeleteContentRange != nil:
+			edits = append(edits, fmt.Sprintf("del %d-%d", r.DeleteContentRange.Range.StartIndex, r.DeleteContentRange.Range.EndIndex))
+		}
+	}
+	// The new row starts where the template row ends (61), and each empty
+	// cell takes a marker and a newline.
+	want := []string{"row below 1", "2@65", "b@63", "del 44-60", "1@44", "del 25-42", "a@25"}
+	if !reflect.DeepEqual(edits, want) {
+		t.Errorf("got %v\nwant %v", edits, want)
+	}
+
+	requests, _ = Render(body, map[string]any{"rows": []any{}}, Options{})
+	if len(requests) != 1 || requests[0].DeleteTableRow == nil || requests[0].DeleteTableRow.TableCellLocation.RowIndex != 1 {
+		t.Errorf("empty rows: got %+v, want one deleteTableRow of row 1", requests)
+	}
+}
+
+func TestImages(t *testing.T) {
+	body := paragraphs("Logo: {{image:logo}}\n")
+	requests, _ := Render(body, map[string]any{"logo": map[string]any{"url": "https://example.com/l.png", "width": 50.0, "height": 20.0}}, Options{})
+	if got := apply(t, body, requests); got != "Logo: [img]\n" {
+		t.Errorf("got %q", got)
+	}
+	img := requests[1].InsertInlineImage
+	if img == nil || img.URI != "https://example.com/l.png" || img.ObjectSize == nil || img.ObjectSize.Width.Magnitude != 50 {
+		t.Errorf("image request = %+v", requests[1])
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	_, report := render(t, map[string]any{"a": true}, "{{#a}} never closed\n", "stray {{/b}}\n")
+	if len(report.Errors) != 2 {
+		t.Errorf("errors = %v, want 2", report.Errors)
+	}
+}
+
+func TestText(t *testing.T) {
+	got, report := Text("Invoice {{number}} for {{customer.name}}{{#vip}} (VIP){{/vip}} {{due}}", map[string]any{
+		"number":   "42",
+		"customer": map[string]any{"name": "Ada"},
+		"vip":      true,
+	})
+	if want := "Invoice 42 for Ada (VIP) {{due}}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(report.Unmatched, []string{"due"}) {
+		t.Errorf("unmatched = %v, want [due]", report.Unmatched)
+	}
+}
+
+func TestRenderEach(t *testing.T) {
+	body := paragraphs("Dear {{name}},\n", "Thanks.\n")
+	requests, report := RenderEach(body, []map[string]any{{"name": "Ada"}, {"name": "Bo"}}, Options{})
+	if len(report.Unmatched) != 0 || len(report.Errors) != 0 {
+		t.Errorf("report = %+v, want empty", report)
+	}
+	if got, want := apply(t, body, requests), "Dear Ada,\nThanks.\nDear Bo,\nThanks.\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	last := requests[len(requests)-1].InsertPageBreak
+	if last == nil || last.Location.Index != 1+len("Dear Ada,\nThanks.\n") {
+		t.Errorf("last request = %+v, want a page break before the second copy", requests[len(requests)-1])
+	}
+
+	tabled := &google.DocumentBody{Content: []google.ContentElement{{StartIndex: 1, EndIndex: 10, Table: &google.Table{}}}}
+	if requests, report := RenderEach(tabled, []map[string]any{{}}, Options{}); requests != nil || len(report.Errors) != 1 {
+		t.Errorf("tables: got %d requests and errors %v, want none and one error", len(requests), report.Errors)
+	}
+}
@@ -1,10 +1,62 @@
 package google
 
+import "fmt"
+
 type Document struct {
-	DocumentID string        `json:"documentId"`
-	Title      string        `json:"title"`
-	Body       *DocumentBody `json:"body,omitempty"`
-	Tabs       []any         `json:"tabs,omitempty"`
+	DocumentID    string                  `json:"documentId"`
+	Title         string                  `json:"title"`
+	RevisionID    string                  `json:"revisionId,omitempty"`
+	Body          *DocumentBody           `json:"body,omitempty"`
+	Tabs          []Tab                   `json:"tabs,omitempty"`
+	Lists         map[string]List         `json:"lists,omitempty"`
+	Footnotes     map[string]Footnote     `json:"footnotes,omitempty"`
+	InlineObjects map[string]InlineObject `json:"inlineObjects,omitempty"`
+	NamedRanges   map[string]NamedRanges  `json:"namedRanges,omitempty"`
+	Headers       map[string]Header       `json:"headers,omitempty"`
+	Footers       map[string]Footer       `json:"footers,omitempty"`
+	DocumentStyle *DocumentStyle          `json:"documentStyle,omitempty"`
+	NamedStyles   *NamedStyles            `json:"namedStyles,omitempty"`
+}
+
+type Tab struct {
+	TabProperties TabProperties `json:"tabProperties"`
+	DocumentTab   *DocumentTab  `json:"documentTab,omitempty"`
+	ChildTabs     []Tab         `json:"childTabs,omitempty"`
+}
+
+type TabProperties struct {
+	TabID        string `json:"tabId"`
+	Title        string `json:"title,omitempty"`
+	Index        int    `json:"index"`
+	NestingLevel int    `json:"nestingLevel,omitempty"`
+}
+
+// DocumentTab holds a tab's body together with the lists, footnotes,
+// inline objects, headers and footers its content refers to. Legacy documents without tabs carry
+// the same fields on Document itself.
+type DocumentTab struct {
+	Body          *DocumentBody           `json:"body,omitempty"`
+	Lists         map[string]List         `json:"lists,omitempty"`
+	Footnotes     map[string]Footnote     `json:"footnotes,omitempty"`
+	InlineObjects map[string]InlineObject `json:"inlineObjects,omitempty"`
+	NamedRanges   map[string]NamedRanges  `json:"namedRanges,omitempty"`
+	Headers       map[string]Header       `json:"headers,omitempty"`
+	Footers       map[string]Footer       `json:"footers,omitempty"`
+	DocumentStyle *DocumentStyle          `json:"documentStyle,omitempty"`
+	NamedStyles   *NamedStyles            `json:"namedStyles,omitempty"`
+}
+
+// NamedRanges holds every named range sharing one name, keyed by that
+// name in Document.NamedRanges.
+type NamedRanges struct {
+	Name        string       `json:"name"`
+	NamedRanges []NamedRange `json:"namedRanges,omitempty"`
+}
+
+type NamedRange struct {
+	NamedRangeID string  `json:"namedRangeId"`
+	Name         string  `json:"name"`
+	Ranges       []Range `json:"ranges,omitempty"`
 }
 
 type DocumentBody struct {
@@ -12,36 +64,198 @@ type DocumentBody struct {
 }
 
 type ContentElement struct {
-	Paragraph *Paragraph `json:"paragraph,omitempty"`
-	Table     *Table     `json:"table,omitempty"`
+	StartIndex   int           `json:"startIndex,omitempty"`
+	EndIndex     int           `json:"endIndex,omitempty"`
+	Paragraph    *Paragraph    `json:"paragraph,omitempty"`
+	Table        *Table        `json:"table,omitempty"`
+	SectionBreak *SectionBreak `json:"sectionBreak,omitempty"`
 }
 
+type SectionBreak struct{}
+
 type Paragraph struct {
-	Elements []ParagraphElement `json:"elements,omitempty"`
+	Elements       []ParagraphElement `json:"elements,omitempty"`
+	ParagraphStyle *ParagraphStyle    `json:"paragraphStyle,omitempty"`
+	Bullet         *Bullet            `json:"bullet,omitempty"`
+}
+
+type Bullet struct {
+	ListID       string `json:"listId"`
+	NestingLevel int    `json:"nestingLevel,omitempty"`
 }
 
 type ParagraphElement struct {
-	TextRun *TextRun `json:"textRun,omitempty"`
+	StartIndex          int                  `json:"startIndex,omitempty"`
+	EndIndex            int                  `json:"endIndex,omitempty"`
+	TextRun             *TextRun             `json:"textRun,omitempty"`
+	InlineObjectElement *InlineObjectElement `json:"inlineObjectElement,omitempty"`
+	FootnoteReference   *FootnoteReference   `json:"footnoteReference,omitempty"`
+	HorizontalRule      *HorizontalRule      `json:"horizontalRule,omitempty"`
 }
 
+// TextRun carries the IDs of any suggestions it is part of when the
+// document is read with SuggestionsInline.
 type TextRun struct {
-	Content string `json:"content"`
+	Content                   string                        `json:"content"`
+	TextStyle                 *TextStyle                    `json:"textStyle,omitempty"`
+	SuggestedInsertionIDs     []string                      `json:"suggestedInsertionIds,omitempty"`
+	SuggestedDeletionIDs      []string                      `json:"suggestedDeletionIds,omitempty"`
+	SuggestedTextStyleChanges map[string]SuggestedTextStyle `json:"suggestedTextStyleChanges,omitempty"`
+}
+
+type SuggestedTextStyle struct {
+	TextStyle *TextStyle `json:"textStyle,omitempty"`
+}
+
+type InlineObjectElement struct {
+	InlineObjectID string `json:"inlineObjectId"`
+}
+
+type FootnoteReference struct {
+	FootnoteID     string `json:"footnoteId"`
+	FootnoteNumber string `json:"footnoteNumber,omitempty"`
+}
+
+type HorizontalRule struct{}
+
+type List struct {
+	ListProperties ListProperties `json:"listProperties"`
+}
+
+type ListProperties struct {
+	NestingLevels []NestingLevel `json:"nestingLevels,omitempty"`
+}
+
+// NestingLevel describes one level of a list. Ordered levels set GlyphType,
+// bulleted levels set GlyphSymbol, and checkbox levels set neither.
+type NestingLevel struct {
+	GlyphType   string `json:"glyphType,omitempty"`
+	GlyphSymbol string `json:"glyphSymbol,omitempty"`
+}
+
+// DocumentStyle names the headers and footers in use. The first page and
+// even page variants only apply when their Use flags are set.
+type DocumentStyle struct {
+	DefaultHeaderID          string `json:"defaultHeaderId,omitempty"`
+	DefaultFooterID          string `json:"defaultFooterId,omitempty"`
+	FirstPageHeaderID        string `json:"firstPageHeaderId,omitempty"`
+	FirstPageFooterID        string `json:"firstPageFooterId,omitempty"`
+	EvenPageHeaderID         string `json:"evenPageHeaderId,omitempty"`
+	EvenPageFooterID         string `json:"evenPageFooterId,omitempty"`
+	UseFirstPageHeaderFooter bool   `json:"useFirstPageHeaderFooter,omitempty"`
+	UseEvenPageHeaderFooter  bool   `json:"useEvenPageHeaderFooter,omitempty"`
+
+	Background          *Background `json:"background,omitempty"`
+	PageSize            *Size       `json:"pageSize,omitempty"`
+	FlipPageOrientation bool        `json:"flipPageOrientation,omitempty"`
+	MarginTop           *Dimension  `json:"marginTop,omitempty"`
+	MarginBottom        *Dimension  `json:"marginBottom,omitempty"`
+	MarginLeft          *Dimension  `json:"marginLeft,omitempty"`
+	MarginRight         *Dimension  `json:"marginRight,omitempty"`
+	MarginHeader        *Dimension  `json:"marginHeader,omitempty"`
+	MarginFooter        *Dimension  `json:"marginFooter,omitempty"`
+	PageNumberStart     int         `json:"pageNumberStart,omitempty"`
+}
+
+type Background struct {
+	Color *OptionalColor `json:"color,omitempty"`
+}
+
+// NamedStyles holds the definitions of NORMAL_TEXT, TITLE, HEADING_1 and
+// the other named paragraph styles. The API reports them but offers no
+// request to change them.
+type NamedStyles struct {
+	Styles []NamedStyle `json:"styles,omitempty"`
+}
+
+type NamedStyle struct {
+	NamedStyleType string          `json:"namedStyleType"`
+	TextStyle      *TextStyle      `json:"textStyle,omitempty"`
+	ParagraphStyle *ParagraphStyle `json:"paragraphStyle,omitempty"`
+}
+
+type Header struct {
+	HeaderID string           `json:"headerId"`
+	Content  []ContentElement `json:"content,omitempty"`
+}
+
+type Footer struct {
+	FooterID string           `json:"footerId"`
+	Content  []ContentElement `json:"content,omitempty"`
+}
+
+type Footnote struct {
+	FootnoteID string           `json:"footnoteId"`
+	Content    []ContentElement `json:"content,omitempty"`
+}
+
+type InlineObject struct {
+	ObjectID               string                 `json:"objectId"`
+	InlineObjectProperties InlineObjectProperties `json:"inlineObjectProperties"`
+}
+
+type InlineObjectProperties struct {
+	EmbeddedObject EmbeddedObject `json:"embeddedObject"`
+}
+
+type EmbeddedObject struct {
+	Title           string           `json:"title,omitempty"`
+	Description     string           `json:"description,omitempty"`
+	Size            *Size            `json:"size,omitempty"`
+	ImageProperties *ImageProperties `json:"imageProperties,omitempty"`
+}
+
+type ImageProperties struct {
+	ContentURI string `json:"contentUri,omitempty"`
+	SourceURI  string `json:"sourceUri,omitempty"`
 }
 
 type Table struct {
+	Rows      int        `json:"rows,omitempty"`
+	Columns   int        `json:"columns,omitempty"`
 	TableRows []TableRow `json:"tableRows,omitempty"`
 }
 
 type TableRow struct {
+	StartIndex int         `json:"startIndex,omitempty"`
+	EndIndex   int         `json:"endIndex,omitempty"`
 	TableCells []TableCell `json:"tableCells,omitempty"`
 }
 
 type TableCell struct {
-	Content []ContentElement `json:"content,omitempty"`
+	StartIndex     int              `json:"startIndex,omitempty"`
+	EndIndex       int              `json:"endIndex,omitempty"`
+	Content        []ContentElement `json:"content,omitempty"`
+	TableCellStyle *TableCellStyle  `json:"tableCellStyle,omitempty"`
+}
+
+// Suggestion view modes for DocsService.GetView. SuggestionsInline shows
+// suggested insertions and deletions in place, with indices matching what
+// batchUpdate expects; the preview modes show the document as if every
+// suggestion were accepted or rejected.
+const (
+	SuggestionsInline          = "SUGGESTIONS_INLINE"
+	PreviewSuggestionsAccepted = "PREVIEW_SUGGESTIONS_ACCEPTED"
+	PreviewWithoutSuggestions  = "PREVIEW_WITHOUT_SUGGESTIONS"
+)
+
+// ConflictError reports a batchUpdate refused because the document changed
+// after the revision its WriteControl required.
+type ConflictError struct {
+	DocumentID         string
+	RequiredRevisionID string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflict: document %s has changed since revision %s; read it again for the current indices and revisionId", e.DocumentID, e.RequiredRevisionID)
 }
 
 type DocsService interface {
 	Get(documentID string) (*Document, error)
-	BatchUpdate(documentID string, requests any) error
+	GetView(documentID string, suggestionsViewMode string) (*Document, error)
+	// BatchUpdate applies requests in order. A nil control applies them to
+	// the latest revision; otherwise a RequiredRevisionID that is no longer
+	// the latest fails with a *ConflictError.
+	BatchUpdate(documentID string, requests []Request, control *WriteControl) (*BatchUpdateResponse, error)
 	Create(title string) (*Document, error)
 }
@@ -0,0 +1,436 @@
+package google
+
+// WriteControl ties a batchUpdate to the revision its indices were read
+// from. With RequiredRevisionID the update is refused if anyone has edited
+// the document since; with TargetRevisionID it is applied as if made at
+// that revision and merged with the edits made since, its indices moved to
+// follow them. Only one may be set.
+type WriteControl struct {
+	RequiredRevisionID string `json:"requiredRevisionId,omitempty"`
+	TargetRevisionID   string `json:"targetRevisionId,omitempty"`
+}
+
+// BatchUpdateResponse is the result of a documents.batchUpdate call: a
+// reply for each request, in order and empty for requests that have none,
+// and the revision the update left the document at.
+type BatchUpdateResponse struct {
+	Replies      []Response    `json:"replies"`
+	WriteControl *WriteControl `json:"writeControl,omitempty"`
+}
+
+// Response is the reply to one Request. At most one field is set.
+type Response struct {
+	ReplaceAllText *ReplaceAllTextResponse `json:"replaceAllText,omitempty"`
+}
+
+type ReplaceAllTextResponse struct {
+	OccurrencesChanged int `json:"occurrencesChanged"`
+}
+
+// Request is a single entry of a documents.batchUpdate call. Exactly one
+// field is set, mirroring the Docs API's union encoding.
+type Request struct {
+	InsertText                  *InsertTextRequest                  `json:"insertText,omitempty"`
+	DeleteContentRange          *DeleteContentRangeRequest          `json:"deleteContentRange,omitempty"`
+	InsertTable                 *InsertTableRequest                 `json:"insertTable,omitempty"`
+	InsertPageBreak             *InsertPageBreakRequest             `json:"insertPageBreak,omitempty"`
+	InsertInlineImage           *InsertInlineImageRequest           `json:"insertInlineImage,omitempty"`
+	UpdateTextStyle             *UpdateTextStyleRequest             `json:"updateTextStyle,omitempty"`
+	UpdateParagraphStyle        *UpdateParagraphStyleRequest        `json:"updateParagraphStyle,omitempty"`
+	UpdateTableCellStyle        *UpdateTableCellStyleRequest        `json:"updateTableCellStyle,omitempty"`
+	CreateParagraphBullets      *CreateParagraphBulletsRequest      `json:"createParagraphBullets,omitempty"`
+	DeleteParagraphBullets      *DeleteParagraphBulletsRequest      `json:"deleteParagraphBullets,omitempty"`
+	ReplaceAllText              *ReplaceAllTextRequest              `json:"replaceAllText,omitempty"`
+	InsertTableRow              *InsertTableRowRequest              `json:"insertTableRow,omitempty"`
+	DeleteTableRow              *DeleteTableRowRequest              `json:"deleteTableRow,omitempty"`
+	CreateNamedRange            *CreateNamedRangeRequest            `json:"createNamedRange,omitempty"`
+	DeleteNamedRange            *DeleteNamedRangeRequest            `json:"deleteNamedRange,omitempty"`
+	ReplaceNamedRangeContent    *ReplaceNamedRangeContentRequest    `json:"replaceNamedRangeContent,omitempty"`
+	CreateHeader                *CreateHeaderFooterRequest          `json:"createHeader,omitempty"`
+	CreateFooter                *CreateHeaderFooterRequest          `json:"createFooter,omitempty"`
+	DeleteHeader                *DeleteHeaderRequest                `json:"deleteHeader,omitempty"`
+	DeleteFooter                *DeleteFooterRequest                `json:"deleteFooter,omitempty"`
+	CreateFootnote              *CreateFootnoteRequest              `json:"createFootnote,omitempty"`
+	UpdateDocumentStyle         *UpdateDocumentStyleRequest         `json:"updateDocumentStyle,omitempty"`
+	InsertTableColumn           *InsertTableColumnRequest           `json:"insertTableColumn,omitempty"`
+	DeleteTableColumn           *DeleteTableColumnRequest           `json:"deleteTableColumn,omitempty"`
+	MergeTableCells             *TableRangeRequest                  `json:"mergeTableCells,omitempty"`
+	UnmergeTableCells           *TableRangeRequest                  `json:"unmergeTableCells,omitempty"`
+	UpdateTableColumnProperties *UpdateTableColumnPropertiesRequest `json:"updateTableColumnProperties,omitempty"`
+	PinTableHeaderRows          *PinTableHeaderRowsRequest          `json:"pinTableHeaderRows,omitempty"`
+}
+
+// Location is a position in the body, or in the header, footer or footnote
+// named by SegmentID.
+type Location struct {
+	Index     int    `json:"index"`
+	SegmentID string `json:"segmentId,omitempty"`
+	TabID     string `json:"tabId,omitempty"`
+}
+
+type Range struct {
+	StartIndex int    `json:"startIndex"`
+	EndIndex   int    `json:"endIndex"`
+	SegmentID  string `json:"segmentId,omitempty"`
+	TabID      string `json:"tabId,omitempty"`
+}
+
+type InsertTextRequest struct {
+	Location Location `json:"location"`
+	Text     string   `json:"text"`
+}
+
+type DeleteContentRangeRequest struct {
+	Range Range `json:"range"`
+}
+
+type InsertTableRequest struct {
+	Location Location `json:"location"`
+	Rows     int      `json:"rows"`
+	Columns  int      `json:"columns"`
+}
+
+type InsertPageBreakRequest struct {
+	Location Location `json:"location"`
+}
+
+type InsertInlineImageRequest struct {
+	Location   Location `json:"location"`
+	URI        string   `json:"uri"`
+	ObjectSize *Size    `json:"objectSize,omitempty"`
+}
+
+type UpdateTextStyleRequest struct {
+	Range     Range     `json:"range"`
+	TextStyle TextStyle `json:"textStyle"`
+	Fields    string    `json:"fields"`
+}
+
+type UpdateParagraphStyleRequest struct {
+	Range          Range          `json:"range"`
+	ParagraphStyle ParagraphStyle `json:"paragraphStyle"`
+	Fields         string         `json:"fields"`
+}
+
+type UpdateTableCellStyleRequest struct {
+	TableRange     TableRange     `json:"tableRange"`
+	TableCellStyle TableCellStyle `json:"tableCellStyle"`
+	Fields         string         `json:"fields"`
+}
+
+type CreateParagraphBulletsRequest struct {
+	Range        Range  `json:"range"`
+	BulletPreset string `json:"bulletPreset"`
+}
+
+// DeleteParagraphBulletsRequest removes bullets, keeping each paragraph's
+// nesting visible as extra indentation.
+type DeleteParagraphBulletsRequest struct {
+	Range Range `json:"range"`
+}
+
+type ReplaceAllTextRequest struct {
+	ContainsText SubstringMatchCriteria `json:"containsText"`
+	ReplaceText  string                 `json:"replaceText"`
+	TabsCriteria *TabsCriteria          `json:"tabsCriteria,omitempty"`
+}
+
+type SubstringMatchCriteria struct {
+	Text      string `json:"text"`
+	MatchCase bool   `json:"matchCase"`
+}
+
+// TabsCriteria limits a request to the listed tabs; without it the request
+// applies to every tab.
+type TabsCriteria struct {
+	TabIDs []string `json:"tabIds"`
+}
+
+type InsertTableRowRequest struct {
+	TableCellLocation TableCellLocation `json:"tableCellLocation"`
+	InsertBelow       bool              `json:"insertBelow"`
+}
+
+type DeleteTableRowRequest struct {
+	TableCellLocation TableCellLocation `json:"tableCellLocation"`
+}
+
+type InsertTableColumnRequest struct {
+	TableCellLocation TableCellLocation `json:"tableCellLocation"`
+	InsertRight       bool              `json:"insertRight"`
+}
+
+type DeleteTableColumnRequest struct {
+	TableCellLocation TableCellLocation `json:"tableCellLocation"`
+}
+
+// TableRangeRequest is the body of mergeTableCells and unmergeTableCells.
+type TableRangeRequest struct {
+	TableRange TableRange `json:"tableRange"`
+}
+
+// UpdateTableColumnPropertiesRequest updates the given columns, or every
+// column when ColumnIndices is empty.
+type UpdateTableColumnPropertiesRequest struct {
+	TableStartLocation    Location              `json:"tableStartLocation"`
+	ColumnIndices         []int                 `json:"columnIndices,omitempty"`
+	TableColumnProperties TableColumnProperties `json:"tableColumnProperties"`
+	Fields                string                `json:"fields"`
+}
+
+type TableColumnProperties struct {
+	WidthType string     `json:"widthType,omitempty"`
+	Width     *Dimension `json:"width,omitempty"`
+}
+
+type PinTableHeaderRowsRequest struct {
+	TableStartLocation    Location `json:"tableStartLocation"`
+	PinnedHeaderRowsCount int      `json:"pinnedHeaderRowsCount"`
+}
+
+type CreateNamedRangeRequest struct {
+	Name  string `json:"name"`
+	Range Range  `json:"range"`
+}
+
+// DeleteNamedRangeRequest deletes the named range with NamedRangeID, or
+// every named range called Name.
+type DeleteNamedRangeRequest struct {
+	NamedRangeID string        `json:"namedRangeId,omitempty"`
+	Name         string        `json:"name,omitempty"`
+	TabsCriteria *TabsCriteria `json:"tabsCriteria,omitempty"`
+}
+
+// ReplaceNamedRangeContentRequest replaces the content of the named range
+// with NamedRangeID, or of every named range called NamedRangeName.
+type ReplaceNamedRangeContentRequest struct {
+	NamedRangeID   string        `json:"namedRangeId,omitempty"`
+	NamedRangeName string        `json:"namedRangeName,omitempty"`
+	Text           string        `json:"text"`
+	TabsCriteria   *TabsCriteria `json:"tabsCriteria,omitempty"`
+}
+
+// CreateHeaderFooterRequest creates the default header or footer of the
+// document, or of the section starting at SectionBreakLocation.
+type CreateHeaderFooterRequest struct {
+	Type                 string    `json:"type"`
+	SectionBreakLocation *Location `json:"sectionBreakLocation,omitempty"`
+}
+
+type DeleteHeaderRequest struct {
+	HeaderID string `json:"headerId"`
+	TabID    string `json:"tabId,omitempty"`
+}
+
+type DeleteFooterRequest struct {
+	FooterID string `json:"footerId"`
+	TabID    string `json:"tabId,omitempty"`
+}
+
+// CreateFootnoteRequest inserts a footnote reference at Location, or at
+// the end of the body when EndOfSegmentLocation is set instead.
+type CreateFootnoteRequest struct {
+	Location             *Location             `json:"location,omitempty"`
+	EndOfSegmentLocation *EndOfSegmentLocation `json:"endOfSegmentLocation,omitempty"`
+}
+
+type EndOfSegmentLocation struct {
+	SegmentID string `json:"segmentId,omitempty"`
+	TabID     string `json:"tabId,omitempty"`
+}
+
+type UpdateDocumentStyleRequest struct {
+	DocumentStyle DocumentStyle `json:"documentStyle"`
+	Fields        string        `json:"fields"`
+	TabID         string        `json:"tabId,omitempty"`
+}
+
+type TableRange struct {
+	TableCellLocation TableCellLocation `json:"tableCellLocation"`
+	RowSpan           int               `json:"rowSpan"`
+	ColumnSpan        int               `json:"columnSpan"`
+}
+
+type TableCellLocation struct {
+	TableStartLocation Location `json:"tableStartLocation"`
+	RowIndex           int      `json:"rowIndex"`
+	ColumnIndex        int      `json:"columnIndex"`
+}
+
+// TextStyle fields are written according to the request's field mask, so a
+// zero value listed in Fields clears that property.
+// TextStyle is the character formatting of a run or named style. Its flags
+// are pointers so that a run can turn a flag off that its named style turns
+// on, as well as leave it unset to inherit it.
+type TextStyle struct {
+	Bold               *bool               `json:"bold,omitempty"`
+	Italic             *bool               `json:"italic,omitempty"`
+	Underline          *bool               `json:"underline,omitempty"`
+	Strikethrough      *bool               `json:"strikethrough,omitempty"`
+	FontSize           *Dimension          `json:"fontSize,omitempty"`
+	WeightedFontFamily *WeightedFontFamily `json:"weightedFontFamily,omitempty"`
+	ForegroundColor    *OptionalColor      `json:"foregroundColor,omitempty"`
+	BackgroundColor    *OptionalColor      `json:"backgroundColor,omitempty"`
+	Link               *Link               `json:"link,omitempty"`
+}
+
+type ParagraphStyle struct {
+	NamedStyleType  string           `json:"namedStyleType,omitempty"`
+	HeadingID       string           `json:"headingId,omitempty"`
+	Alignment       string           `json:"alignment,omitempty"`
+	IndentStart     *Dimension       `json:"indentStart,omitempty"`
+	IndentEnd       *Dimension       `json:"indentEnd,omitempty"`
+	IndentFirstLine *Dimension       `json:"indentFirstLine,omitempty"`
+	SpaceAbove      *Dimension       `json:"spaceAbove,omitempty"`
+	SpaceBelow      *Dimension       `json:"spaceBelow,omitempty"`
+	KeepWithNext    *bool            `json:"keepWithNext,omitempty"`
+	BorderLeft      *ParagraphBorder `json:"borderLeft,omitempty"`
+	BorderBottom    *ParagraphBorder `json:"borderBottom,omitempty"`
+}
+
+type TableCellStyle struct {
+	BackgroundColor *OptionalColor   `json:"backgroundColor,omitempty"`
+	PaddingTop      *Dimension       `json:"paddingTop,omitempty"`
+	PaddingBottom   *Dimension       `json:"paddingBottom,omitempty"`
+	PaddingLeft     *Dimension       `json:"paddingLeft,omitempty"`
+	PaddingRight    *Dimension       `json:"paddingRight,omitempty"`
+	BorderTop       *TableCellBorder `json:"borderTop,omitempty"`
+	BorderBottom    *TableCellBorder `json:"borderBottom,omitempty"`
+	BorderLeft      *TableCellBorder `json:"borderLeft,omitempty"`
+	BorderRight     *TableCellBorder `json:"borderRight,omitempty"`
+}
+
+type ParagraphBorder struct {
+	Color     *OptionalColor `json:"color,omitempty"`
+	Width     *Dimension     `json:"width,omitempty"`
+	Padding   *Dimension     `json:"padding,omitempty"`
+	DashStyle string         `json:"dashStyle,omitempty"`
+}
+
+type TableCellBorder struct {
+	Color     *OptionalColor `json:"color,omitempty"`
+	Width     *Dimension     `json:"width,omitempty"`
+	DashStyle string         `json:"dashStyle,omitempty"`
+}
+
+type Dimension struct {
+	Magnitude float64 `json:"magnitude"`
+	Unit      string  `json:"unit"`
+}
+
+type Size struct {
+	Width  *Dimension `json:"width,omitempty"`
+	Height *Dimension `json:"height,omitempty"`
+}
+
+type WeightedFontFamily struct {
+	FontFamily string `json:"fontFamily"`
+	Weight     int    `json:"weight,omitempty"`
+}
+
+type OptionalColor struct {
+	Color *Color `json:"color,omitempty"`
+}
+
+type Color struct {
+	RGBColor *RGBColor `json:"rgbColor,omitempty"`
+}
+
+type RGBColor struct {
+	Red   float64 `json:"red,omitempty"`
+	Green float64 `json:"green,omitempty"`
+	Blue  float64 `json:"blue,omitempty"`
+}
+
+type Link struct {
+	URL        string `json:"url,omitempty"`
+	HeadingID  string `json:"headingId,omitempty"`
+	BookmarkID string `json:"bookmarkId,omitempty"`
+}
+
+// Points returns a Dimension measured in points, the unit used throughout
+// the Docs API for sizes, indents and spacing.
+func Points(magnitude float64) *Dimension {
+	return &Dimension{Magnitude: magnitude, Unit: "PT"}
+}
+
+// Bool returns a pointer to b, for the flags of TextStyle and
+// ParagraphStyle.
+func Bool(b bool) *bool {
+	return &b
+}
+
+// Flag reports whether a style flag is set and on.
+func Flag(b *bool) bool {
+	return b != nil && *b
+}
+
+// RGB returns an OptionalColor for the given 0-1 channel values.
+func RGB(red, green, blue float64) *OptionalColor {
+	return &OptionalColor{Color: &Color{RGBColor: &RGBColor{Red: red, Green: green, Blue: blue}}}
+}
+
+// Kind returns the API name of the request's populated field, e.g.
+// "insertText".
+func (r Request) Kind() string {
+	switch {
+	case r.InsertText != nil:
+		return "insertText"
+	case r.DeleteContentRange != nil:
+		return "deleteContentRange"
+	case r.InsertTable != nil:
+		return "insertTable"
+	case r.InsertPageBreak != nil:
+		return "insertPageBreak"
+	case r.InsertInlineImage != nil:
+		return "insertInlineImage"
+	case r.UpdateTextStyle != nil:
+		return "updateTextStyle"
+	case r.UpdateParagraphStyle != nil:
+		return "updateParagraphStyle"
+	case r.UpdateTableCellStyle != nil:
+		return "updateTableCellStyle"
+	case r.CreateParagraphBullets != nil:
+		return "createParagraphBullets"
+	case r.DeleteParagraphBullets != nil:
+		return "deleteParagraphBullets"
+	case r.ReplaceAllText != nil:
+		return "replaceAllText"
+	case r.InsertTableRow != nil:
+		return "insertTableRow"
+	case r.DeleteTableRow != nil:
+		return "deleteTableRow"
+	case r.CreateNamedRange != nil:
+		return "createNamedRange"
+	case r.DeleteNamedRange != nil:
+		return "deleteNamedRange"
+	case r.ReplaceNamedRangeContent != nil:
+		return "replaceNamedRangeContent"
+	case r.CreateHeader != nil:
+		return "createHeader"
+	case r.CreateFooter != nil:
+		return "createFooter"
+	case r.DeleteHeader != nil:
+		return "deleteHeader"
+	case r.DeleteFooter != nil:
+		return "deleteFooter"
+	case r.CreateFootnote != nil:
+		return "createFootnote"
+	case r.UpdateDocumentStyle != nil:
+		return "updateDocumentStyle"
+	case r.InsertTableColumn != nil:
+		return "insertTableColumn"
+	case r.DeleteTableColumn != nil:
+		return "deleteTableColumn"
+	case r.MergeTableCells != nil:
+		return "mergeTableCells"
+	case r.UnmergeTableCells != nil:
+		return "unmergeTableCells"
+	case r.UpdateTableColumnProperties != nil:
+		return "updateTableColumnProperties"
+	case r.PinTableHeaderRows != nil:
+		return "pinTableHeaderRows"
+	default:
+		return "unknown"
+	}
+}
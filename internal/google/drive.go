@@ -1,14 +1,45 @@
 package google
 
 type DriveFile struct {
-	ID           string      `json:"id"`
-	Name         string      `json:"name"`
-	MimeType     string      `json:"mimeType,omitempty"`
-	ModifiedTime string      `json:"modifiedTime,omitempty"`
-	CreatedTime  string      `json:"createdTime,omitempty"`
-	WebViewLink  string      `json:"webViewLink,omitempty"`
-	Owners       []FileOwner `json:"owners,omitempty"`
-	Parents      []string    `json:"parents,omitempty"`
+	ID                 string              `json:"id"`
+	Name               string              `json:"name"`
+	MimeType           string              `json:"mimeType,omitempty"`
+	ModifiedTime       string              `json:"modifiedTime,omitempty"`
+	CreatedTime        string              `json:"createdTime,omitempty"`
+	WebViewLink        string              `json:"webViewLink,omitempty"`
+	WebContentLink     string              `json:"webContentLink,omitempty"`
+	Size               string              `json:"size,omitempty"`
+	ImageMediaMetadata *ImageMediaMetadata `json:"imageMediaMetadata,omitempty"`
+	Owners             []FileOwner         `json:"owners,omitempty"`
+	Parents            []string            `json:"parents,omitempty"`
+}
+
+// ImageMediaMetadata holds the pixel size Drive reports for image files.
+type ImageMediaMetadata struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Permission grants a user, group, domain or anyone a role on a file.
+type Permission struct {
+	ID           string `json:"id,omitempty"`
+	Type         string `json:"type"`
+	Role         string `json:"role"`
+	EmailAddress string `json:"emailAddress,omitempty"`
+}
+
+// Revision is a stored version of a file. Drive keeps revisions of Google
+// Docs, Sheets and Slides files for as long as it chooses and may merge
+// nearby edits into one revision; other files keep each uploaded version.
+type Revision struct {
+	ID                string            `json:"id"`
+	MimeType          string            `json:"mimeType,omitempty"`
+	ModifiedTime      string            `json:"modifiedTime,omitempty"`
+	KeepForever       bool              `json:"keepForever,omitempty"`
+	Size              string            `json:"size,omitempty"`
+	OriginalFilename  string            `json:"originalFilename,omitempty"`
+	LastModifyingUser *FileOwner        `json:"lastModifyingUser,omitempty"`
+	ExportLinks       map[string]string `json:"exportLinks,omitempty"`
 }
 
 type FileOwner struct {
@@ -30,9 +61,24 @@ type DriveService interface {
 	ListFiles(query string, pageSize int, orderBy string) ([]DriveFile, error)
 	GetFile(fileID string) (*DriveFile, error)
 	CreateFile(name string, mimeType string, parentID string) (*DriveFile, error)
+	UploadFile(name string, mimeType string, parentID string, content []byte) (*DriveFile, error)
+	// ImportFile uploads content of type mimeType and has Drive convert it
+	// to targetMimeType, a Google Docs, Sheets or Slides type.
+	ImportFile(name string, mimeType string, targetMimeType string, parentID string, content []byte) (*DriveFile, error)
 	UpdateFile(fileID string, name string, addParents string, removeParents string) (*DriveFile, error)
 	CopyFile(fileID string, name string) (*DriveFile, error)
 	DeleteFile(fileID string) error
+	// ExportFile converts a Docs, Sheets or Slides file to mimeType. Drive
+	// refuses exports larger than 10 MB.
+	ExportFile(fileID string, mimeType string) ([]byte, error)
+	// ListRevisions returns a file's revisions, oldest first.
+	ListRevisions(fileID string) ([]Revision, error)
+	// ExportRevision downloads a revision. Google files are converted to
+	// mimeType through the revision's export links; other files are
+	// returned as stored and mimeType is ignored.
+	ExportRevision(fileID string, revisionID string, mimeType string) ([]byte, error)
+	CreatePermission(fileID string, permission Permission) (*Permission, error)
+	DeletePermission(fileID string, permissionID string) error
 	ListComments(fileID string) ([]Comment, error)
 	GetComment(fileID string, commentID string) (*Comment, error)
 	CreateComment(fileID string, content string, quotedContent string) (*Comment, error)
@@ -1,5 +1,10 @@
 package google
 
+import (
+	"fmt"
+	"strings"
+)
+
 func newMockClient() *Client {
 	return &Client{
 		Docs:   &mockDocsService{},
@@ -10,24 +15,203 @@ func newMockClient() *Client {
 
 type mockDocsService struct{}
 
+// Extra mock documents for tools that need a table, lists or suggestions
+// to work on.
+const (
+	mockTableDocumentID   = "mock-table-doc-id"
+	mockListDocumentID    = "mock-list-doc-id"
+	mockSuggestDocumentID = "mock-suggest-doc-id"
+)
+
+// mockRevisionID is the latest revision of every mock document.
+const mockRevisionID = "mock-revision-1"
+
 func (m *mockDocsService) Get(documentID string) (*Document, error) {
+	if documentID == mockSuggestDocumentID {
+		return mockSuggestionDocument(), nil
+	}
+	if documentID == mockListDocumentID {
+		return mockListDocument(), nil
+	}
+	if documentID == mockTableDocumentID {
+		table, end := mockTable(19, [][]string{{"Region", "Sales"}, {"North", "10"}})
+		return &Document{
+			DocumentID: mockTableDocumentID,
+			RevisionID: mockRevisionID,
+			Title:      "Mock Table Document",
+			Body: &DocumentBody{Content: []ContentElement{
+				mockParagraph(1, "Quarterly numbers\n", ""),
+				table,
+				mockParagraph(end, "\n", ""),
+			}},
+		}, nil
+	}
+	// The Risks heading turns off the bold its named style sets.
+	risks := mockParagraph(62, "Risks\n", "h.risks")
+	risks.Paragraph.Elements[0].TextRun.TextStyle = &TextStyle{Bold: Bool(false)}
 	return &Document{
 		DocumentID: "mock-doc-id-123",
+		RevisionID: mockRevisionID,
 		Title:      "Mock Document",
 		Body: &DocumentBody{
 			Content: []ContentElement{
-				{Paragraph: &Paragraph{
-					Elements: []ParagraphElement{
-						{TextRun: &TextRun{Content: "Hello from the mock document.\n"}},
-					},
-				}},
+				mockParagraph(1, "Hello from the mock document.\n", ""),
+				mockParagraph(31, "Overview\n", "h.overview"),
+				mockParagraph(40, "The overview section.\n", ""),
+				risks,
+				mockParagraph(68, "Nothing risky yet.\n", ""),
 			},
 		},
-		Tabs: []any{},
+		Tabs: []Tab{},
+		DocumentStyle: &DocumentStyle{
+			DefaultHeaderID: "kix.mock-header",
+			PageSize:        &Size{Width: Points(612), Height: Points(792)},
+			MarginTop:       Points(72),
+			MarginBottom:    Points(72),
+			MarginLeft:      Points(72),
+			MarginRight:     Points(72),
+		},
+		NamedStyles: &NamedStyles{Styles: []NamedStyle{
+			{NamedStyleType: "NORMAL_TEXT", TextStyle: &TextStyle{FontSize: Points(11), WeightedFontFamily: &WeightedFontFamily{FontFamily: "Arial"}}},
+			{NamedStyleType: "HEADING_1", TextStyle: &TextStyle{FontSize: Points(20), Bold: Bool(true)}, ParagraphStyle: &ParagraphStyle{SpaceAbove: Points(20), SpaceBelow: Points(6)}},
+		}},
+		Headers: map[string]Header{
+			"kix.mock-header": {HeaderID: "kix.mock-header", Content: []ContentElement{
+				{EndIndex: 12, Paragraph: &Paragraph{Elements: []ParagraphElement{{EndIndex: 12, TextRun: &TextRun{Content: "Mock header\n"}}}}},
+			}},
+		},
+		NamedRanges: map[string]NamedRanges{
+			"exec-summary": {Name: "exec-summary", NamedRanges: []NamedRange{{
+				NamedRangeID: "kix.mock-range",
+				Name:         "exec-summary",
+				Ranges:       []Range{{StartIndex: 40, EndIndex: 61}},
+			}}},
+		},
 	}, nil
 }
 
-func (m *mockDocsService) BatchUpdate(documentID string, requests any) error { return nil }
+func (m *mockDocsService) GetView(documentID, mode string) (*Document, error) {
+	return m.Get(documentID)
+}
+
+// mockSuggestionDocument holds one paragraph with a suggested deletion
+// followed by a suggested insertion.
+func mockSuggestionDocument() *Document {
+	runs := []struct {
+		text     string
+		ins, del string
+	}{{"The ", "", ""}, {"old ", "", "suggest.del"}, {"new ", "suggest.ins", ""}, {"plan is ready.\n", "", ""}}
+	p := &Paragraph{}
+	index := 1
+	for _, r := range runs {
+		run := &TextRun{Content: r.text}
+		if r.ins != "" {
+			run.SuggestedInsertionIDs = []string{r.ins}
+		}
+		if r.del != "" {
+			run.SuggestedDeletionIDs = []string{r.del}
+		}
+		p.Elements = append(p.Elements, ParagraphElement{StartIndex: index, EndIndex: index + len(r.text), TextRun: run})
+		index += len(r.text)
+	}
+	return &Document{
+		DocumentID: mockSuggestDocumentID,
+		RevisionID: mockRevisionID,
+		Title:      "Mock Suggestion Document",
+		Body:       &DocumentBody{Content: []ContentElement{{StartIndex: 1, EndIndex: index, Paragraph: p}}},
+	}
+}
+
+// mockListDocument holds plain paragraphs typed as a markdown-style list
+// followed by a real two-level bulleted list.
+func mockListDocument() *Document {
+	var content []ContentElement
+	index := 1
+	for _, text := range []string{"Groceries\n", "- apples\n", "  - green\n", "- pears\n", "1. wash\n", "Packed\n", "Passport\n", "\n"} {
+		p := mockParagraph(index, text, "")
+		content = append(content, p)
+		index = p.EndIndex
+	}
+	content[5].Paragraph.Bullet = &Bullet{ListID: "kix.mock-list"}
+	content[6].Paragraph.Bullet = &Bullet{ListID: "kix.mock-list", NestingLevel: 1}
+	return &Document{
+		DocumentID: mockListDocumentID,
+		RevisionID: mockRevisionID,
+		Title:      "Mock List Document",
+		Body:       &DocumentBody{Content: content},
+		Lists: map[string]List{
+			"kix.mock-list": {ListProperties: ListProperties{NestingLevels: []NestingLevel{{GlyphSymbol: "●"}, {GlyphSymbol: "○"}}}},
+		},
+	}
+}
+
+// mockTable lays out a table of single-paragraph cells at start the way
+// the Docs API does, returning it and the index just after it.
+func mockTable(start int, cells [][]string) (ContentElement, int) {
+	t := &Table{Rows: len(cells), Columns: len(cells[0])}
+	index := start + 1
+	for _, row := range cells {
+		tr := TableRow{StartIndex: index}
+		index++
+		for _, text := range row {
+			p := mockParagraph(index+1, text+"\n", "")
+			tr.TableCells = append(tr.TableCells, TableCell{StartIndex: index, EndIndex: p.EndIndex, Content: []ContentElement{p}})
+			index = p.EndIndex
+		}
+		tr.EndIndex = index
+		t.TableRows = append(t.TableRows, tr)
+	}
+	index++
+	return ContentElement{StartIndex: start, EndIndex: index, Table: t}, index
+}
+
+// mockParagraph builds a paragraph of ASCII text at start, styled as
+// HEADING_1 with the given heading ID when headingID is set.
+func mockParagraph(start int, text, headingID string) ContentElement {
+	end := start + len(text)
+	p := &Paragraph{Elements: []ParagraphElement{{StartIndex: start, EndIndex: end, TextRun: &TextRun{Content: text}}}}
+	if headingID != "" {
+		p.ParagraphStyle = &ParagraphStyle{NamedStyleType: "HEADING_1", HeadingID: headingID}
+	}
+	return ContentElement{StartIndex: start, EndIndex: end, Paragraph: p}
+}
+
+func (m *mockDocsService) BatchUpdate(documentID string, requests []Request, control *WriteControl) (*BatchUpdateResponse, error) {
+	if control != nil && control.RequiredRevisionID != "" && control.RequiredRevisionID != mockRevisionID {
+		return nil, &ConflictError{DocumentID: documentID, RequiredRevisionID: control.RequiredRevisionID}
+	}
+	resp := &BatchUpdateResponse{Replies: make([]Response, len(requests)), WriteControl: &WriteControl{RequiredRevisionID: mockRevisionID}}
+	for i, req := range requests {
+		if req.ReplaceAllText != nil {
+			resp.Replies[i].ReplaceAllText = &ReplaceAllTextResponse{OccurrencesChanged: mockOccurrences(documentID, req.ReplaceAllText.ContainsText)}
+		}
+	}
+	return resp, nil
+}
+
+// mockOccurrences counts the matches of criteria in the body paragraphs of
+// a mock document.
+func mockOccurrences(documentID string, criteria SubstringMatchCriteria) int {
+	doc, _ := (&mockDocsService{}).Get(documentID)
+	if doc.Body == nil {
+		return 0
+	}
+	var text strings.Builder
+	for _, el := range doc.Body.Content {
+		if el.Paragraph != nil {
+			for _, pe := range el.Paragraph.Elements {
+				if pe.TextRun != nil {
+					text.WriteString(pe.TextRun.Content)
+				}
+			}
+		}
+	}
+	s, find := text.String(), criteria.Text
+	if !criteria.MatchCase {
+		s, find = strings.ToLower(s), strings.ToLower(find)
+	}
+	return strings.Count(s, find)
+}
 
 func (m *mockDocsService) Create(title string) (*Document, error) {
 	doc, _ := m.Get("")
@@ -36,6 +220,13 @@ func (m *mockDocsService) Create(title string) (*Document, error) {
 
 type mockDriveService struct{}
 
+// mockImageFile is a Drive image, returned by GetFile for its ID only.
+var mockImageFile = DriveFile{
+	ID: "mock-image-id", Name: "chart.png", MimeType: "image/png", Size: "2048",
+	WebContentLink:     "https://drive.google.com/uc?id=mock-image-id&export=download",
+	ImageMediaMetadata: &ImageMediaMetadata{Width: 800, Height: 600},
+}
+
 var mockFiles = []DriveFile{
 	{
 		ID: "mock-doc-id-123", Name: "Mock Document",
@@ -56,10 +247,32 @@ var mockFiles = []DriveFile{
 func (m *mockDriveService) ListFiles(q string, ps int, ob string) ([]DriveFile, error) {
 	return mockFiles, nil
 }
-func (m *mockDriveService) GetFile(id string) (*DriveFile, error) { return &mockFiles[0], nil }
+func (m *mockDriveService) GetFile(id string) (*DriveFile, error) {
+	if id == mockImageFile.ID {
+		return &mockImageFile, nil
+	}
+	for i := range mockFiles {
+		if mockFiles[i].ID == id {
+			return &mockFiles[i], nil
+		}
+	}
+	return &mockFiles[0], nil
+}
 func (m *mockDriveService) CreateFile(n, mt, p string) (*DriveFile, error) {
 	return &mockFiles[0], nil
 }
+func (m *mockDriveService) UploadFile(n, mt, p string, content []byte) (*DriveFile, error) {
+	return &DriveFile{
+		ID: "mock-upload-id", Name: n, MimeType: mt, Size: fmt.Sprint(len(content)),
+		WebContentLink: "https://drive.google.com/uc?id=mock-upload-id&export=download",
+	}, nil
+}
+func (m *mockDriveService) ImportFile(n, mt, target, p string, content []byte) (*DriveFile, error) {
+	return &DriveFile{
+		ID: "mock-import-id", Name: n, MimeType: target,
+		WebViewLink: "https://drive.google.com/file/d/mock-import-id/view",
+	}, nil
+}
 func (m *mockDriveService) UpdateFile(id, n, ap, rp string) (*DriveFile, error) {
 	return &mockFiles[0], nil
 }
@@ -69,6 +282,48 @@ func (m *mockDriveService) CopyFile(id, n string) (*DriveFile, error) {
 	return &f, nil
 }
 func (m *mockDriveService) DeleteFile(id string) error { return nil }
+func (m *mockDriveService) ExportFile(id, mt string) ([]byte, error) {
+	return []byte("mock export of " + id + " as " + mt), nil
+}
+
+// mockRevisions are the revisions of every mock file, oldest first, with
+// the plain text each one exports to.
+var mockRevisions = []struct {
+	Revision
+	text string
+}{
+	{
+		Revision{ID: "rev-1", MimeType: "application/vnd.google-apps.document", ModifiedTime: "2025-01-10T09:00:00.000Z",
+			LastModifyingUser: &FileOwner{DisplayName: "Test User", EmailAddress: "test@example.com"}},
+		"Hello from the mock document.\nOverview\nA first draft of the overview.\n",
+	},
+	{
+		Revision{ID: "rev-2", MimeType: "application/vnd.google-apps.document", ModifiedTime: "2025-01-15T10:30:00.000Z",
+			LastModifyingUser: &FileOwner{DisplayName: "Other User", EmailAddress: "other@example.com"}},
+		"Hello from the mock document.\nOverview\nThe overview section.\nRisks\nNothing risky yet.\n",
+	},
+}
+
+func (m *mockDriveService) ListRevisions(id string) ([]Revision, error) {
+	var revs []Revision
+	for _, r := range mockRevisions {
+		revs = append(revs, r.Revision)
+	}
+	return revs, nil
+}
+func (m *mockDriveService) ExportRevision(id, rid, mt string) ([]byte, error) {
+	for _, r := range mockRevisions {
+		if r.ID == rid {
+			return []byte(r.text), nil
+		}
+	}
+	return nil, fmt.Errorf("revision not found: %s", rid)
+}
+func (m *mockDriveService) CreatePermission(id string, p Permission) (*Permission, error) {
+	p.ID = "mock-permission-id"
+	return &p, nil
+}
+func (m *mockDriveService) DeletePermission(id, pid string) error { return nil }
 func (m *mockDriveService) ListComments(id string) ([]Comment, error) {
 	return []Comment{}, nil
 }
@@ -0,0 +1,176 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+var (
+	fencePattern              = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	footnoteDefinitionPattern = regexp.MustCompile(`^\[\^[^\]]+\]:`)
+	commentBlockPattern       = regexp.MustCompile(`^<!--(?s:.*)-->$`)
+)
+
+// Block is one top-level markdown block of a rendered tab together with the
+// body range [StartIndex, EndIndex) it was rendered from. Consecutive items
+// of a list form a single block.
+type Block struct {
+	Markdown             string
+	StartIndex, EndIndex int
+}
+
+// Blocks renders a tab the way FromDocs does but keeps the blocks apart,
+// so that edits to the markdown can be mapped back to document ranges.
+// Elements that render to nothing, such as empty paragraphs, belong to no
+// block, and footnote definitions are left out.
+func Blocks(tab *google.DocumentTab) []Block {
+	if tab == nil || tab.Body == nil {
+		return nil
+	}
+	r := newRenderer(tab)
+	var blocks []Block
+	for i, el := range tab.Body.Content {
+		before := r.out.Len()
+		r.element(i, el)
+		added := r.out.String()[before:]
+		if strings.TrimSpace(added) == "" {
+			continue
+		}
+		// A new block is always preceded by a separating newline; output
+		// without one continues the current list.
+		if n := len(blocks); n > 0 && !strings.HasPrefix(added, "\n") {
+			blocks[n-1].Markdown += added
+			blocks[n-1].EndIndex = el.EndIndex
+			continue
+		}
+		blocks = append(blocks, Block{Markdown: added, StartIndex: el.StartIndex, EndIndex: el.EndIndex})
+	}
+	for i := range blocks {
+		blocks[i].Markdown = strings.TrimSpace(blocks[i].Markdown)
+	}
+	return blocks
+}
+
+// SplitBlocks splits markdown into top-level blocks at blank lines outside
+// fenced code, matching how Blocks separates a rendered document. Footnote
+// definitions and comments, such as the header and footer notes, are
+// dropped since they are not part of the body.
+func SplitBlocks(markdown string) []string {
+	var blocks []string
+	var current []string
+	fence := ""
+	flush := func() {
+		if block := strings.TrimSpace(strings.Join(current, "\n")); block != "" && !footnoteDefinitionPattern.MatchString(block) && !commentBlockPattern.MatchString(block) {
+			blocks = append(blocks, block)
+		}
+		current = nil
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		if m := fencePattern.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+			case m[1][0] == fence[0] && len(m[1]) >= len(fence):
+				fence = ""
+			}
+		}
+		if fence == "" && strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return blocks
+}
+
+// PlainText returns the text of markdown as it reads in a document, with
+// the markup dropped: emphasis, links, heading markers and comments go,
+// list items keep a bullet or number, table cells are separated by tabs
+// and each paragraph, item or row is on its own line.
+func PlainText(markdown string) string {
+	source := []byte(markdown)
+	doc := newParser().Parser().Parse(text.NewReader(source))
+	var sb strings.Builder
+	lineStart := true
+	write := func(s string) {
+		sb.WriteString(s)
+		lineStart = strings.HasSuffix(s, "\n")
+	}
+	newLine := func() {
+		if !lineStart {
+			write("\n")
+		}
+	}
+	lines := func(n ast.Node) string {
+		var lb strings.Builder
+		for i := 0; i < n.Lines().Len(); i++ {
+			seg := n.Lines().At(i)
+			lb.Write(seg.Value(source))
+		}
+		return strings.TrimSuffix(lb.String(), "\n")
+	}
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Paragraph, *ast.TextBlock, *ast.Heading, *extast.TableRow, *extast.TableHeader, *ast.ThematicBreak:
+			newLine()
+		case *ast.ListItem:
+			newLine()
+			marker := "• "
+			if list, ok := n.Parent().(*ast.List); ok && list.IsOrdered() {
+				number := list.Start
+				for p := n.PreviousSibling(); p != nil; p = p.PreviousSibling() {
+					number++
+				}
+				marker = fmt.Sprintf("%d. ", number)
+			}
+			sb.WriteString(marker)
+		case *extast.TableCell:
+			if n.PreviousSibling() != nil {
+				write("\t")
+			}
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			newLine()
+			write(lines(n))
+			return ast.WalkSkipChildren, nil
+		case *ast.HTMLBlock:
+			if n.HTMLBlockType != ast.HTMLBlockType2 {
+				newLine()
+				write(lines(n))
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeSpan:
+			for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+				if t, ok := c.(*ast.Text); ok {
+					write(string(t.Segment.Value(source)))
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.AutoLink:
+			write(string(n.Label(source)))
+		case *ast.Image, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			write(string(unescape(n.Segment.Value(source))))
+			switch {
+			case n.HardLineBreak():
+				write("\n")
+			case n.SoftLineBreak():
+				write(" ")
+			}
+		case *ast.String:
+			write(string(n.Value))
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSuffix(sb.String(), "\n")
+}
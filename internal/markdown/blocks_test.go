@@ -0,0 +1,77 @@
+package markdown
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amarbel-llc/piers/internal/google"
+)
+
+func at(start int, el google.ContentElement) google.ContentElement {
+	el.StartIndex = start
+	el.EndIndex = start + 1
+	return el
+}
+
+func TestBlocks(t *testing.T) {
+	tab := body(
+		at(0, google.ContentElement{SectionBreak: &google.SectionBreak{}}),
+		at(1, styled("HEADING_1", para(run("Plan\n", nil)))),
+		at(2, para(run("Intro\n", nil))),
+		at(3, para(run("\n", nil))),
+		at(4, bulleted("b", 0, para(run("One\n", nil)))),
+		at(5, bulleted("b", 1, para(run("Two\n", nil)))),
+		at(6, para(run("After\n", nil))),
+	)
+	want := []Block{
+		{Markdown: "# Plan", StartIndex: 1, EndIndex: 2},
+		{Markdown: "Intro", StartIndex: 2, EndIndex: 3},
+		{Markdown: "- One\n  - Two", StartIndex: 4, EndIndex: 6},
+		{Markdown: "After", StartIndex: 6, EndIndex: 7},
+	}
+	if got := Blocks(tab); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestSplitBlocks(t *testing.T) {
+	md := "# Plan\n\nIntro\nsame paragraph\n\n- One\n  - Two\n\n```\ncode\n\nmore\n```\n\n[^1]: A note.\n"
+	want := []string{"# Plan", "Intro\nsame paragraph", "- One\n  - Two", "```\ncode\n\nmore\n```"}
+	if got := SplitBlocks(md); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestSplitBlocksMatchesBlocks(t *testing.T) {
+	tab := body(
+		styled("HEADING_2", para(run("Notes\n", nil))),
+		para(run("Some ", nil), run("bold", &google.TextStyle{Bold: google.Bool(true)}), run(" text\n", nil)),
+		table([]google.TableCell{cell(nil, para(run("a\n", nil))), cell(nil, para(run("b\n", nil)))}),
+		bulleted("b", 0, para(run("Item\n", nil))),
+	)
+	var rendered []string
+	for _, b := range Blocks(tab) {
+		rendered = append(rendered, b.Markdown)
+	}
+	if got := SplitBlocks(FromDocs(tab)); !reflect.DeepEqual(got, rendered) {
+		t.Errorf("SplitBlocks(FromDocs) = %q\nBlocks = %q", got, rendered)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct{ markdown, want string }{
+		{"## A **bold** [link](https://example.com) and `co*de`", "A bold link and co*de"},
+		{"Line one\nline two", "Line one line two"},
+		{"- One\n  - Two\n- Three", "• One\n• Two\n• Three"},
+		{"3. Third\n4. Fourth", "3. Third\n4. Fourth"},
+		{"| a | b |\n| - | - |\n| 1 | 2 |", "a\tb\n1\t2"},
+		{"```\nx := 1\n```", "x := 1"},
+		{"<!-- note -->\n\nText with <u>underline</u>", "Text with underline"},
+		{"Escaped \\*stars\\*", "Escaped *stars*"},
+	}
+	for _, tt := range tests {
+		if got := PlainText(tt.markdown); got != tt.want {
+			t.Errorf("PlainText(%q) = %q, want %q", tt.markdown, got, tt.want)
+		}
+	}
+}
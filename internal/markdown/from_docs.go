@@ -0,0 +1,577 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/google"
+)
+
+// codeFontFamilies are the monospace fonts rendered as code, including the
+// one ToRequests applies.
+var codeFontFamilies = map[string]bool{
+	CodeFontFamily: true,
+	"Courier New":  true,
+	"Consolas":     true,
+	"monospace":    true,
+}
+
+var (
+	entityPattern    = regexp.MustCompile(`^&(#[0-9]+|#[xX][0-9a-fA-F]+|[A-Za-z][A-Za-z0-9]*);`)
+	headingPrefix    = regexp.MustCompile(`^#{1,6}(\s|$)`)
+	orderedPrefix    = regexp.MustCompile(`^[0-9]{1,9}[.)](\s|$)`)
+	bulletPrefix     = regexp.MustCompile(`^[-+](\s|$)`)
+	thematicBreakish = regexp.MustCompile(`^(-[ \t]*){3,}$`)
+)
+
+// FromDocs renders a document tab as markdown that ToRequests converts back
+// into equivalent content: headings, inline styles, links, nested and
+// checkbox lists, tables, code blocks (styled 1x1 tables), blockquotes,
+// horizontal rules, images and footnotes. Headers and footers are shown as
+// HTML comments, which ToRequests skips.
+func FromDocs(tab *google.DocumentTab) string {
+	if tab == nil || tab.Body == nil {
+		return ""
+	}
+	r := newRenderer(tab)
+	r.headersFooters(false)
+	for i, el := range tab.Body.Content {
+		r.element(i, el)
+	}
+	r.footnoteDefinitions()
+	r.headersFooters(true)
+	return strings.TrimSpace(r.out.String())
+}
+
+// FromDocsElements renders the body elements [from, to) of tab as FromDocs
+// would, leaving out the header and footer notes, so a long document can be
+// read a piece at a time. Footnotes referenced in the range are defined at
+// its end, numbered from 1 within the piece.
+func FromDocsElements(tab *google.DocumentTab, from, to int) string {
+	if tab == nil || tab.Body == nil {
+		return ""
+	}
+	r := newRenderer(tab)
+	for i := max(from, 0); i < min(to, len(tab.Body.Content)); i++ {
+		r.element(i, tab.Body.Content[i])
+	}
+	r.footnoteDefinitions()
+	return strings.TrimSpace(r.out.String())
+}
+
+type renderer struct {
+	tab *google.DocumentTab
+	out strings.Builder
+
+	inList bool
+	listID string
+	// listIndents holds the content indent of each open list level, which
+	// is what a nested item must be indented by to stay inside its parent.
+	listIndents []int
+
+	// plainBold drops bold markers, used for table header rows which
+	// ToRequests bolds on its own.
+	plainBold bool
+
+	footnotes       []string
+	footnoteNumbers map[string]string
+}
+
+func newRenderer(tab *google.DocumentTab) *renderer {
+	return &renderer{tab: tab, footnoteNumbers: map[string]string{}}
+}
+
+// element renders the i-th element of the body.
+func (r *renderer) element(i int, el google.ContentElement) {
+	switch {
+	case el.Paragraph != nil:
+		r.paragraph(el.Paragraph)
+	case el.Table != nil:
+		r.table(el.Table)
+	case el.SectionBreak != nil:
+		// Every body opens with a section break; only later ones mark a
+		// visible boundary.
+		if i > 0 {
+			r.block("---")
+		}
+	}
+}
+
+// separate ends the output with a blank line so the next block starts
+// fresh.
+func (r *renderer) separate() {
+	s := r.out.String()
+	switch {
+	case s == "" || strings.HasSuffix(s, "\n\n"):
+	case strings.HasSuffix(s, "\n"):
+		r.out.WriteByte('\n')
+	default:
+		r.out.WriteString("\n\n")
+	}
+}
+
+func (r *renderer) block(s string) {
+	r.separate()
+	r.out.WriteString(s)
+	r.out.WriteByte('\n')
+	r.inList = false
+}
+
+// --- Paragraphs ---
+
+func (r *renderer) paragraph(p *google.Paragraph) {
+	text := strings.TrimSpace(r.inlines(p.Elements))
+	style := p.ParagraphStyle
+	if style == nil {
+		style = &google.ParagraphStyle{}
+	}
+
+	if text == "" {
+		if isRule(p) {
+			r.block("---")
+		} else {
+			r.inList = false
+		}
+		return
+	}
+
+	if level := headingLevel(style.NamedStyleType); level > 0 {
+		r.block(strings.Repeat("#", level) + " " + escapeLineStart(text))
+		return
+	}
+
+	if p.Bullet != nil {
+		r.listItem(p.Bullet, text)
+		return
+	}
+
+	text = escapeLineStart(text)
+	if style.BorderLeft != nil && style.IndentStart != nil && style.IndentStart.Magnitude > 0 {
+		text = "> " + strings.ReplaceAll(text, "\n", "\n> ")
+	}
+	r.block(text)
+}
+
+// isRule reports whether an empty paragraph stands for a horizontal rule,
+// either a native one or the bottom-bordered paragraph ToRequests emits.
+func isRule(p *google.Paragraph) bool {
+	for _, pe := range p.Elements {
+		if pe.HorizontalRule != nil {
+			return true
+		}
+	}
+	s := p.ParagraphStyle
+	return s != nil && s.BorderBottom != nil && s.BorderBottom.Width != nil && s.BorderBottom.Width.Magnitude > 0
+}
+
+func headingLevel(namedStyleType string) int {
+	switch namedStyleType {
+	case "TITLE":
+		return 1
+	case "SUBTITLE":
+		return 2
+	}
+	var level int
+	if _, err := fmt.Sscanf(namedStyleType, "HEADING_%d", &level); err != nil || level < 1 {
+		return 0
+	}
+	return min(level, 6)
+}
+
+// --- Lists ---
+
+func (r *renderer) listItem(b *google.Bullet, text string) {
+	if !r.inList || r.listID != b.ListID {
+		r.separate()
+		r.listIndents = nil
+	}
+	r.inList = true
+	r.listID = b.ListID
+
+	level := b.NestingLevel
+	for len(r.listIndents) < level {
+		r.listIndents = append(r.listIndents, 2)
+	}
+	indent := 0
+	for _, w := range r.listIndents[:level] {
+		indent += w
+	}
+
+	marker := "-"
+	width := 2
+	switch r.glyph(b) {
+	case "ordered":
+		marker, width = "1.", 3
+	case "checkbox":
+		marker = "- [ ]"
+	}
+	r.listIndents = append(r.listIndents[:level], width)
+
+	pad := strings.Repeat(" ", indent)
+	text = escapeLineStart(text)
+	text = strings.ReplaceAll(text, "\n", "\n"+pad+strings.Repeat(" ", width))
+	fmt.Fprintf(&r.out, "%s%s %s\n", pad, marker, text)
+}
+
+// glyph classifies a bullet's nesting level as "ordered", "checkbox" or
+// "bullet" from the list's glyph settings.
+func (r *renderer) glyph(b *google.Bullet) string {
+	list, ok := r.tab.Lists[b.ListID]
+	if !ok || b.NestingLevel >= len(list.ListProperties.NestingLevels) {
+		return "bullet"
+	}
+	level := list.ListProperties.NestingLevels[b.NestingLevel]
+	switch {
+	case level.GlyphType != "" && level.GlyphType != "GLYPH_TYPE_UNSPECIFIED":
+		return "ordered"
+	case level.GlyphSymbol == "":
+		return "checkbox"
+	default:
+		return "bullet"
+	}
+}
+
+// --- Inline content ---
+
+// inlines renders paragraph elements, dropping the paragraph's closing
+// newline and turning line breaks within it into markdown hard breaks.
+func (r *renderer) inlines(elements []google.ParagraphElement) string {
+	var sb strings.Builder
+	for _, pe := range elements {
+		switch {
+		case pe.TextRun != nil:
+			sb.WriteString(r.textRun(pe.TextRun))
+		case pe.InlineObjectElement != nil:
+			sb.WriteString(r.image(pe.InlineObjectElement.InlineObjectID))
+		case pe.FootnoteReference != nil:
+			sb.WriteString(r.footnoteReference(pe.FootnoteReference))
+		}
+	}
+	s := strings.TrimSuffix(sb.String(), "\n")
+	return strings.ReplaceAll(s, "\v", "\\\n")
+}
+
+func (r *renderer) textRun(run *google.TextRun) string {
+	content := run.Content
+	trailing := ""
+	if strings.HasSuffix(content, "\n") {
+		content, trailing = content[:len(content)-1], "\n"
+	}
+	if content == "" {
+		return trailing
+	}
+	style := run.TextStyle
+	if style == nil {
+		return escapeText(content) + trailing
+	}
+
+	var formatted string
+	if isCodeStyle(style) {
+		formatted = codeSpan(content)
+	} else {
+		formatted = escapeText(content)
+		switch bold := google.Flag(style.Bold) && !r.plainBold; {
+		case bold && google.Flag(style.Italic):
+			formatted = wrap(formatted, "***", "***")
+		case bold:
+			formatted = wrap(formatted, "**", "**")
+		case google.Flag(style.Italic):
+			formatted = wrap(formatted, "*", "*")
+		}
+		if google.Flag(style.Strikethrough) {
+			formatted = wrap(formatted, "~~", "~~")
+		}
+		if google.Flag(style.Underline) && (style.Link == nil || style.Link.URL == "") {
+			formatted = wrap(formatted, "<u>", "</u>")
+		}
+	}
+	if style.Link != nil && style.Link.URL != "" {
+		formatted = wrap(formatted, "[", "]("+linkDestination(style.Link.URL)+")")
+	}
+	return formatted + trailing
+}
+
+func isCodeStyle(style *google.TextStyle) bool {
+	return style != nil && style.WeightedFontFamily != nil && codeFontFamilies[style.WeightedFontFamily.FontFamily]
+}
+
+// wrap surrounds s with open and close, keeping leading and trailing
+// whitespace outside the markers where markdown requires it.
+func wrap(s, open, close string) string {
+	inner := strings.TrimSpace(s)
+	if inner == "" {
+		return s
+	}
+	i := strings.Index(s, inner)
+	return s[:i] + open + inner + close + s[i+len(inner):]
+}
+
+// codeSpan fences s with one more backtick than its longest backtick run.
+func codeSpan(s string) string {
+	fence := strings.Repeat("`", longestRun(s, '`')+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
+
+func longestRun(s string, c byte) int {
+	longest, n := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			n++
+			longest = max(longest, n)
+		} else {
+			n = 0
+		}
+	}
+	return longest
+}
+
+func linkDestination(url string) string {
+	if strings.ContainsAny(url, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url) + ">"
+	}
+	return url
+}
+
+// escapeText backslash-escapes characters that would otherwise be read as
+// inline markdown.
+func escapeText(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case strings.IndexByte("\\`*_[]~", c) >= 0:
+			sb.WriteByte('\\')
+		case c == '<' && i+1 < len(s) && (isLetter(s[i+1]) || s[i+1] == '/'):
+			sb.WriteByte('\\')
+		case c == '&' && entityPattern.MatchString(s[i:]):
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// escapeLineStart escapes a leading character that would turn the text
+// into a heading, list item, blockquote or rule.
+func escapeLineStart(s string) string {
+	switch {
+	case headingPrefix.MatchString(s), strings.HasPrefix(s, ">"),
+		bulletPrefix.MatchString(s), thematicBreakish.MatchString(s):
+		return "\\" + s
+	case orderedPrefix.MatchString(s):
+		i := strings.IndexAny(s, ".)")
+		return s[:i] + "\\" + s[i:]
+	}
+	return s
+}
+
+func (r *renderer) image(objectID string) string {
+	obj, ok := r.tab.InlineObjects[objectID]
+	if !ok {
+		return ""
+	}
+	embedded := obj.InlineObjectProperties.EmbeddedObject
+	if embedded.ImageProperties == nil {
+		return ""
+	}
+	uri := embedded.ImageProperties.SourceURI
+	if uri == "" {
+		uri = embedded.ImageProperties.ContentURI
+	}
+	if uri == "" {
+		return ""
+	}
+	alt := embedded.Title
+	if alt == "" {
+		alt = embedded.Description
+	}
+	return "![" + escapeText(alt) + "](" + linkDestination(uri) + ")"
+}
+
+// --- Footnotes ---
+
+func (r *renderer) footnoteReference(ref *google.FootnoteReference) string {
+	number, seen := r.footnoteNumbers[ref.FootnoteID]
+	if !seen {
+		number = ref.FootnoteNumber
+		if number == "" {
+			number = fmt.Sprint(len(r.footnotes) + 1)
+		}
+		r.footnoteNumbers[ref.FootnoteID] = number
+		r.footnotes = append(r.footnotes, ref.FootnoteID)
+	}
+	return "[^" + number + "]"
+}
+
+func (r *renderer) footnoteDefinitions() {
+	if len(r.footnotes) == 0 {
+		return
+	}
+	r.separate()
+	// Footnote bodies may themselves reference footnotes, so the list can
+	// grow while it is being rendered.
+	for i := 0; i < len(r.footnotes); i++ {
+		id := r.footnotes[i]
+		var parts []string
+		for _, el := range r.tab.Footnotes[id].Content {
+			if el.Paragraph != nil {
+				if text := strings.TrimSpace(r.inlines(el.Paragraph.Elements)); text != "" {
+					parts = append(parts, text)
+				}
+			}
+		}
+		fmt.Fprintf(&r.out, "[^%s]: %s\n", r.footnoteNumbers[id], strings.Join(parts, " "))
+	}
+}
+
+// --- Headers and footers ---
+
+// headersFooters writes a comment for each header, or each footer, the tab
+// uses, e.g. "<!-- first-page footer: Draft -->".
+func (r *renderer) headersFooters(footers bool) {
+	style := r.tab.DocumentStyle
+	if style == nil {
+		return
+	}
+	variants := []struct{ label, id string }{
+		{"header", style.DefaultHeaderID},
+		{"first-page header", style.FirstPageHeaderID},
+		{"even-page header", style.EvenPageHeaderID},
+	}
+	if footers {
+		variants = []struct{ label, id string }{
+			{"footer", style.DefaultFooterID},
+			{"first-page footer", style.FirstPageFooterID},
+			{"even-page footer", style.EvenPageFooterID},
+		}
+	}
+	if !style.UseFirstPageHeaderFooter {
+		variants[1].id = ""
+	}
+	if !style.UseEvenPageHeaderFooter {
+		variants[2].id = ""
+	}
+
+	var lines []string
+	for _, v := range variants {
+		if v.id == "" {
+			continue
+		}
+		content := r.tab.Headers[v.id].Content
+		if footers {
+			content = r.tab.Footers[v.id].Content
+		}
+		var parts []string
+		for _, el := range content {
+			if el.Paragraph != nil {
+				if text := strings.TrimSpace(r.inlines(el.Paragraph.Elements)); text != "" {
+					parts = append(parts, text)
+				}
+			}
+		}
+		if len(parts) > 0 {
+			text := strings.ReplaceAll(strings.Join(parts, "\n"), "-->", "--&gt;")
+			lines = append(lines, fmt.Sprintf("<!-- %s: %s -->", v.label, text))
+		}
+	}
+	if len(lines) > 0 {
+		r.block(strings.Join(lines, "\n"))
+	}
+}
+
+// --- Tables ---
+
+func (r *renderer) table(t *google.Table) {
+	if len(t.TableRows) == 0 {
+		return
+	}
+	if isCodeBlockTable(t) {
+		r.codeBlock(t.TableRows[0].TableCells[0])
+		return
+	}
+
+	var sb strings.Builder
+	for i, row := range t.TableRows {
+		r.plainBold = i == 0
+		sb.WriteString("|")
+		for _, cell := range row.TableCells {
+			sb.WriteString(" " + r.cellText(cell) + " |")
+		}
+		sb.WriteString("\n")
+		if i == 0 {
+			sb.WriteString("|" + strings.Repeat(" --- |", len(row.TableCells)) + "\n")
+		}
+	}
+	r.plainBold = false
+	r.block(strings.TrimSuffix(sb.String(), "\n"))
+}
+
+// cellText renders a cell's paragraphs on one line, as markdown table cells
+// cannot span lines.
+func (r *renderer) cellText(cell google.TableCell) string {
+	var parts []string
+	for _, el := range cell.Content {
+		if el.Paragraph != nil {
+			text := strings.TrimSpace(r.inlines(el.Paragraph.Elements))
+			text = strings.NewReplacer("\\\n", " ", "|", "\\|").Replace(text)
+			if text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// isCodeBlockTable reports whether a table is a code block: a 1x1 table
+// with a light gray background or monospace text, which is how both
+// ToRequests and the Docs "Code Block" building block render them.
+func isCodeBlockTable(t *google.Table) bool {
+	if len(t.TableRows) != 1 || len(t.TableRows[0].TableCells) != 1 {
+		return false
+	}
+	cell := t.TableRows[0].TableCells[0]
+
+	if s := cell.TableCellStyle; s != nil && s.BackgroundColor != nil && s.BackgroundColor.Color != nil && s.BackgroundColor.Color.RGBColor != nil {
+		bg := s.BackgroundColor.Color.RGBColor
+		if bg.Red > 0.85 && bg.Green > 0.85 && bg.Blue > 0.85 && bg.Red < 1 && bg.Green < 1 && bg.Blue < 1 {
+			return true
+		}
+	}
+
+	for _, el := range cell.Content {
+		if el.Paragraph == nil {
+			continue
+		}
+		for _, pe := range el.Paragraph.Elements {
+			if pe.TextRun != nil && isCodeStyle(pe.TextRun.TextStyle) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *renderer) codeBlock(cell google.TableCell) {
+	var sb strings.Builder
+	for _, el := range cell.Content {
+		if el.Paragraph == nil {
+			continue
+		}
+		for _, pe := range el.Paragraph.Elements {
+			if pe.TextRun != nil {
+				sb.WriteString(pe.TextRun.Content)
+			}
+		}
+	}
+	code := strings.ReplaceAll(strings.TrimSuffix(sb.String(), "\n"), "\v", "\n")
+	fence := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+	r.block(fence + "\n" + code + "\n" + fence)
+}
@@ -0,0 +1,315 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/amarbel-llc/piers/internal/google"
+)
+
+func para(runs ...google.ParagraphElement) google.ContentElement {
+	return google.ContentElement{Paragraph: &google.Paragraph{Elements: runs}}
+}
+
+func styled(namedStyle string, el google.ContentElement) google.ContentElement {
+	el.Paragraph.ParagraphStyle = &google.ParagraphStyle{NamedStyleType: namedStyle}
+	return el
+}
+
+func bulleted(listID string, level int, el google.ContentElement) google.ContentElement {
+	el.Paragraph.Bullet = &google.Bullet{ListID: listID, NestingLevel: level}
+	return el
+}
+
+func run(content string, style *google.TextStyle) google.ParagraphElement {
+	return google.ParagraphElement{TextRun: &google.TextRun{Content: content, TextStyle: style}}
+}
+
+func body(content ...google.ContentElement) *google.DocumentTab {
+	return &google.DocumentTab{Body: &google.DocumentBody{Content: content}}
+}
+
+func mono(family string) *google.TextStyle {
+	return &google.TextStyle{WeightedFontFamily: &google.WeightedFontFamily{FontFamily: family}}
+}
+
+func cell(style *google.TableCellStyle, content ...google.ContentElement) google.TableCell {
+	return google.TableCell{Content: content, TableCellStyle: style}
+}
+
+func table(rows ...[]google.TableCell) google.ContentElement {
+	t := &google.Table{}
+	for _, cells := range rows {
+		t.TableRows = append(t.TableRows, google.TableRow{TableCells: cells})
+	}
+	return google.ContentElement{Table: t}
+}
+
+func assertContains(t *testing.T, md string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestFromDocsHeadings(t *testing.T) {
+	tab := body(
+		styled("TITLE", para(run("My Title\n", nil))),
+		styled("SUBTITLE", para(run("My Subtitle\n", nil))),
+		styled("HEADING_1", para(run("Hello\n", nil))),
+		styled("HEADING_4", para(run("H4\n", nil))),
+		styled("HEADING_6", para(run("H6\n", nil))),
+	)
+	assertContains(t, FromDocs(tab), "# My Title", "## My Subtitle", "# Hello", "#### H4", "###### H6")
+}
+
+func TestFromDocsTextFormatting(t *testing.T) {
+	tests := []struct {
+		style *google.TextStyle
+		want  string
+	}{
+		{&google.TextStyle{Bold: google.Bool(true)}, "**text**"},
+		{&google.TextStyle{Italic: google.Bool(true)}, "*text*"},
+		{&google.TextStyle{Bold: google.Bool(true), Italic: google.Bool(true)}, "***text***"},
+		{&google.TextStyle{Strikethrough: google.Bool(true)}, "~~text~~"},
+		{&google.TextStyle{Underline: google.Bool(true)}, "<u>text</u>"},
+		{&google.TextStyle{Link: &google.Link{URL: "https://example.com"}}, "[text](https://example.com)"},
+	}
+	for _, tt := range tests {
+		if got := FromDocs(body(para(run("text", tt.style)))); got != tt.want {
+			t.Errorf("style %+v: got %q, want %q", tt.style, got, tt.want)
+		}
+	}
+
+	got := FromDocs(body(para(run("normal ", nil), run("code_here", mono("Roboto Mono")), run(" more\n", nil))))
+	if got != "normal `code_here` more" {
+		t.Errorf("got %q", got)
+	}
+
+	got = FromDocs(body(para(run("a ", nil), run("bold ", &google.TextStyle{Bold: google.Bool(true)}), run("b\n", nil))))
+	if got != "a **bold** b" {
+		t.Errorf("trailing space not moved outside markers: %q", got)
+	}
+}
+
+func TestFromDocsEscaping(t *testing.T) {
+	tests := map[string]string{
+		"2 * 3 = 6":       `2 \* 3 = 6`,
+		"# not heading":   `\# not heading`,
+		"1. not a list":   `1\. not a list`,
+		"- not a bullet":  `\- not a bullet`,
+		"a [b] <c> &amp;": `a \[b\] \<c> \&amp;`,
+	}
+	for in, want := range tests {
+		if got := FromDocs(body(para(run(in+"\n", nil)))); got != want {
+			t.Errorf("%q: got %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFromDocsLists(t *testing.T) {
+	bullets := google.List{ListProperties: google.ListProperties{NestingLevels: []google.NestingLevel{
+		{GlyphSymbol: "\u25cf"}, {GlyphSymbol: "\u25cb"}, {GlyphSymbol: "\u25a0"},
+	}}}
+	mixed := google.List{ListProperties: google.ListProperties{NestingLevels: []google.NestingLevel{
+		{GlyphType: "DECIMAL"}, {GlyphSymbol: "\u25cb"},
+	}}}
+	checks := google.List{ListProperties: google.ListProperties{NestingLevels: []google.NestingLevel{
+		{GlyphType: "GLYPH_TYPE_UNSPECIFIED"},
+	}}}
+
+	tab := body(
+		bulleted("b", 0, para(run("Level 0\n", nil))),
+		bulleted("b", 1, para(run("Level 1\n", nil))),
+		bulleted("b", 2, para(run("Level 2\n", nil))),
+		bulleted("b", 0, para(run("Back to top\n", nil))),
+		para(run("Between\n", nil)),
+		bulleted("m", 0, para(run("First\n", nil))),
+		bulleted("m", 1, para(run("Nested\n", nil))),
+		bulleted("m", 0, para(run("Second\n", nil))),
+		bulleted("c", 0, para(run("Task\n", nil))),
+	)
+	tab.Lists = map[string]google.List{"b": bullets, "m": mixed, "c": checks}
+
+	want := strings.Join([]string{
+		"- Level 0",
+		"  - Level 1",
+		"    - Level 2",
+		"- Back to top",
+		"",
+		"Between",
+		"",
+		"1. First",
+		"   - Nested",
+		"1. Second",
+		"",
+		"- [ ] Task",
+	}, "\n")
+	if got := FromDocs(tab); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFromDocsTables(t *testing.T) {
+	gray := &google.TableCellStyle{BackgroundColor: google.RGB(0.937, 0.945, 0.953)}
+
+	md := FromDocs(body(table([]google.TableCell{cell(gray,
+		para(run("line1\n", mono("Roboto Mono"))),
+		para(run("line2\n", mono("Roboto Mono"))),
+	)})))
+	if md != "```\nline1\nline2\n```" {
+		t.Errorf("gray 1x1 table: got %q", md)
+	}
+
+	md = FromDocs(body(table([]google.TableCell{cell(nil, para(run("print(\"hello\")\n", mono("Courier New"))))})))
+	if md != "```\nprint(\"hello\")\n```" {
+		t.Errorf("monospace 1x1 table: got %q", md)
+	}
+
+	md = FromDocs(body(table(
+		[]google.TableCell{cell(nil, para(run("A\n", &google.TextStyle{Bold: google.Bool(true)}))), cell(nil, para(run("B\n", nil)))},
+		[]google.TableCell{cell(nil, para(run("1|2\n", nil))), cell(nil, para(run("2\n", &google.TextStyle{Italic: google.Bool(true)})))},
+	)))
+	if md != "| A | B |\n| --- | --- |\n| 1\\|2 | *2* |" {
+		t.Errorf("2x2 table: got %q", md)
+	}
+}
+
+func TestFromDocsRulesAndBreaks(t *testing.T) {
+	tab := body(
+		google.ContentElement{SectionBreak: &google.SectionBreak{}},
+		para(run("Before\n", nil)),
+		google.ContentElement{SectionBreak: &google.SectionBreak{}},
+		para(run("Middle\n", nil)),
+		google.ContentElement{Paragraph: &google.Paragraph{
+			Elements:       []google.ParagraphElement{run("\n", nil)},
+			ParagraphStyle: &google.ParagraphStyle{BorderBottom: &google.ParagraphBorder{Width: google.Points(1)}},
+		}},
+		para(run("After\n", nil)),
+	)
+	if got := FromDocs(tab); got != "Before\n\n---\n\nMiddle\n\n---\n\nAfter" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFromDocsImagesAndFootnotes(t *testing.T) {
+	tab := body(
+		para(
+			run("See", nil),
+			google.ParagraphElement{FootnoteReference: &google.FootnoteReference{FootnoteID: "fn1", FootnoteNumber: "1"}},
+			run(" and ", nil),
+			google.ParagraphElement{InlineObjectElement: &google.InlineObjectElement{InlineObjectID: "img1"}},
+			run("\n", nil),
+		),
+	)
+	tab.Footnotes = map[string]google.Footnote{"fn1": {FootnoteID: "fn1", Content: []google.ContentElement{para(run("A note.\n", nil))}}}
+	tab.InlineObjects = map[string]google.InlineObject{"img1": {InlineObjectProperties: google.InlineObjectProperties{
+		EmbeddedObject: google.EmbeddedObject{Title: "logo", ImageProperties: &google.ImageProperties{ContentURI: "https://example.com/logo.png"}},
+	}}}
+
+	if got := FromDocs(tab); got != "See[^1] and ![logo](https://example.com/logo.png)\n\n[^1]: A note." {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFromDocsHeadersAndFooters(t *testing.T) {
+	tab := body(para(run("Body\n", nil)))
+	tab.DocumentStyle = &google.DocumentStyle{
+		DefaultHeaderID:   "h1",
+		FirstPageHeaderID: "h2",
+		DefaultFooterID:   "f1",
+		EvenPageFooterID:  "f2",
+		// Only the first page variants are switched on.
+		UseFirstPageHeaderFooter: true,
+	}
+	tab.Headers = map[string]google.Header{
+		"h1": {HeaderID: "h1", Content: []google.ContentElement{para(run("Acme\n", nil)), para(run("Confidential\n", nil))}},
+		"h2": {HeaderID: "h2", Content: []google.ContentElement{para(run("Cover\n", nil))}},
+	}
+	tab.Footers = map[string]google.Footer{
+		"f1": {FooterID: "f1", Content: []google.ContentElement{para(run("Page footer\n", nil))}},
+		"f2": {FooterID: "f2", Content: []google.ContentElement{para(run("Even\n", nil))}},
+	}
+
+	want := "<!-- header: Acme\nConfidential -->\n<!-- first-page header: Cover -->\n\nBody\n\n<!-- footer: Page footer -->"
+	got := FromDocs(tab)
+	if got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+	// The notes do not become body content when converted back.
+	if text := insertedText(convert(got)); text != "Body\n" {
+		t.Errorf("converted back to %q, want just the body", text)
+	}
+	if blocks := SplitBlocks(got); len(blocks) != 1 || blocks[0] != "Body" {
+		t.Errorf("SplitBlocks = %q, want just the body", blocks)
+	}
+}
+
+func TestFromDocsElements(t *testing.T) {
+	tab := body(
+		styled("HEADING_1", para(run("Intro\n", nil))),
+		para(run("First part.\n", nil)),
+		styled("HEADING_1", para(run("Details\n", nil))),
+		para(run("Second part.\n", nil)),
+	)
+	tab.DocumentStyle = &google.DocumentStyle{DefaultHeaderID: "h1"}
+	tab.Headers = map[string]google.Header{"h1": {HeaderID: "h1", Content: []google.ContentElement{para(run("Acme\n", nil))}}}
+
+	if got, want := FromDocsElements(tab, 2, 4), "# Details\n\nSecond part."; got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+	if got := FromDocsElements(tab, 3, 10); got != "Second part." {
+		t.Errorf("range past the end gave %q", got)
+	}
+}
+
+func TestFromDocsEdgeCases(t *testing.T) {
+	for _, tab := range []*google.DocumentTab{nil, {}, body(), body(para())} {
+		if got := FromDocs(tab); got != "" {
+			t.Errorf("got %q, want empty", got)
+		}
+	}
+}
+
+// TestRoundTrip renders a document as markdown and converts it back,
+// checking that text and structure survive.
+func TestRoundTrip(t *testing.T) {
+	tab := body(
+		styled("HEADING_1", para(run("Plan\n", nil))),
+		para(run("Ship ", nil), run("fast", &google.TextStyle{Bold: google.Bool(true)}), run(" with ", nil), run("care_ful", mono(CodeFontFamily)), run(" * notes\n", nil)),
+		bulleted("b", 0, para(run("One\n", nil))),
+		bulleted("b", 1, para(run("Two\n", nil))),
+		table(
+			[]google.TableCell{cell(nil, para(run("K\n", nil))), cell(nil, para(run("V\n", nil)))},
+			[]google.TableCell{cell(nil, para(run("a\n", nil))), cell(nil, para(run("b\n", &google.TextStyle{Underline: google.Bool(true)})))},
+		),
+	)
+	md := FromDocs(tab)
+	requests := ToRequests(md, Options{StartIndex: 1})
+
+	if got := insertedText(requests); !strings.Contains(got, "Ship fast with care_ful * notes") {
+		t.Errorf("text did not survive: %q", got)
+	}
+	if n := len(namedStyles(requests, "HEADING_1")); n != 1 {
+		t.Errorf("got %d HEADING_1, want 1", n)
+	}
+	if findInsert(requests, "\t") == nil {
+		t.Error("nested list item lost its nesting")
+	}
+	if tables := ofKind(requests, "insertTable"); len(tables) != 1 || tables[0].InsertTable.Rows != 2 || tables[0].InsertTable.Columns != 2 {
+		t.Errorf("tables = %+v, want one 2x2", tables)
+	}
+
+	var bold, code, underline bool
+	for _, r := range ofKind(requests, "updateTextStyle") {
+		s := r.UpdateTextStyle.TextStyle
+		bold = bold || google.Flag(s.Bold)
+		underline = underline || google.Flag(s.Underline)
+		code = code || (s.WeightedFontFamily != nil && s.WeightedFontFamily.FontFamily == CodeFontFamily)
+	}
+	if !bold || !code || !underline {
+		t.Errorf("lost inline styles: bold=%v code=%v underline=%v\n%s", bold, code, underline, md)
+	}
+}
@@ -0,0 +1,877 @@
+package markdown
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	BulletPresetDisc     = "BULLET_DISC_CIRCLE_SQUARE"
+	BulletPresetNumbered = "NUMBERED_DECIMAL_ALPHA_ROMAN"
+	BulletPresetCheckbox = "BULLET_CHECKBOX"
+
+	CodeFontFamily = "Roboto Mono"
+
+	// QuoteIndent is the indentation, in points, given to blockquote
+	// paragraphs.
+	QuoteIndent = 36
+)
+
+var (
+	codeTextColor       = google.RGB(0.094, 0.502, 0.22)  // #188038
+	codeBackgroundColor = google.RGB(0.945, 0.953, 0.957) // #F1F3F4
+
+	// Fenced code blocks become a styled 1x1 table, like the Docs "Code
+	// Block" building block.
+	codeBlockBackground = google.RGB(0.937, 0.945, 0.953) // #EFF1F3
+	codeBlockBorder     = google.RGB(0.855, 0.863, 0.878) // #DADCE0
+
+	ruleColor  = google.RGB(0.75, 0.75, 0.75)
+	quoteColor = google.RGB(0.8, 0.8, 0.8)
+)
+
+// The Docs API inserts a newline BEFORE a table when processing insertTable,
+// so a table requested at index T is laid out as:
+//
+//	T       paragraph break auto-inserted by the API
+//	T + 1   table.startIndex
+//	T + 2   first tableRow.startIndex
+//	T + 3   first tableCell.startIndex
+//	T + 4   first cell paragraph (text insertion point)
+//
+// Every row adds one index for itself plus two per cell (cell start and the
+// cell paragraph's newline), and the table end adds one more, so an empty
+// rows x columns table occupies 3 + rows*(1+2*columns) indices.
+const (
+	tableCellContentOffset = 4
+	tableCellStride        = 2
+)
+
+func emptyTableSize(rows, columns int) int {
+	return 3 + rows*(1+tableCellStride*columns)
+}
+
+// Options controls how markdown is converted to Docs requests.
+type Options struct {
+	// StartIndex is the document index the content is inserted at. Defaults
+	// to 1, the start of the body.
+	StartIndex int
+	// TabID targets a specific tab; empty means the first tab.
+	TabID string
+	// FirstHeadingAsTitle styles the first H1 as TITLE instead of HEADING_1.
+	FirstHeadingAsTitle bool
+	// ResetStyles clears paragraph and text styles over the inserted range
+	// before formatting it. Set it when inserting at the start of an
+	// existing paragraph, whose style the new text would otherwise inherit.
+	ResetStyles bool
+	// EndOfBody says the content replaces the last paragraph of the body,
+	// whose newline cannot be deleted and is still in place after
+	// StartIndex. The content's own final newline is left out so that one
+	// ends its last paragraph, rather than an empty paragraph in the old
+	// style being left behind.
+	EndOfBody bool
+}
+
+type formatKind int
+
+const (
+	formatBold formatKind = iota
+	formatItalic
+	formatStrikethrough
+	formatUnderline
+	formatCode
+	formatLink
+)
+
+type formatEntry struct {
+	kind formatKind
+	link string
+}
+
+type formatting struct {
+	bold, italic, strikethrough, underline, code bool
+	link                                         string
+}
+
+func (f formatting) any() bool {
+	return f.bold || f.italic || f.strikethrough || f.underline || f.code || f.link != ""
+}
+
+type indexRange struct {
+	start, end int
+}
+
+type textRange struct {
+	indexRange
+	formatting formatting
+}
+
+type paragraphRange struct {
+	indexRange
+	namedStyleType string
+}
+
+type listState struct {
+	ordered bool
+	level   int
+}
+
+type pendingListItem struct {
+	start, end   int
+	bulletPreset string
+}
+
+type codeBlockRange struct {
+	tableStart int
+	textStart  int
+	textEnd    int
+}
+
+type converter struct {
+	source []byte
+	opts   Options
+
+	currentIndex   int
+	insertRequests []google.Request
+	formatRequests []google.Request
+
+	formattingStack []formatEntry
+	textRanges      []textRange
+	paragraphRanges []paragraphRange
+	normalRanges    []indexRange
+	listSpacing     []indexRange
+	quoteRanges     []indexRange
+	hrRanges        []indexRange
+	codeBlocks      []codeBlockRange
+
+	listStack    []listState
+	pendingItems []*pendingListItem
+	openItems    []*pendingListItem
+
+	runEnd        int
+	runFormatting formatting
+
+	paragraphStart int
+	inParagraph    bool
+	quoteDepth     int
+	titleConsumed  bool
+}
+
+func newParser() goldmark.Markdown {
+	return goldmark.New(goldmark.WithExtensions(extension.GFM))
+}
+
+// ToRequests converts markdown to Docs batchUpdate requests that insert and
+// format the content at opts.StartIndex. Insertions come first, in document
+// order, followed by formatting requests, so the slice can be sent as-is in
+// a single batchUpdate.
+func ToRequests(markdown string, opts Options) []google.Request {
+	if strings.TrimSpace(markdown) == "" {
+		return nil
+	}
+	if opts.StartIndex == 0 {
+		opts.StartIndex = 1
+	}
+
+	source := []byte(markdown)
+	doc := newParser().Parser().Parse(text.NewReader(source))
+
+	c := &converter{
+		source:       source,
+		opts:         opts,
+		currentIndex: opts.StartIndex,
+	}
+	c.blocks(doc)
+	c.finalize()
+	if opts.EndOfBody && c.lastInsertEndsWithNewline() {
+		last := c.insertRequests[len(c.insertRequests)-1].InsertText
+		if last.Text = strings.TrimSuffix(last.Text, "\n"); last.Text == "" {
+			c.insertRequests = c.insertRequests[:len(c.insertRequests)-1]
+		}
+	}
+
+	return append(c.insertRequests, c.formatRequests...)
+}
+
+// TableRequests returns the requests that insert a table of plain text
+// cells at opts.StartIndex, laid out like a markdown table. Short rows are
+// padded with empty cells, and the first row is bold when headerRow is set.
+func TableRequests(cells [][]string, headerRow bool, opts Options) []google.Request {
+	columns := 0
+	for _, row := range cells {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return nil
+	}
+	if opts.StartIndex == 0 {
+		opts.StartIndex = 1
+	}
+
+	c := &converter{opts: opts, currentIndex: opts.StartIndex}
+	c.insertRequests = append(c.insertRequests, google.Request{
+		InsertTable: &google.InsertTableRequest{Location: c.location(opts.StartIndex), Rows: len(cells), Columns: columns},
+	})
+	index := opts.StartIndex + tableCellContentOffset
+	for r, row := range cells {
+		for col := 0; col < columns; col++ {
+			c.currentIndex = index
+			if col < len(row) && row[col] != "" {
+				if headerRow && r == 0 {
+					c.formattingStack = append(c.formattingStack, formatEntry{kind: formatBold})
+				}
+				c.text(row[col])
+				if headerRow && r == 0 {
+					c.popFormatting(formatBold)
+				}
+			}
+			index = c.currentIndex + tableCellStride
+		}
+		index++
+	}
+	c.finalize()
+	return append(c.insertRequests, c.formatRequests...)
+}
+
+// BulletPreset picks the preset matching the list a bullet belongs to, so
+// paragraphs can be re-bulleted without changing how they look. Unknown
+// lists count as bulleted.
+func BulletPreset(lists map[string]google.List, b *google.Bullet) string {
+	list, ok := lists[b.ListID]
+	if ok && b.NestingLevel < len(list.ListProperties.NestingLevels) {
+		level := list.ListProperties.NestingLevels[b.NestingLevel]
+		switch {
+		case level.GlyphType != "" && level.GlyphType != "GLYPH_TYPE_UNSPECIFIED":
+			return BulletPresetNumbered
+		case level.GlyphSymbol == "":
+			return BulletPresetCheckbox
+		}
+	}
+	return BulletPresetDisc
+}
+
+// --- Block handling ---
+
+func (c *converter) blocks(parent ast.Node) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		c.block(n)
+	}
+}
+
+func (c *converter) block(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		c.heading(n)
+	case *ast.Paragraph, *ast.TextBlock:
+		c.paragraphOpen()
+		c.inlines(n)
+		c.paragraphClose()
+	case *ast.List:
+		c.listStack = append(c.listStack, listState{ordered: n.IsOrdered(), level: len(c.listStack)})
+		c.blocks(n)
+		c.listClose()
+	case *ast.ListItem:
+		c.listItemOpen()
+		c.blocks(n)
+		c.listItemClose()
+	case *ast.FencedCodeBlock:
+		c.codeBlock(c.lines(n))
+	case *ast.CodeBlock:
+		c.codeBlock(c.lines(n))
+	case *ast.ThematicBreak:
+		c.horizontalRule()
+	case *ast.Blockquote:
+		c.quoteDepth++
+		c.blocks(n)
+		c.quoteDepth--
+	case *extast.Table:
+		c.table(n)
+	case *ast.HTMLBlock:
+		// Comments, including the header and footer notes FromDocs
+		// writes, have no visible content.
+		if n.HTMLBlockType == ast.HTMLBlockType2 {
+			return
+		}
+		// Raw HTML is not interpreted; keep it visible as plain text.
+		c.paragraphOpen()
+		c.text(strings.TrimSuffix(c.lines(n), "\n"))
+		c.paragraphClose()
+	default:
+		c.blocks(n)
+	}
+}
+
+func (c *converter) lines(n ast.Node) string {
+	var sb strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		sb.Write(seg.Value(c.source))
+	}
+	return sb.String()
+}
+
+func (c *converter) heading(n *ast.Heading) {
+	c.paragraphStart = c.currentIndex
+	c.inlines(n)
+
+	useTitle := c.opts.FirstHeadingAsTitle && !c.titleConsumed && n.Level == 1
+	if useTitle {
+		c.titleConsumed = true
+	}
+	style := fmt.Sprintf("HEADING_%d", n.Level)
+	if useTitle {
+		style = "TITLE"
+	}
+	c.paragraphRanges = append(c.paragraphRanges, paragraphRange{
+		indexRange:     indexRange{c.paragraphStart, c.currentIndex},
+		namedStyleType: style,
+	})
+
+	c.insertText("\n")
+}
+
+func (c *converter) paragraphOpen() {
+	c.inParagraph = len(c.listStack) == 0
+	if c.inParagraph {
+		c.paragraphStart = c.currentIndex
+	}
+}
+
+func (c *converter) paragraphClose() {
+	if !c.lastInsertEndsWithNewline() {
+		c.insertText("\n")
+	}
+
+	if item := c.currentListItem(); item != nil {
+		end := c.currentIndex - 1
+		if end > item.start {
+			item.end = end
+		}
+	}
+
+	// Plain paragraphs get spacing below; the default NORMAL_TEXT style has
+	// none, which leaves rendered markdown paragraphs crammed together.
+	if c.inParagraph && len(c.listStack) == 0 {
+		r := indexRange{c.paragraphStart, c.currentIndex}
+		c.normalRanges = append(c.normalRanges, r)
+		if c.quoteDepth > 0 {
+			c.quoteRanges = append(c.quoteRanges, r)
+		}
+	}
+	c.inParagraph = false
+}
+
+func (c *converter) horizontalRule() {
+	if !c.lastInsertEndsWithNewline() {
+		c.insertText("\n")
+	}
+	start := c.currentIndex
+	c.insertText("\n")
+	c.hrRanges = append(c.hrRanges, indexRange{start, c.currentIndex})
+}
+
+// --- Lists ---
+
+func (c *converter) listItemOpen() {
+	list := c.listStack[len(c.listStack)-1]
+	start := c.currentIndex
+
+	// Leading tabs set the nesting level once createParagraphBullets runs.
+	if list.level > 0 {
+		c.insertText(strings.Repeat("\t", list.level))
+	}
+
+	preset := BulletPresetDisc
+	if list.ordered {
+		preset = BulletPresetNumbered
+	}
+	item := &pendingListItem{start: start, bulletPreset: preset}
+	c.pendingItems = append(c.pendingItems, item)
+	c.openItems = append(c.openItems, item)
+}
+
+func (c *converter) listItemClose() {
+	item := c.currentListItem()
+	if item == nil {
+		return
+	}
+	c.openItems = c.openItems[:len(c.openItems)-1]
+
+	if item.end == 0 {
+		end := c.currentIndex
+		if c.lastInsertEndsWithNewline() {
+			end--
+		}
+		if end > item.start {
+			item.end = end
+		}
+	}
+	if !c.lastInsertEndsWithNewline() {
+		c.insertText("\n")
+	}
+}
+
+// listClose records the last item of a top-level list so it gets spacing
+// below, separating the list from whatever follows.
+func (c *converter) listClose() {
+	c.listStack = c.listStack[:len(c.listStack)-1]
+	if len(c.listStack) > 0 {
+		return
+	}
+	for i := len(c.pendingItems) - 1; i >= 0; i-- {
+		item := c.pendingItems[i]
+		if item.end > item.start {
+			c.listSpacing = append(c.listSpacing, indexRange{item.start, item.end})
+			break
+		}
+	}
+}
+
+func (c *converter) currentListItem() *pendingListItem {
+	if len(c.openItems) == 0 {
+		return nil
+	}
+	return c.openItems[len(c.openItems)-1]
+}
+
+// --- Code blocks and tables ---
+
+func (c *converter) codeBlock(content string) {
+	content = strings.TrimSuffix(content, "\n")
+
+	if len(c.insertRequests) > 0 && !c.lastInsertEndsWithNewline() {
+		c.insertText("\n")
+	}
+
+	tableStart := c.currentIndex
+	c.insertRequests = append(c.insertRequests, google.Request{
+		InsertTable: &google.InsertTableRequest{Location: c.location(tableStart), Rows: 1, Columns: 1},
+	})
+
+	cellIndex := tableStart + tableCellContentOffset
+	textLength := docindex.UTF16Len(content)
+	if textLength > 0 {
+		c.insertRequests = append(c.insertRequests, google.Request{
+			InsertText: &google.InsertTextRequest{Location: c.location(cellIndex), Text: content},
+		})
+	}
+
+	c.codeBlocks = append(c.codeBlocks, codeBlockRange{
+		tableStart: tableStart,
+		textStart:  cellIndex,
+		textEnd:    cellIndex + textLength,
+	})
+
+	c.currentIndex = tableStart + emptyTableSize(1, 1) + textLength
+	c.insertText("\n")
+}
+
+// table inserts an empty grid and then fills cells in document order. Each
+// cell's insertion index already accounts for the text of the cells before
+// it, so the recorded formatting ranges are final positions.
+func (c *converter) table(n *extast.Table) {
+	var rows []ast.Node
+	for r := n.FirstChild(); r != nil; r = r.NextSibling() {
+		rows = append(rows, r)
+	}
+	columns := 0
+	if len(rows) > 0 {
+		columns = rows[0].ChildCount()
+	}
+	if columns == 0 {
+		return
+	}
+
+	if len(c.insertRequests) > 0 && !c.lastInsertEndsWithNewline() {
+		c.insertText("\n")
+	}
+
+	tableStart := c.currentIndex
+	c.insertRequests = append(c.insertRequests, google.Request{
+		InsertTable: &google.InsertTableRequest{Location: c.location(tableStart), Rows: len(rows), Columns: columns},
+	})
+
+	index := tableStart + tableCellContentOffset
+	for _, row := range rows {
+		_, header := row.(*extast.TableHeader)
+		cell := row.FirstChild()
+		for col := 0; col < columns; col++ {
+			c.currentIndex = index
+			if cell != nil {
+				if header {
+					c.formattingStack = append(c.formattingStack, formatEntry{kind: formatBold})
+				}
+				c.inlines(cell)
+				if header {
+					c.popFormatting(formatBold)
+				}
+				cell = cell.NextSibling()
+			}
+			index = c.currentIndex + tableCellStride
+		}
+		// Step over the row start of the next row.
+		index++
+	}
+
+	// index now points where the first cell of a further row would start;
+	// the table ends one before that.
+	c.currentIndex = index - 1
+	c.insertText("\n")
+}
+
+// --- Inline handling ---
+
+func (c *converter) inlines(parent ast.Node) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		c.inline(n)
+	}
+}
+
+func (c *converter) inline(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Text:
+		c.text(string(unescape(n.Segment.Value(c.source))))
+		switch {
+		case n.HardLineBreak():
+			c.insertText("\n")
+		case n.SoftLineBreak():
+			c.text(" ")
+		}
+	case *ast.String:
+		c.text(string(n.Value))
+	case *ast.Emphasis:
+		kind := formatItalic
+		if n.Level >= 2 {
+			kind = formatBold
+		}
+		c.withFormatting(formatEntry{kind: kind}, n)
+	case *extast.Strikethrough:
+		c.withFormatting(formatEntry{kind: formatStrikethrough}, n)
+	case *ast.CodeSpan:
+		c.formattingStack = append(c.formattingStack, formatEntry{kind: formatCode})
+		c.text(c.rawText(n))
+		c.popFormatting(formatCode)
+	case *ast.Link:
+		c.withFormatting(formatEntry{kind: formatLink, link: string(unescape(n.Destination))}, n)
+	case *ast.AutoLink:
+		c.formattingStack = append(c.formattingStack, formatEntry{kind: formatLink, link: string(n.URL(c.source))})
+		c.text(string(n.Label(c.source)))
+		c.popFormatting(formatLink)
+	case *ast.Image:
+		c.image(string(unescape(n.Destination)))
+	case *ast.RawHTML:
+		var html strings.Builder
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			html.Write(seg.Value(c.source))
+		}
+		// <u> has no markdown syntax, so underline travels as inline HTML;
+		// any other tag is kept as literal text.
+		switch strings.ToLower(html.String()) {
+		case "<u>":
+			c.formattingStack = append(c.formattingStack, formatEntry{kind: formatUnderline})
+		case "</u>":
+			c.popFormatting(formatUnderline)
+		default:
+			c.text(html.String())
+		}
+	case *extast.TaskCheckBox:
+		if item := c.currentListItem(); item != nil {
+			item.bulletPreset = BulletPresetCheckbox
+		}
+	default:
+		c.inlines(n)
+	}
+}
+
+func (c *converter) withFormatting(entry formatEntry, n ast.Node) {
+	c.formattingStack = append(c.formattingStack, entry)
+	c.inlines(n)
+	c.popFormatting(entry.kind)
+}
+
+func (c *converter) popFormatting(kind formatKind) {
+	for i := len(c.formattingStack) - 1; i >= 0; i-- {
+		if c.formattingStack[i].kind == kind {
+			c.formattingStack = append(c.formattingStack[:i], c.formattingStack[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *converter) currentFormatting() formatting {
+	var f formatting
+	for _, e := range c.formattingStack {
+		switch e.kind {
+		case formatBold:
+			f.bold = true
+		case formatItalic:
+			f.italic = true
+		case formatStrikethrough:
+			f.strikethrough = true
+		case formatUnderline:
+			f.underline = true
+		case formatCode:
+			f.code = true
+		case formatLink:
+			f.link = e.link
+		}
+	}
+	return f
+}
+
+// rawText returns the unprocessed text of a code span, joining soft line
+// breaks with spaces.
+func (c *converter) rawText(n ast.Node) string {
+	var sb strings.Builder
+	for t := n.FirstChild(); t != nil; t = t.NextSibling() {
+		if t, ok := t.(*ast.Text); ok {
+			sb.Write(t.Segment.Value(c.source))
+			if t.SoftLineBreak() {
+				sb.WriteByte(' ')
+			}
+		}
+	}
+	return sb.String()
+}
+
+// text inserts s with the current inline formatting. goldmark splits text
+// nodes at escapes and possible autolinks, so a run that directly follows
+// one with the same formatting is merged into it.
+func (c *converter) text(s string) {
+	if s == "" {
+		return
+	}
+	f := c.currentFormatting()
+	if c.runEnd == c.currentIndex && c.runFormatting == f {
+		last := c.insertRequests[len(c.insertRequests)-1].InsertText
+		last.Text += s
+		c.currentIndex += docindex.UTF16Len(s)
+		if f.any() {
+			c.textRanges[len(c.textRanges)-1].end = c.currentIndex
+		}
+		c.runEnd = c.currentIndex
+		return
+	}
+	start := c.currentIndex
+	c.insertText(s)
+	if f.any() {
+		c.textRanges = append(c.textRanges, textRange{indexRange{start, c.currentIndex}, f})
+	}
+	c.runEnd, c.runFormatting = c.currentIndex, f
+}
+
+// image inserts an inline image, which occupies a single index.
+func (c *converter) image(uri string) {
+	if uri == "" {
+		return
+	}
+	c.insertRequests = append(c.insertRequests, google.Request{
+		InsertInlineImage: &google.InsertInlineImageRequest{Location: c.location(c.currentIndex), URI: uri},
+	})
+	c.currentIndex++
+}
+
+func (c *converter) insertText(s string) {
+	c.insertRequests = append(c.insertRequests, google.Request{
+		InsertText: &google.InsertTextRequest{Location: c.location(c.currentIndex), Text: s},
+	})
+	c.currentIndex += docindex.UTF16Len(s)
+}
+
+func (c *converter) lastInsertEndsWithNewline() bool {
+	if len(c.insertRequests) == 0 {
+		return false
+	}
+	last := c.insertRequests[len(c.insertRequests)-1].InsertText
+	return last != nil && strings.HasSuffix(last.Text, "\n")
+}
+
+func (c *converter) location(index int) google.Location {
+	return google.Location{Index: index, TabID: c.opts.TabID}
+}
+
+func (c *converter) rangeOf(r indexRange) google.Range {
+	return google.Range{StartIndex: r.start, EndIndex: r.end, TabID: c.opts.TabID}
+}
+
+// --- Finalization ---
+
+func (c *converter) finalize() {
+	if c.opts.ResetStyles && c.currentIndex > c.opts.StartIndex {
+		all := c.rangeOf(indexRange{c.opts.StartIndex, c.currentIndex})
+		c.addFormat(google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+			Range: all, ParagraphStyle: google.ParagraphStyle{NamedStyleType: "NORMAL_TEXT"}, Fields: "namedStyleType",
+		}})
+		c.addFormat(google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+			Range: all, Fields: "*",
+		}})
+	}
+
+	for _, r := range c.textRanges {
+		f := r.formatting
+		if f.bold || f.italic || f.strikethrough || f.underline || f.code {
+			var style google.TextStyle
+			var fields []string
+			if f.bold {
+				style.Bold = google.Bool(true)
+				fields = append(fields, "bold")
+			}
+			if f.italic {
+				style.Italic = google.Bool(true)
+				fields = append(fields, "italic")
+			}
+			if f.strikethrough {
+				style.Strikethrough = google.Bool(true)
+				fields = append(fields, "strikethrough")
+			}
+			if f.underline {
+				style.Underline = google.Bool(true)
+				fields = append(fields, "underline")
+			}
+			if f.code {
+				style.WeightedFontFamily = &google.WeightedFontFamily{FontFamily: CodeFontFamily}
+				style.ForegroundColor = codeTextColor
+				style.BackgroundColor = codeBackgroundColor
+				fields = append(fields, "weightedFontFamily", "foregroundColor", "backgroundColor")
+			}
+			c.addFormat(google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+				Range: c.rangeOf(r.indexRange), TextStyle: style, Fields: strings.Join(fields, ","),
+			}})
+		}
+		if f.link != "" {
+			c.addFormat(google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+				Range: c.rangeOf(r.indexRange), TextStyle: google.TextStyle{Link: &google.Link{URL: f.link}}, Fields: "link",
+			}})
+		}
+	}
+
+	for _, r := range c.paragraphRanges {
+		c.addFormat(google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+			Range: c.rangeOf(r.indexRange), ParagraphStyle: google.ParagraphStyle{NamedStyleType: r.namedStyleType}, Fields: "namedStyleType",
+		}})
+	}
+
+	for _, r := range append(append([]indexRange{}, c.normalRanges...), c.listSpacing...) {
+		c.addFormat(google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+			Range: c.rangeOf(r), ParagraphStyle: google.ParagraphStyle{SpaceBelow: google.Points(8)}, Fields: "spaceBelow",
+		}})
+	}
+
+	for _, cb := range c.codeBlocks {
+		if cb.textEnd > cb.textStart {
+			c.addFormat(google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+				Range:     c.rangeOf(indexRange{cb.textStart, cb.textEnd}),
+				TextStyle: google.TextStyle{WeightedFontFamily: &google.WeightedFontFamily{FontFamily: CodeFontFamily}},
+				Fields:    "weightedFontFamily",
+			}})
+		}
+
+		border := &google.TableCellBorder{Color: codeBlockBorder, Width: google.Points(0.5), DashStyle: "SOLID"}
+		c.addFormat(google.Request{UpdateTableCellStyle: &google.UpdateTableCellStyleRequest{
+			TableRange: google.TableRange{
+				// The table element itself starts one past the insertTable
+				// index because of the auto-inserted newline.
+				TableCellLocation: google.TableCellLocation{TableStartLocation: c.location(cb.tableStart + 1)},
+				RowSpan:           1,
+				ColumnSpan:        1,
+			},
+			TableCellStyle: google.TableCellStyle{
+				BackgroundColor: codeBlockBackground,
+				PaddingTop:      google.Points(8),
+				PaddingBottom:   google.Points(8),
+				PaddingLeft:     google.Points(12),
+				PaddingRight:    google.Points(12),
+				BorderTop:       border,
+				BorderBottom:    border,
+				BorderLeft:      border,
+				BorderRight:     border,
+			},
+			Fields: "backgroundColor,paddingTop,paddingBottom,paddingLeft,paddingRight,borderTop,borderBottom,borderLeft,borderRight",
+		}})
+	}
+
+	for _, r := range c.hrRanges {
+		c.addFormat(google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+			Range: c.rangeOf(r),
+			ParagraphStyle: google.ParagraphStyle{BorderBottom: &google.ParagraphBorder{
+				Color: ruleColor, Width: google.Points(1), Padding: google.Points(6), DashStyle: "SOLID",
+			}},
+			Fields: "borderBottom",
+		}})
+	}
+
+	for _, r := range c.quoteRanges {
+		c.addFormat(google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+			Range: c.rangeOf(r),
+			ParagraphStyle: google.ParagraphStyle{
+				IndentStart:     google.Points(QuoteIndent),
+				IndentFirstLine: google.Points(QuoteIndent),
+				BorderLeft: &google.ParagraphBorder{
+					Color: quoteColor, Width: google.Points(3), Padding: google.Points(12), DashStyle: "SOLID",
+				},
+			},
+			Fields: "indentStart,indentFirstLine,borderLeft",
+		}})
+	}
+
+	c.finalizeLists()
+}
+
+// finalizeLists merges adjacent items that share a bullet preset into single
+// createParagraphBullets ranges, so Docs treats them as one list with
+// continuous numbering. Items separated by other content are never merged,
+// otherwise the intervening paragraphs would become bullets too. Ranges are
+// emitted bottom-to-top because createParagraphBullets consumes the leading
+// nesting tabs and shifts every later index.
+func (c *converter) finalizeLists() {
+	var items []*pendingListItem
+	for _, item := range c.pendingItems {
+		if item.end > item.start {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].start < items[j].start })
+
+	type mergedRange struct {
+		indexRange
+		preset string
+	}
+	var merged []mergedRange
+	for _, item := range items {
+		if n := len(merged); n > 0 && merged[n-1].preset == item.bulletPreset && item.start <= merged[n-1].end+1 {
+			merged[n-1].end = max(merged[n-1].end, item.end)
+			continue
+		}
+		merged = append(merged, mergedRange{indexRange{item.start, item.end}, item.bulletPreset})
+	}
+
+	for i := len(merged) - 1; i >= 0; i-- {
+		c.addFormat(google.Request{CreateParagraphBullets: &google.CreateParagraphBulletsRequest{
+			Range: c.rangeOf(merged[i].indexRange), BulletPreset: merged[i].preset,
+		}})
+	}
+}
+
+func (c *converter) addFormat(r google.Request) {
+	c.formatRequests = append(c.formatRequests, r)
+}
+
+// unescape resolves backslash escapes and HTML entities the way a markdown
+// renderer would, since goldmark leaves them in the raw text segments.
+func unescape(b []byte) []byte {
+	b = util.UnescapePunctuations(b)
+	b = util.ResolveNumericReferences(b)
+	return util.ResolveEntityNames(b)
+}
@@ -0,0 +1,523 @@
+package markdown
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amarbel-llc/piers/internal/google"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func convert(md string) []google.Request {
+	return ToRequests(md, Options{StartIndex: 1})
+}
+
+func ofKind(requests []google.Request, kind string) []google.Request {
+	var out []google.Request
+	for _, r := range requests {
+		if r.Kind() == kind {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func insertedText(requests []google.Request) string {
+	var b strings.Builder
+	for _, r := range ofKind(requests, "insertText") {
+		b.WriteString(r.InsertText.Text)
+	}
+	return b.String()
+}
+
+func namedStyles(requests []google.Request, style string) []google.Request {
+	var out []google.Request
+	for _, r := range ofKind(requests, "updateParagraphStyle") {
+		if r.UpdateParagraphStyle.ParagraphStyle.NamedStyleType == style {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// spacing returns the spaceBelow-only paragraph requests, excluding heading
+// and horizontal rule styling.
+func spacing(requests []google.Request) []google.Request {
+	var out []google.Request
+	for _, r := range ofKind(requests, "updateParagraphStyle") {
+		ps := r.UpdateParagraphStyle.ParagraphStyle
+		if ps.SpaceBelow != nil && ps.NamedStyleType == "" && ps.BorderBottom == nil {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func borders(requests []google.Request) []google.Request {
+	var out []google.Request
+	for _, r := range ofKind(requests, "updateParagraphStyle") {
+		if r.UpdateParagraphStyle.ParagraphStyle.BorderBottom != nil {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func bulletPresets(requests []google.Request) []string {
+	var out []string
+	for _, r := range ofKind(requests, "createParagraphBullets") {
+		out = append(out, r.CreateParagraphBullets.BulletPreset)
+	}
+	return out
+}
+
+func findInsert(requests []google.Request, substr string) *google.InsertTextRequest {
+	for _, r := range ofKind(requests, "insertText") {
+		if strings.Contains(r.InsertText.Text, substr) {
+			return r.InsertText
+		}
+	}
+	return nil
+}
+
+func TestInlineFormatting(t *testing.T) {
+	tests := []struct {
+		markdown string
+		text     string
+		check    func(google.TextStyle) bool
+	}{
+		{"**bold text**", "bold text", func(s google.TextStyle) bool { return google.Flag(s.Bold) }},
+		{"*italic text*", "italic text", func(s google.TextStyle) bool { return google.Flag(s.Italic) }},
+		{"~~strikethrough text~~", "strikethrough text", func(s google.TextStyle) bool { return google.Flag(s.Strikethrough) }},
+		{"***bold italic***", "bold italic", func(s google.TextStyle) bool { return google.Flag(s.Bold) && google.Flag(s.Italic) }},
+		{"[link text](https://example.com)", "link text", func(s google.TextStyle) bool {
+			return s.Link != nil && s.Link.URL == "https://example.com"
+		}},
+	}
+	for _, tt := range tests {
+		requests := convert(tt.markdown)
+		inserts := ofKind(requests, "insertText")
+		if len(inserts) == 0 || inserts[0].InsertText.Text != tt.text {
+			t.Errorf("%q: first insert = %+v, want text %q", tt.markdown, inserts, tt.text)
+		}
+		styles := ofKind(requests, "updateTextStyle")
+		if len(styles) == 0 || !tt.check(styles[0].UpdateTextStyle.TextStyle) {
+			t.Errorf("%q: unexpected text styles %+v", tt.markdown, styles)
+		}
+	}
+}
+
+func TestInlineCode(t *testing.T) {
+	requests := convert("Use `inline_code` here")
+
+	if n := len(ofKind(requests, "insertTable")); n != 0 {
+		t.Errorf("inline code produced %d tables", n)
+	}
+	found := false
+	for _, r := range ofKind(requests, "updateTextStyle") {
+		if f := r.UpdateTextStyle.TextStyle.WeightedFontFamily; f != nil && f.FontFamily == CodeFontFamily {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("inline code was not styled as monospace")
+	}
+}
+
+func TestHeadings(t *testing.T) {
+	for md, style := range map[string]string{
+		"# Heading 1":   "HEADING_1",
+		"## Heading 2":  "HEADING_2",
+		"### Heading 3": "HEADING_3",
+	} {
+		if n := len(namedStyles(convert(md), style)); n != 1 {
+			t.Errorf("%q: got %d %s requests, want 1", md, n, style)
+		}
+	}
+}
+
+func TestFirstHeadingAsTitle(t *testing.T) {
+	withTitle := func(md string) []google.Request {
+		return ToRequests(md, Options{StartIndex: 1, FirstHeadingAsTitle: true})
+	}
+
+	requests := withTitle("# Title\n\n# Second H1\n\nSome text.")
+	if n := len(namedStyles(requests, "TITLE")); n != 1 {
+		t.Errorf("got %d TITLE requests, want 1", n)
+	}
+	if n := len(namedStyles(requests, "HEADING_1")); n != 1 {
+		t.Errorf("got %d HEADING_1 requests, want 1", n)
+	}
+
+	requests = withTitle("## Section\n\n### Subsection")
+	if n := len(namedStyles(requests, "TITLE")); n != 0 {
+		t.Errorf("H2+ headings produced %d TITLE requests", n)
+	}
+
+	requests = withTitle("# Project Plan\n\n## Overview\n\nThis is the overview.\n\n## Tasks\n\n- Task 1\n- Task 2")
+	if n := len(namedStyles(requests, "TITLE")); n != 1 {
+		t.Errorf("got %d TITLE requests, want 1", n)
+	}
+	if n := len(namedStyles(requests, "HEADING_2")); n != 2 {
+		t.Errorf("got %d HEADING_2 requests, want 2", n)
+	}
+}
+
+func TestLists(t *testing.T) {
+	tests := []struct {
+		markdown string
+		presets  []string
+	}{
+		{"- Item 1\n- Item 2\n- Item 3", []string{BulletPresetDisc}},
+		{"1. Item 1\n2. Item 2\n3. Item 3", []string{BulletPresetNumbered}},
+		{"- [x] done\n- [ ] todo", []string{BulletPresetCheckbox}},
+		{"- A\n- B\n- C", []string{BulletPresetDisc}},
+	}
+	for _, tt := range tests {
+		got := bulletPresets(convert(tt.markdown))
+		if strings.Join(got, ",") != strings.Join(tt.presets, ",") {
+			t.Errorf("%q: presets = %v, want %v", tt.markdown, got, tt.presets)
+		}
+	}
+
+	if text := insertedText(convert("- [x] done\n- [ ] todo")); strings.Contains(text, "[x]") || strings.Contains(text, "[ ]") {
+		t.Errorf("task markers leaked into text %q", text)
+	}
+}
+
+func TestNestedLists(t *testing.T) {
+	requests := convert("- Level 0\n  - Level 1\n    - Level 2")
+	if findInsert(requests, "\t\t") == nil {
+		t.Error("level 2 item was not indented with two tabs")
+	}
+
+	for _, md := range []string{
+		"- Bullet parent\n  1. Ordered child 1\n  2. Ordered child 2",
+		"1. Ordered parent\n  - Bullet child 1\n  - Bullet child 2",
+	} {
+		presets := strings.Join(bulletPresets(convert(md)), ",")
+		if !strings.Contains(presets, BulletPresetDisc) || !strings.Contains(presets, BulletPresetNumbered) {
+			t.Errorf("%q: presets = %s, want both bullet and numbered", md, presets)
+		}
+	}
+
+	text := insertedText(convert("- Parent 1\n  1. Ordered child\n- Parent 2"))
+	for _, want := range []string{"Parent 1", "Ordered child", "Parent 2"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("inserted text %q is missing %q", text, want)
+		}
+	}
+}
+
+func TestListsDoNotBleed(t *testing.T) {
+	requests := convert("- Parent\n  1. Child\n\n## Next Heading")
+	headings := namedStyles(requests, "HEADING_2")
+	if len(headings) != 1 {
+		t.Fatalf("got %d HEADING_2 requests, want 1", len(headings))
+	}
+	start := headings[0].UpdateParagraphStyle.Range.StartIndex
+	for _, b := range ofKind(requests, "createParagraphBullets") {
+		if r := b.CreateParagraphBullets.Range; start >= r.StartIndex && start < r.EndIndex {
+			t.Errorf("bullet range %+v covers heading at %d", r, start)
+		}
+	}
+
+	requests = convert("**Part 1: The Question**\n- Item A\n- Item B\n\n**Part 2: The Results**\n- Item C\n- Item D")
+	bullets := ofKind(requests, "createParagraphBullets")
+	if len(bullets) != 2 {
+		t.Fatalf("got %d bullet requests, want 2", len(bullets))
+	}
+	part2 := findInsert(requests, "Part 2").Location.Index
+	for _, b := range bullets {
+		if r := b.CreateParagraphBullets.Range; part2 >= r.StartIndex && part2 < r.EndIndex {
+			t.Errorf("bullet range %+v covers paragraph at %d", r, part2)
+		}
+	}
+}
+
+func TestCodeBlocks(t *testing.T) {
+	requests := convert("```js\nconst x = 1;\nconsole.log(x);\n```")
+	tables := ofKind(requests, "insertTable")
+	if len(tables) != 1 || tables[0].InsertTable.Rows != 1 || tables[0].InsertTable.Columns != 1 {
+		t.Fatalf("tables = %+v, want one 1x1 table", tables)
+	}
+	mono := 0
+	for _, r := range ofKind(requests, "updateTextStyle") {
+		if f := r.UpdateTextStyle.TextStyle.WeightedFontFamily; f != nil && f.FontFamily == CodeFontFamily {
+			mono++
+		}
+	}
+	if mono != 1 {
+		t.Errorf("got %d monospace requests, want 1", mono)
+	}
+
+	requests = convert("```\nhello\n```")
+	table := ofKind(requests, "insertTable")[0].InsertTable
+	code := findInsert(requests, "hello")
+	if code == nil || code.Location.Index != table.Location.Index+4 {
+		t.Errorf("code inserted at %+v, want table index %d + 4", code, table.Location.Index)
+	}
+	cells := ofKind(requests, "updateTableCellStyle")
+	if len(cells) != 1 {
+		t.Fatalf("got %d cell style requests, want 1", len(cells))
+	}
+	cell := cells[0].UpdateTableCellStyle
+	if got := cell.TableRange.TableCellLocation.TableStartLocation.Index; got != table.Location.Index+1 {
+		t.Errorf("cell style table start = %d, want %d", got, table.Location.Index+1)
+	}
+	if s := cell.TableCellStyle; s.BackgroundColor == nil || s.PaddingTop == nil || s.PaddingBottom == nil || s.PaddingLeft == nil || s.PaddingRight == nil {
+		t.Errorf("cell style is missing background or padding: %+v", s)
+	}
+
+	if findInsert(convert("```\nline1\nline2\nline3\n```"), "line1\nline2\nline3") == nil {
+		t.Error("multi-line code block was not inserted as one run")
+	}
+
+	requests = convert("```\n```")
+	if n := len(ofKind(requests, "insertTable")); n != 1 {
+		t.Errorf("empty code block produced %d tables, want 1", n)
+	}
+	for _, r := range ofKind(requests, "insertText") {
+		if r.InsertText.Text != "\n" {
+			t.Errorf("empty code block inserted %q", r.InsertText.Text)
+		}
+	}
+
+	requests = convert("```\ncode1\n```\n\n```\ncode2\n```")
+	if n := len(ofKind(requests, "insertTable")); n != 2 {
+		t.Errorf("got %d tables, want 2", n)
+	}
+	if n := len(ofKind(requests, "updateTableCellStyle")); n != 2 {
+		t.Errorf("got %d cell style requests, want 2", n)
+	}
+
+	if after := findInsert(convert("```\ncode\n```\n\nFollowing text."), "Following text"); after == nil || after.Location.Index <= 1 {
+		t.Errorf("text after code block inserted at %+v", after)
+	}
+}
+
+func TestTabID(t *testing.T) {
+	requests := ToRequests("**bold text**\n\n```\ncode\n```\n\n- Item\n\n---", Options{StartIndex: 1, TabID: "tab123"})
+	for _, r := range requests {
+		var tabID string
+		switch {
+		case r.InsertText != nil:
+			tabID = r.InsertText.Location.TabID
+		case r.InsertTable != nil:
+			tabID = r.InsertTable.Location.TabID
+		case r.UpdateTextStyle != nil:
+			tabID = r.UpdateTextStyle.Range.TabID
+		case r.UpdateParagraphStyle != nil:
+			tabID = r.UpdateParagraphStyle.Range.TabID
+		case r.UpdateTableCellStyle != nil:
+			tabID = r.UpdateTableCellStyle.TableRange.TableCellLocation.TableStartLocation.TabID
+		case r.CreateParagraphBullets != nil:
+			tabID = r.CreateParagraphBullets.Range.TabID
+		}
+		if tabID != "tab123" {
+			t.Errorf("%s request has tab %q, want tab123", r.Kind(), tabID)
+		}
+	}
+}
+
+func TestStartIndex(t *testing.T) {
+	inserts := ofKind(ToRequests("Test text", Options{StartIndex: 100}), "insertText")
+	if len(inserts) == 0 || inserts[0].InsertText.Location.Index != 100 {
+		t.Errorf("first insert = %+v, want index 100", inserts)
+	}
+}
+
+func TestResetStyles(t *testing.T) {
+	requests := ToRequests("## Risks\n\nNone.", Options{StartIndex: 40, ResetStyles: true})
+	formats := requests[len(ofKind(requests, "insertText")):]
+	reset := formats[0].UpdateParagraphStyle
+	if reset == nil || reset.ParagraphStyle.NamedStyleType != "NORMAL_TEXT" || reset.Range.StartIndex != 40 || reset.Range.EndIndex != 52 {
+		t.Fatalf("first format = %+v, want NORMAL_TEXT over 40-52", formats[0])
+	}
+	if clear := formats[1].UpdateTextStyle; clear == nil || clear.Fields != "*" {
+		t.Errorf("second format = %+v, want a text style reset", formats[1])
+	}
+	if n := len(namedStyles(requests, "HEADING_2")); n != 1 {
+		t.Errorf("got %d HEADING_2 after the reset, want 1", n)
+	}
+
+	if n := len(namedStyles(ToRequests("Plain", Options{}), "NORMAL_TEXT")); n != 0 {
+		t.Errorf("got %d NORMAL_TEXT resets without ResetStyles", n)
+	}
+}
+
+func TestEndOfBody(t *testing.T) {
+	requests := ToRequests("## Risks\n\nNone.", Options{StartIndex: 40, ResetStyles: true, EndOfBody: true})
+	if got := insertedText(requests); got != "Risks\nNone." {
+		t.Errorf("inserted %q, want the final newline left to the body", got)
+	}
+	// Formatting still reaches the body's final newline, which now ends
+	// the last paragraph.
+	reset := namedStyles(requests, "NORMAL_TEXT")
+	if len(reset) != 1 || reset[0].UpdateParagraphStyle.Range.EndIndex != 52 {
+		t.Errorf("NORMAL_TEXT resets = %+v, want one over 40-52", reset)
+	}
+
+	if got := insertedText(ToRequests("Plain", Options{EndOfBody: true})); got != "Plain" {
+		t.Errorf("inserted %q, want %q", got, "Plain")
+	}
+}
+
+func TestParagraphSpacing(t *testing.T) {
+	tests := []struct {
+		markdown string
+		want     int
+	}{
+		{"First paragraph.\n\nSecond paragraph.", 2},
+		{"- Item 1\n- Item 2\n- Item 3", 1},
+		{"1. First\n2. Second\n3. Third", 1},
+		{"# Heading\n\n## Subheading", 0},
+		{"# Title\n\nA paragraph.\n\n- List item\n\nAnother paragraph.", 3},
+		{"- A\n- B\n\nSome text.\n\n1. One\n2. Two", 3},
+		{"- Item 1\n- Item 2\n\nFollowing paragraph.", 2},
+		{"- Parent\n  - Child 1\n  - Child 2\n\nAfter the list.", 2},
+	}
+	for _, tt := range tests {
+		got := spacing(convert(tt.markdown))
+		if len(got) != tt.want {
+			t.Errorf("%q: got %d spacing requests, want %d", tt.markdown, len(got), tt.want)
+		}
+		for _, r := range got {
+			u := r.UpdateParagraphStyle
+			if *u.ParagraphStyle.SpaceBelow != *google.Points(8) || u.Fields != "spaceBelow" {
+				t.Errorf("%q: unexpected spacing request %+v", tt.markdown, u)
+			}
+		}
+	}
+}
+
+func TestEdgeCases(t *testing.T) {
+	for _, md := range []string{"", "   \n\n   "} {
+		if n := len(convert(md)); n != 0 {
+			t.Errorf("%q produced %d requests, want 0", md, n)
+		}
+	}
+
+	requests := convert("Just plain text")
+	if text := ofKind(requests, "insertText")[0].InsertText.Text; text != "Just plain text" {
+		t.Errorf("inserted %q", text)
+	}
+	if n := len(ofKind(requests, "updateTextStyle")); n != 0 {
+		t.Errorf("plain text produced %d text styles", n)
+	}
+}
+
+func TestHorizontalRules(t *testing.T) {
+	requests := convert("Above\n\n---\n\nBelow")
+	hrs := borders(requests)
+	if len(hrs) != 1 {
+		t.Fatalf("got %d border requests, want 1", len(hrs))
+	}
+	hr := hrs[0].UpdateParagraphStyle
+	if b := hr.ParagraphStyle.BorderBottom; b.DashStyle != "SOLID" || *b.Width != *google.Points(1) {
+		t.Errorf("unexpected border %+v", b)
+	}
+	if above := findInsert(requests, "Above"); above.Location.Index >= hr.Range.StartIndex {
+		t.Errorf("Above inserted at %d, after rule at %d", above.Location.Index, hr.Range.StartIndex)
+	}
+	if below := findInsert(requests, "Below"); below.Location.Index < hr.Range.EndIndex {
+		t.Errorf("Below inserted at %d, before rule end %d", below.Location.Index, hr.Range.EndIndex)
+	}
+
+	if n := len(borders(convert("# Title\n\n---\n\n## S1\n\nText.\n\n---\n\n## S2"))); n != 2 {
+		t.Errorf("got %d border requests, want 2", n)
+	}
+}
+
+func TestRealisticDocument(t *testing.T) {
+	md := "# Project Plan\n\n---\n\n## Goals\n\n- **Speed:** Ship faster\n- **Quality:** Fewer bugs\n\n" +
+		"## Timeline\n\n1. Planning\n2. Execution\n3. Review\n\n---\n\n*Last updated: 2026*"
+	requests := convert(md)
+
+	if n := len(borders(requests)); n != 2 {
+		t.Errorf("got %d rules, want 2", n)
+	}
+	if n := len(namedStyles(requests, "HEADING_1")); n != 1 {
+		t.Errorf("got %d HEADING_1, want 1", n)
+	}
+	if n := len(namedStyles(requests, "HEADING_2")); n != 2 {
+		t.Errorf("got %d HEADING_2, want 2", n)
+	}
+	if got := strings.Join(bulletPresets(requests), ","); got != BulletPresetNumbered+","+BulletPresetDisc {
+		t.Errorf("presets = %s", got)
+	}
+	text := insertedText(requests)
+	for _, want := range []string{"Project Plan", "Ship faster", "Execution", "Last updated: 2026"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("inserted text is missing %q", want)
+		}
+	}
+}
+
+// TestGolden compares the full request list for each testdata/*.md file
+// against its .golden.json. Run with -update to regenerate.
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range files {
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(ToRequests(string(src), Options{StartIndex: 1}), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(path, ".md") + ".golden.json"
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("requests differ from %s; rerun with -update and review the diff\ngot:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestTableRequests(t *testing.T) {
+	requests := TableRequests([][]string{{"A", "B"}, {"c"}}, true, Options{StartIndex: 10})
+	// The same layout as the equivalent markdown table, without the
+	// paragraph markdown adds after it.
+	want := ofKind(ToRequests("| A | B |\n| --- | --- |\n| c | |", Options{StartIndex: 10}), "insertText")
+	got := ofKind(requests, "insertText")
+	if len(got) != len(want)-1 {
+		t.Fatalf("got %d inserts, want %d", len(got), len(want)-1)
+	}
+	for i := range got {
+		if *got[i].InsertText != *want[i].InsertText {
+			t.Errorf("insert %d = %+v, want %+v", i, *got[i].InsertText, *want[i].InsertText)
+		}
+	}
+	if table := requests[0].InsertTable; table == nil || table.Rows != 2 || table.Columns != 2 {
+		t.Errorf("first request = %+v, want a 2x2 table", requests[0])
+	}
+	if n := len(ofKind(requests, "updateTextStyle")); n != 2 {
+		t.Errorf("got %d text styles, want the 2 header cells bolded", n)
+	}
+	if n := len(ofKind(TableRequests([][]string{{"A", "B"}}, false, Options{}), "updateTextStyle")); n != 0 {
+		t.Errorf("got %d text styles without a header row, want 0", n)
+	}
+}
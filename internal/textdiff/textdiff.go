@@ -0,0 +1,309 @@
+// Package textdiff compares texts line by line and renders the result as a
+// unified diff or, within changed lines, word by word.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Edit replaces a[OldStart:OldEnd] with b[NewStart:NewEnd]. Either range
+// may be empty.
+type Edit struct {
+	OldStart, OldEnd int
+	NewStart, NewEnd int
+}
+
+// Diff returns the edits that turn a into b. It uses Myers' algorithm, so
+// the cost grows with the size of the difference rather than with the
+// product of the lengths, which matters for whole documents that differ
+// in a few places.
+func Diff(a, b []string) []Edit {
+	// Common ends are matched directly; only the middle is searched.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	edits := myers(a[pre:len(a)-suf], b[pre:len(b)-suf])
+	for i := range edits {
+		edits[i].OldStart += pre
+		edits[i].OldEnd += pre
+		edits[i].NewStart += pre
+		edits[i].NewEnd += pre
+	}
+	return edits
+}
+
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	// v[off+k] is the furthest x reached on diagonal k = x-y. trace[d]
+	// keeps diagonals -d..d of v as they stood before step d.
+	off := n + m + 1
+	v := make([]int, 2*off+1)
+	var trace [][]int
+	d := 0
+search:
+	for ; ; d++ {
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			x := v[off+k-1] + 1
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk back from the end, noting each deleted or inserted element.
+	type step struct {
+		insert bool
+		x, y   int
+	}
+	var steps []step
+	x, y := n, m
+	for ; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d] }
+		k := x - y
+		pk := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			pk = k + 1
+		}
+		px := at(pk)
+		py := px - pk
+		for x > px && y > py {
+			x, y = x-1, y-1
+		}
+		if x == px {
+			steps = append(steps, step{insert: true, x: x, y: py})
+		} else {
+			steps = append(steps, step{x: px, y: y})
+		}
+		x, y = px, py
+	}
+
+	// Steps come out last first; adjacent ones join into a single edit.
+	var edits []Edit
+	for i := len(steps) - 1; i >= 0; i-- {
+		s := steps[i]
+		if n := len(edits); n > 0 && edits[n-1].OldEnd == s.x && edits[n-1].NewEnd == s.y {
+			if s.insert {
+				edits[n-1].NewEnd++
+			} else {
+				edits[n-1].OldEnd++
+			}
+			continue
+		}
+		e := Edit{OldStart: s.x, OldEnd: s.x, NewStart: s.y, NewEnd: s.y}
+		if s.insert {
+			e.NewEnd++
+		} else {
+			e.OldEnd++
+		}
+		edits = append(edits, e)
+	}
+	return edits
+}
+
+// Lines splits s into lines without their line endings. A final newline
+// does not start another line.
+func Lines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Count returns how many elements the edits remove from a and add from b.
+func Count(edits []Edit) (removed, added int) {
+	for _, e := range edits {
+		removed += e.OldEnd - e.OldStart
+		added += e.NewEnd - e.NewStart
+	}
+	return removed, added
+}
+
+// hunks groups edits that are within 2*context lines of each other, so
+// that their context lines would touch or overlap.
+func hunks(edits []Edit, context int) [][]Edit {
+	var groups [][]Edit
+	for i, e := range edits {
+		if i == 0 || e.OldStart-edits[i-1].OldEnd > 2*context {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], e)
+	}
+	return groups
+}
+
+// hunkHeader writes the @@ line for the lines [oldStart, oldEnd) of a and
+// [newStart, newEnd) of b. An empty range is numbered after the line
+// preceding it, as diff(1) does.
+func hunkHeader(sb *strings.Builder, oldStart, oldEnd, newStart, newEnd int) {
+	r := func(start, end int) string {
+		if end == start {
+			return fmt.Sprintf("%d,0", start)
+		}
+		if end-start == 1 {
+			return fmt.Sprint(start + 1)
+		}
+		return fmt.Sprintf("%d,%d", start+1, end-start)
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", r(oldStart, oldEnd), r(newStart, newEnd))
+}
+
+// render writes a diff of lines a and b with context unchanged lines
+// around each change, calling change to write each edit's lines. It
+// returns "" when a and b are equal.
+func render(fromName, toName string, a, b []string, context int, change func(*strings.Builder, Edit)) string {
+	edits := Diff(a, b)
+	if len(edits) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for _, g := range hunks(edits, context) {
+		first, last := g[0], g[len(g)-1]
+		oldStart := max(first.OldStart-context, 0)
+		newStart := first.NewStart - (first.OldStart - oldStart)
+		oldEnd := min(last.OldEnd+context, len(a))
+		newEnd := last.NewEnd + (oldEnd - last.OldEnd)
+		hunkHeader(&sb, oldStart, oldEnd, newStart, newEnd)
+		i := oldStart
+		for _, e := range g {
+			for ; i < e.OldStart; i++ {
+				sb.WriteString(" " + a[i] + "\n")
+			}
+			change(&sb, e)
+			i = e.OldEnd
+		}
+		for ; i < oldEnd; i++ {
+			sb.WriteString(" " + a[i] + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// Unified returns a unified diff of the lines a and b with context
+// unchanged lines around each change, or "" when they are equal.
+func Unified(fromName, toName string, a, b []string, context int) string {
+	return render(fromName, toName, a, b, context, func(sb *strings.Builder, e Edit) {
+		for _, l := range a[e.OldStart:e.OldEnd] {
+			sb.WriteString("-" + l + "\n")
+		}
+		for _, l := range b[e.NewStart:e.NewEnd] {
+			sb.WriteString("+" + l + "\n")
+		}
+	})
+}
+
+// Words is like Unified, but each run of changed lines is written once,
+// indented like context, with the words that changed marked inline as
+// [-removed-] and {+added+}.
+func Words(fromName, toName string, a, b []string, context int) string {
+	return render(fromName, toName, a, b, context, func(sb *strings.Builder, e Edit) {
+		inline := Inline(strings.Join(a[e.OldStart:e.OldEnd], "\n"), strings.Join(b[e.NewStart:e.NewEnd], "\n"))
+		for _, l := range strings.Split(inline, "\n") {
+			sb.WriteString(" " + l + "\n")
+		}
+	})
+}
+
+// Op says whether a segment of a word diff is unchanged, removed or added.
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Segment is a run of text that a word diff keeps, removes or adds.
+type Segment struct {
+	Op   Op
+	Text string
+}
+
+// WordDiff compares a and b word by word and returns, in order, the runs
+// of text they share and the runs removed from a or added from b. Removed
+// text comes before the text that replaces it.
+func WordDiff(a, b string) []Segment {
+	before, after := words(a), words(b)
+	var segs []Segment
+	add := func(op Op, ws []string) {
+		if len(ws) > 0 {
+			segs = append(segs, Segment{op, strings.Join(ws, "")})
+		}
+	}
+	i := 0
+	for _, e := range Diff(before, after) {
+		add(Equal, before[i:e.OldStart])
+		add(Delete, before[e.OldStart:e.OldEnd])
+		add(Insert, after[e.NewStart:e.NewEnd])
+		i = e.OldEnd
+	}
+	add(Equal, before[i:])
+	return segs
+}
+
+// Inline returns a with the words that change in b marked as [-removed-]
+// and {+added+}.
+func Inline(a, b string) string {
+	var sb strings.Builder
+	for _, seg := range WordDiff(a, b) {
+		switch seg.Op {
+		case Equal:
+			sb.WriteString(seg.Text)
+		case Delete:
+			sb.WriteString("[-" + seg.Text + "-]")
+		case Insert:
+			sb.WriteString("{+" + seg.Text + "+}")
+		}
+	}
+	return sb.String()
+}
+
+// words splits s into runs of letters and digits, runs of space, and
+// single other characters, so that punctuation changes stand apart from
+// the word they follow. Joining the pieces gives back s.
+func words(s string) []string {
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		}
+		return 0
+	}
+	var ws []string
+	start, prev := 0, -1
+	for i, r := range s {
+		c := class(r)
+		if i > start && (c != prev || c == 0) {
+			ws = append(ws, s[start:i])
+			start = i
+		}
+		prev = c
+	}
+	if start < len(s) {
+		ws = append(ws, s[start:])
+	}
+	return ws
+}
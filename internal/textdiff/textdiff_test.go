@@ -0,0 +1,139 @@
+package textdiff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// apply turns a into b using edits, checking them as it goes.
+func apply(t *testing.T, a, b []string, edits []Edit) []string {
+	t.Helper()
+	var out []string
+	i := 0
+	for _, e := range edits {
+		if e.OldStart < i || e.OldEnd < e.OldStart || e.NewEnd < e.NewStart {
+			t.Fatalf("bad edit %+v after %d", e, i)
+		}
+		out = append(out, a[i:e.OldStart]...)
+		out = append(out, b[e.NewStart:e.NewEnd]...)
+		i = e.OldEnd
+	}
+	return append(out, a[i:]...)
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want []Edit
+	}{
+		{"a b c", "a b c", nil},
+		{"", "a b", []Edit{{0, 0, 0, 2}}},
+		{"a b", "", []Edit{{0, 2, 0, 0}}},
+		{"a b c", "a x c", []Edit{{1, 2, 1, 2}}},
+		{"a b c d", "a c d e", []Edit{{1, 2, 1, 1}, {4, 4, 3, 4}}},
+		{"a", "a b c", []Edit{{1, 1, 1, 3}}},
+		{"a b c d", "x b c y z", []Edit{{0, 1, 0, 1}, {3, 4, 3, 5}}},
+	}
+	for _, tt := range tests {
+		a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+		if got := Diff(a, b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Diff(%q, %q) = %+v, want %+v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDiffIsMinimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	seq := func() []string {
+		s := make([]string, r.Intn(20))
+		for i := range s {
+			s[i] = string(rune('a' + r.Intn(4)))
+		}
+		return s
+	}
+	for range 500 {
+		a, b := seq(), seq()
+		edits := Diff(a, b)
+		if got := apply(t, a, b, edits); !reflect.DeepEqual(got, append([]string(nil), b...)) && len(got)+len(b) > 0 {
+			t.Fatalf("Diff(%q, %q) gives %q", a, b, got)
+		}
+		// The fewest changes leave the longest common subsequence.
+		removed, added := Count(edits)
+		if lcs := lcsLen(a, b); removed != len(a)-lcs || added != len(b)-lcs {
+			t.Fatalf("Diff(%q, %q) removes %d and adds %d, want %d and %d", a, b, removed, added, len(a)-lcs, len(b)-lcs)
+		}
+	}
+}
+
+func lcsLen(a, b []string) int {
+	l := make([][]int, len(a)+1)
+	for i := range l {
+		l[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				l[i][j] = l[i+1][j+1] + 1
+			} else {
+				l[i][j] = max(l[i+1][j], l[i][j+1])
+			}
+		}
+	}
+	return l[0][0]
+}
+
+func TestUnified(t *testing.T) {
+	a := Lines("one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\n")
+	b := Lines("one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\n")
+	want := `--- a
++++ b
+@@ -1,3 +1,3 @@
+ one
+-two
++2
+ three
+@@ -8 +8,2 @@
+ eight
++nine
+`
+	if got := Unified("a", "b", a, b, 1); got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+	if got := Unified("a", "b", a, a, 3); got != "" {
+		t.Errorf("Unified() of equal texts = %q, want empty", got)
+	}
+}
+
+func TestWords(t *testing.T) {
+	a := Lines("Title\nThe quick brown fox.\nEnd\n")
+	b := Lines("Title\nThe slow brown fox jumps.\nEnd\n")
+	want := `--- a
++++ b
+@@ -1,3 +1,3 @@
+ Title
+ The [-quick-]{+slow+} brown fox{+ jumps+}.
+ End
+`
+	if got := Words("a", "b", a, b, 3); got != want {
+		t.Errorf("Words() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	got := WordDiff("keep the old words", "keep the new words here")
+	want := []Segment{
+		{Equal, "keep the "},
+		{Delete, "old"},
+		{Insert, "new"},
+		{Equal, " words"},
+		{Insert, " here"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WordDiff() = %+v, want %+v", got, want)
+	}
+	if got, want := Inline("a b", "a c"), "a [-b-]{+c+}"; got != want {
+		t.Errorf("Inline() = %q, want %q", got, want)
+	}
+}
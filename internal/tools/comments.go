@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/amarbel-llc/piers/internal/docindex"
 	"github.com/amarbel-llc/piers/internal/google"
 	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
 )
@@ -83,7 +84,22 @@ func registerCommentCommands(app *command.App, client *google.Client) {
 				return command.TextErrorResult("endIndex must be greater than startIndex"), nil
 			}
 
-			comment, err := client.Drive.CreateComment(params.DocumentID, params.Content, "")
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, "")
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to add comment: %v", err)), nil
+			}
+			text := docindex.New(body)
+			if err := checkRange(body, text, params.StartIndex, params.EndIndex); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to add comment: %v", err)), nil
+			}
+
+			// The quoted text is what the comments panel shows as the
+			// comment's anchor, so it must be cut on character boundaries.
+			comment, err := client.Drive.CreateComment(params.DocumentID, params.Content, text.Slice(params.StartIndex, params.EndIndex))
 			if err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to add comment: %v", err)), nil
 			}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/amarbel-llc/piers/internal/docindex"
 	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/markdown"
 	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
 )
 
@@ -42,22 +45,127 @@ func extractText(doc *google.Document) string {
 	return sb.String()
 }
 
+// documentTab returns the tab with the given ID, or the document's legacy
+// top-level content when tabID is empty.
+func documentTab(doc *google.Document, tabID string) (*google.DocumentTab, error) {
+	if tabID == "" {
+		if doc.Body == nil || len(doc.Body.Content) == 0 {
+			return nil, fmt.Errorf("no content found in document")
+		}
+		return &google.DocumentTab{
+			Body:          doc.Body,
+			Lists:         doc.Lists,
+			Footnotes:     doc.Footnotes,
+			InlineObjects: doc.InlineObjects,
+			NamedRanges:   doc.NamedRanges,
+			Headers:       doc.Headers,
+			Footers:       doc.Footers,
+			DocumentStyle: doc.DocumentStyle,
+			NamedStyles:   doc.NamedStyles,
+		}, nil
+	}
+	tab := findTab(doc.Tabs, tabID)
+	if tab == nil {
+		return nil, fmt.Errorf("tab with ID %q not found in document", tabID)
+	}
+	if tab.DocumentTab == nil || tab.DocumentTab.Body == nil || len(tab.DocumentTab.Body.Content) == 0 {
+		return nil, fmt.Errorf("tab %q does not have content (may not be a document tab)", tabID)
+	}
+	return tab.DocumentTab, nil
+}
+
+// documentBody returns the body of the tab with the given ID, or the
+// document's main body when tabID is empty.
+func documentBody(doc *google.Document, tabID string) (*google.DocumentBody, error) {
+	tab, err := documentTab(doc, tabID)
+	if err != nil {
+		return nil, err
+	}
+	return tab.Body, nil
+}
+
+func findTab(tabs []google.Tab, tabID string) *google.Tab {
+	for i := range tabs {
+		if tabs[i].TabProperties.TabID == tabID {
+			return &tabs[i]
+		}
+		if child := findTab(tabs[i].ChildTabs, tabID); child != nil {
+			return child
+		}
+	}
+	return nil
+}
+
+// bodyEndIndex returns the index just before the body's final newline, the
+// last position where content can be inserted.
+func bodyEndIndex(body *google.DocumentBody) int {
+	return body.Content[len(body.Content)-1].EndIndex - 1
+}
+
+// checkIndex validates a caller-supplied index against the body, rejecting
+// positions outside it and positions between the two UTF-16 units of a
+// character such as an emoji, which the API refuses.
+func checkIndex(body *google.DocumentBody, text *docindex.Text, name string, index int) error {
+	if index < 1 {
+		return fmt.Errorf("%s must be at least 1", name)
+	}
+	if end := bodyEndIndex(body); index > end {
+		return fmt.Errorf("%s %d is past the end of the document (last index %d)", name, index, end)
+	}
+	if text.SplitsCharacter(index) {
+		return fmt.Errorf("%s %d falls inside a character that spans two indices (such as an emoji); use %d or %d", name, index, index-1, index+1)
+	}
+	return nil
+}
+
+// checkRange validates a [startIndex, endIndex) range with checkIndex.
+func checkRange(body *google.DocumentBody, text *docindex.Text, start, end int) error {
+	if end <= start {
+		return fmt.Errorf("endIndex must be greater than startIndex")
+	}
+	if err := checkIndex(body, text, "startIndex", start); err != nil {
+		return err
+	}
+	return checkIndex(body, text, "endIndex", end)
+}
+
+// revisionNote names the revision a read reflects, to pass back to editing
+// tools as expectedRevisionId. Markdown gets it as a comment, so the text
+// can still be edited and written back as it is.
+func revisionNote(doc *google.Document, format string) string {
+	switch {
+	case doc.RevisionID == "":
+		return ""
+	case format == "markdown":
+		return fmt.Sprintf("<!-- revisionId: %s -->\n\n", doc.RevisionID)
+	}
+	return fmt.Sprintf("Revision ID: %s\n", doc.RevisionID)
+}
+
 func registerDocsCommands(app *command.App, client *google.Client) {
 	app.AddCommand(&command.Command{
 		Name:        "readDocument",
-		Description: command.Description{Short: "Reads the content of a Google Document. Returns plain text by default. Use format='markdown' to get formatted content suitable for editing and re-uploading with replaceDocumentWithMarkdown, or format='json' for the raw document structure."},
+		Description: command.Description{Short: "Reads the content of a Google Document. Returns plain text by default. Use format='markdown' to get formatted content suitable for editing and re-uploading with replaceDocumentWithMarkdown, or format='json' for the raw document structure. Every format includes the document's revisionId; pass it as expectedRevisionId to editing tools so that edits fail rather than land in the wrong place if someone else has changed the document since."},
 		Params: []command.Param{
 			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
-			{Name: "format", Type: command.String, Description: "Output format: 'text' (plain text), 'json' (raw API structure, complex), 'markdown' (experimental conversion)."},
-			{Name: "maxLength", Type: command.Int, Description: "Maximum character limit for text output. If not specified, returns full document content. Use this to limit very large documents."},
+			{Name: "format", Type: command.String, Description: "Output format: 'text' (plain text), 'json' (raw API structure, complex), 'markdown' (headings, formatting, lists, tables, code blocks, images and footnotes; round-trips through replaceDocumentWithMarkdown)."},
+			{Name: "maxLength", Type: command.Int, Description: "Maximum character limit for output. Text and markdown stop at the last whole paragraph or table that fits and say which cursor to continue from; a page always holds at least one, so a single paragraph or table longer than maxLength is returned whole. JSON is truncated. If not specified, returns full document content."},
+			{Name: "cursor", Type: command.Int, Description: "Document index to resume reading from, as returned by a previous paged read. Text and markdown only."},
+			{Name: "chunkBy", Type: command.String, Description: "Return the content as JSON chunks with their start and end indices: 'heading' (one per heading and the content under it), 'paragraph' (one per paragraph or table) or 'tokens' (runs of about chunkSize tokens). Combine with maxLength and cursor to page through long documents."},
+			{Name: "chunkSize", Type: command.Int, Description: "Approximate tokens per chunk for chunkBy='tokens'. Defaults to 1000."},
 			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to read. If not specified, reads the first tab (or legacy document.body for documents without tabs)."},
+			{Name: "suggestions", Type: command.String, Description: "How to show pending suggested edits: 'inline' (both suggested insertions and deletions), 'accepted' (as if all were accepted) or 'rejected' (as if none were). If not specified, uses the document's default view."},
 		},
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
-				DocumentID string `json:"documentId"`
-				Format     string `json:"format"`
-				MaxLength  int    `json:"maxLength"`
-				TabID      string `json:"tabId"`
+				DocumentID  string `json:"documentId"`
+				Format      string `json:"format"`
+				MaxLength   int    `json:"maxLength"`
+				Cursor      int    `json:"cursor"`
+				ChunkBy     string `json:"chunkBy"`
+				ChunkSize   int    `json:"chunkSize"`
+				TabID       string `json:"tabId"`
+				Suggestions string `json:"suggestions"`
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
@@ -65,8 +173,22 @@ func registerDocsCommands(app *command.App, client *google.Client) {
 			if params.Format == "" {
 				params.Format = "text"
 			}
+			view, ok := suggestionViews[params.Suggestions]
+			if !ok {
+				return command.TextErrorResult(fmt.Sprintf("invalid suggestions %q: must be inline, accepted or rejected", params.Suggestions)), nil
+			}
+			if params.ChunkBy != "" && params.ChunkBy != "heading" && params.ChunkBy != "paragraph" && params.ChunkBy != "tokens" {
+				return command.TextErrorResult(fmt.Sprintf("invalid chunkBy %q: must be heading, paragraph or tokens", params.ChunkBy)), nil
+			}
+			if params.ChunkSize <= 0 {
+				params.ChunkSize = 1000
+			}
+			paged := params.MaxLength > 0 || params.Cursor != 0 || params.ChunkBy != ""
+			if paged && params.Format == "json" && (params.Cursor != 0 || params.ChunkBy != "") {
+				return command.TextErrorResult("cursor and chunkBy apply to text and markdown output only"), nil
+			}
 
-			doc, err := client.Docs.Get(params.DocumentID)
+			doc, err := client.Docs.GetView(params.DocumentID, view)
 			if err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
 			}
@@ -78,29 +200,48 @@ func registerDocsCommands(app *command.App, client *google.Client) {
 					return command.TextErrorResult(fmt.Sprintf("failed to marshal document: %v", err)), nil
 				}
 				content := string(b)
-				if params.MaxLength > 0 && len(content) > params.MaxLength {
-					content = content[:params.MaxLength] + fmt.Sprintf("\n... [JSON truncated: %d total chars]", len(content))
+				if total := docindex.RuneCount(content); params.MaxLength > 0 && total > params.MaxLength {
+					content = docindex.TruncateRunes(content, params.MaxLength) + fmt.Sprintf("\n... [JSON truncated: %d total chars]", total)
 				}
 				return command.TextResult(content), nil
 
 			case "markdown":
-				text := extractText(doc)
-				if params.MaxLength > 0 && len(text) > params.MaxLength {
-					text = text[:params.MaxLength] + fmt.Sprintf("\n\n... [Markdown truncated to %d chars of %d total.]", params.MaxLength, len(text))
+				tab, err := documentTab(doc, params.TabID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+				}
+				if paged {
+					text, err := readPage(tab, doc.RevisionID, "markdown", params.Cursor, params.MaxLength, params.ChunkBy, params.ChunkSize)
+					if err != nil {
+						return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+					}
+					if params.ChunkBy != "" {
+						return command.TextResult(text), nil
+					}
+					return command.TextResult(revisionNote(doc, "markdown") + text), nil
 				}
-				return command.TextResult(text), nil
+				return command.TextResult(revisionNote(doc, "markdown") + markdown.FromDocs(tab)), nil
 
 			default: // text
+				if paged {
+					tab, err := documentTab(doc, params.TabID)
+					if err != nil {
+						return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+					}
+					text, err := readPage(tab, doc.RevisionID, "text", params.Cursor, params.MaxLength, params.ChunkBy, params.ChunkSize)
+					if err != nil {
+						return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+					}
+					if params.ChunkBy != "" {
+						return command.TextResult(text), nil
+					}
+					return command.TextResult(revisionNote(doc, "text") + text), nil
+				}
 				text := extractText(doc)
 				if text == "" {
-					return command.TextResult("Document found, but appears empty."), nil
-				}
-				totalLength := len(text)
-				if params.MaxLength > 0 && totalLength > params.MaxLength {
-					truncated := text[:params.MaxLength]
-					return command.TextResult(fmt.Sprintf("Content (truncated to %d chars of %d total):\n---\n%s\n\n... [Document continues for %d more characters.]", params.MaxLength, totalLength, truncated, totalLength-params.MaxLength)), nil
+					return command.TextResult(revisionNote(doc, "text") + "Document found, but appears empty."), nil
 				}
-				return command.TextResult(fmt.Sprintf("Content (%d characters):\n---\n%s", totalLength, text)), nil
+				return command.TextResult(fmt.Sprintf("%sContent (%d characters):\n---\n%s", revisionNote(doc, "text"), docindex.RuneCount(text), text)), nil
 			}
 		},
 	})
@@ -108,84 +249,173 @@ func registerDocsCommands(app *command.App, client *google.Client) {
 	app.AddCommand(&command.Command{
 		Name:        "appendText",
 		Description: command.Description{Short: "Appends plain text to the end of a document. For formatted content, use appendMarkdown instead."},
-		Params: []command.Param{
+		Params: append([]command.Param{
 			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
 			{Name: "text", Type: command.String, Description: "The plain text to append to the end of the document.", Required: true},
 			{Name: "addNewlineIfNeeded", Type: command.Bool, Description: "Automatically add a newline before the appended text if the doc doesn't end with one."},
 			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to append to. If not specified, appends to the first tab."},
-		},
+		}, writeControlParams...),
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
-				DocumentID string `json:"documentId"`
-				Text       string `json:"text"`
-				TabID      string `json:"tabId"`
+				DocumentID         string `json:"documentId"`
+				Text               string `json:"text"`
+				AddNewlineIfNeeded bool   `json:"addNewlineIfNeeded"`
+				TabID              string `json:"tabId"`
+				writeControl
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
 			}
 
-			if err := client.Docs.BatchUpdate(params.DocumentID, nil); err != nil {
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to append text: %v", err)), nil
 			}
-			return command.TextResult(fmt.Sprintf("Successfully appended text to document %s.", params.DocumentID)), nil
+
+			// The body always ends with the last paragraph's newline, so the
+			// text goes in before it, after whatever that paragraph holds.
+			index := bodyEndIndex(body)
+			text := params.Text
+			if params.AddNewlineIfNeeded && index > 1 && docindex.New(body).Slice(index-1, index) != "\n" {
+				text = "\n" + text
+			}
+			req := google.Request{InsertText: &google.InsertTextRequest{
+				Location: google.Location{Index: index, TabID: params.TabID},
+				Text:     text,
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to append text: %v", err)), nil
+			}
+			return command.TextResult(fmt.Sprintf("Successfully appended %d characters at index %d of document %s.", docindex.UTF16Len(text), index, params.DocumentID)), nil
 		},
 	})
 
 	app.AddCommand(&command.Command{
 		Name:        "insertText",
-		Description: command.Description{Short: "Inserts text at a specific character index within a document. Use readDocument with format='json' to determine the correct index."},
-		Params: []command.Param{
+		Description: command.Description{Short: "Inserts text into a document at a character index, or next to existing text, a heading or a named range (afterText, beforeText, afterHeading, atEndOfSection, afterNamedRange, beforeNamedRange). Returns the resolved index."},
+		Params: slices.Concat([]command.Param{
 			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
 			{Name: "text", Type: command.String, Description: "The text to insert.", Required: true},
-			{Name: "index", Type: command.Int, Description: "1-based character index within the document body. Use readDocument with format='json' to inspect indices.", Required: true},
+			{Name: "index", Type: command.Int, Description: "1-based character index within the document body. Use readDocument with format='json' to inspect indices, or use an anchor parameter instead."},
 			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to insert into. If not specified, inserts into the first tab."},
-		},
+		}, anchorParams, writeControlParams),
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
 				DocumentID string `json:"documentId"`
 				Text       string `json:"text"`
 				Index      int    `json:"index"`
 				TabID      string `json:"tabId"`
+				anchor
+				writeControl
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
 			}
 
-			if err := client.Docs.BatchUpdate(params.DocumentID, nil); err != nil {
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert text: %v", err)), nil
+			}
+			index, err := resolveIndex(tab, docindex.New(tab.Body), params.Index, params.anchor)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert text: %v", err)), nil
+			}
+
+			req := google.Request{InsertText: &google.InsertTextRequest{
+				Location: google.Location{Index: index, TabID: params.TabID},
+				Text:     params.Text,
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(params.anchor.revision(doc, params.ExpectedRevisionID))); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to insert text: %v", err)), nil
 			}
-			return command.TextResult(fmt.Sprintf("Successfully inserted text at index %d.", params.Index)), nil
+			return command.TextResult(fmt.Sprintf("Successfully inserted text at index %d. Content after it moved forward by %d.", index, docindex.UTF16Len(params.Text))), nil
 		},
 	})
 
 	app.AddCommand(&command.Command{
 		Name:        "deleteRange",
-		Description: command.Description{Short: "Deletes content within a character range [startIndex, endIndex) from a document. Use readDocument with format='json' to determine index positions."},
-		Params: []command.Param{
+		Description: command.Description{Short: "Deletes content within a character range [startIndex, endIndex) from a document. Either end can be given as an index or anchored to text, a heading or a named range: afterText, afterHeading or afterNamedRange for the start, beforeText, atEndOfSection or beforeNamedRange for the end. Use namedRange to delete exactly the content of a named range. Returns the resolved range."},
+		Params: slices.Concat([]command.Param{
 			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
-			{Name: "startIndex", Type: command.Int, Description: "1-based character index within the document body. The start of the range to delete (inclusive).", Required: true},
-			{Name: "endIndex", Type: command.Int, Description: "1-based character index within the document body. The end of the range to delete (exclusive).", Required: true},
+			{Name: "startIndex", Type: command.Int, Description: "1-based character index within the document body. The start of the range to delete (inclusive)."},
+			{Name: "endIndex", Type: command.Int, Description: "1-based character index within the document body. The end of the range to delete (exclusive)."},
+			{Name: "namedRange", Type: command.String, Description: "Delete the content of the named range with this name or ID (alternative to the indices and anchors)."},
 			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to delete from. If not specified, deletes from the first tab."},
-		},
+		}, anchorParams, writeControlParams),
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
 				DocumentID string `json:"documentId"`
 				StartIndex int    `json:"startIndex"`
 				EndIndex   int    `json:"endIndex"`
+				NamedRange string `json:"namedRange"`
 				TabID      string `json:"tabId"`
+				anchor
+				writeControl
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
 			}
 
-			if params.EndIndex <= params.StartIndex {
+			if params.NamedRange != "" && (len(params.names()) > 0 || params.StartIndex != 0 || params.EndIndex != 0) {
+				return command.TextErrorResult("namedRange cannot be combined with indices or other anchors"), nil
+			}
+			if params.NamedRange == "" && len(params.names()) == 0 && params.EndIndex <= params.StartIndex {
 				return command.TextErrorResult("endIndex must be greater than startIndex"), nil
 			}
 
-			if err := client.Docs.BatchUpdate(params.DocumentID, nil); err != nil {
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to delete range: %v", err)), nil
 			}
-			return command.TextResult(fmt.Sprintf("Successfully deleted content in range %d-%d.", params.StartIndex, params.EndIndex)), nil
+			text := docindex.New(tab.Body)
+			var start, end int
+			if params.NamedRange != "" {
+				var nr namedRange
+				if nr, err = findNamedRange(tab, params.NamedRange); err == nil {
+					start, end = nr.startIndex, min(nr.endIndex, bodyEndIndex(tab.Body))
+					err = checkRange(tab.Body, text, start, end)
+				}
+			} else {
+				start, end, err = resolveRange(tab, text, params.StartIndex, params.EndIndex, params.anchor)
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete range: %v", err)), nil
+			}
+
+			// A range mixing an index with an anchor is only whole when the
+			// index, given for the expected revision, also holds in doc.
+			base := doc.RevisionID
+			switch {
+			case params.NamedRange == "" && len(params.names()) == 0:
+				base = params.ExpectedRevisionID
+			case params.StartIndex != 0 || params.EndIndex != 0:
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to delete range: %v", err)), nil
+				}
+			}
+			req := google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+				Range: google.Range{StartIndex: start, EndIndex: end, TabID: params.TabID},
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(base)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete range: %v", err)), nil
+			}
+			deleted := text.Slice(start, end)
+			if docindex.RuneCount(deleted) > 80 {
+				deleted = docindex.TruncateRunes(deleted, 80) + "..."
+			}
+			return command.TextResult(fmt.Sprintf("Successfully deleted content in range %d-%d: %q", start, end, deleted)), nil
 		},
 	})
 
@@ -0,0 +1,279 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// anchor locates a position by the surrounding text, a heading or a named
+// range instead of a numeric index. At most one of the fields other than
+// MatchInstance may be set.
+type anchor struct {
+	AfterText        string `json:"afterText"`
+	BeforeText       string `json:"beforeText"`
+	MatchInstance    int    `json:"matchInstance"`
+	AfterHeading     string `json:"afterHeading"`
+	AtEndOfSection   string `json:"atEndOfSection"`
+	AfterNamedRange  string `json:"afterNamedRange"`
+	BeforeNamedRange string `json:"beforeNamedRange"`
+}
+
+var anchorParams = []command.Param{
+	{Name: "afterText", Type: command.String, Description: "The position immediately after this exact text (alternative to an index)."},
+	{Name: "beforeText", Type: command.String, Description: "The position immediately before this exact text (alternative to an index)."},
+	{Name: "matchInstance", Type: command.Int, Description: "Which instance of afterText, beforeText or the heading to target (1st, 2nd, etc.). Defaults to 1."},
+	{Name: "afterHeading", Type: command.String, Description: "The start of the paragraph following the heading with this text (alternative to an index)."},
+	{Name: "atEndOfSection", Type: command.String, Description: "The end of the section under the heading with this text, before the next heading of the same or higher level (alternative to an index)."},
+	{Name: "afterNamedRange", Type: command.String, Description: "The position immediately after the named range with this name or ID (alternative to an index)."},
+	{Name: "beforeNamedRange", Type: command.String, Description: "The position immediately before the named range with this name or ID (alternative to an index)."},
+}
+
+func (a anchor) names() []string {
+	var names []string
+	for _, f := range []struct {
+		name, value string
+	}{
+		{"afterText", a.AfterText},
+		{"beforeText", a.BeforeText},
+		{"afterHeading", a.AfterHeading},
+		{"atEndOfSection", a.AtEndOfSection},
+		{"afterNamedRange", a.AfterNamedRange},
+		{"beforeNamedRange", a.BeforeNamedRange},
+	} {
+		if f.value != "" {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+func (a anchor) instance() int {
+	if a.MatchInstance < 1 {
+		return 1
+	}
+	return a.MatchInstance
+}
+
+// revision returns the revision an index resolved with a belongs to: doc's
+// when an anchor found it there, or expected when the caller gave it.
+func (a anchor) revision(doc *google.Document, expected string) string {
+	if len(a.names()) > 0 {
+		return doc.RevisionID
+	}
+	return expected
+}
+
+// resolveIndex returns index when no anchor is set, or the position the
+// anchor points at in tab, validated with checkIndex either way.
+func resolveIndex(tab *google.DocumentTab, text *docindex.Text, index int, a anchor) (int, error) {
+	body := tab.Body
+	names := a.names()
+	switch {
+	case len(names) > 1:
+		return 0, fmt.Errorf("only one of %s may be given", strings.Join(names, ", "))
+	case len(names) == 1 && index != 0:
+		return 0, fmt.Errorf("provide either index or %s, not both", names[0])
+	case len(names) == 0 && index == 0:
+		return 0, fmt.Errorf("provide index or one of afterText, beforeText, afterHeading, atEndOfSection, afterNamedRange, beforeNamedRange")
+	}
+
+	var err error
+	switch {
+	case a.AfterText != "":
+		_, index, err = findText(text, a.AfterText, 0, a.instance())
+	case a.BeforeText != "":
+		index, _, err = findText(text, a.BeforeText, 0, a.instance())
+	case a.AfterHeading != "":
+		var h heading
+		if h, err = findHeading(body, a.AfterHeading, a.instance()); err == nil {
+			index = min(h.endIndex, bodyEndIndex(body))
+		}
+	case a.AtEndOfSection != "":
+		var h heading
+		if h, err = findHeading(body, a.AtEndOfSection, a.instance()); err == nil {
+			index = sectionEndIndex(body, h)
+		}
+	case a.AfterNamedRange != "":
+		var nr namedRange
+		if nr, err = findNamedRange(tab, a.AfterNamedRange); err == nil {
+			index = nr.endIndex
+		}
+	case a.BeforeNamedRange != "":
+		var nr namedRange
+		if nr, err = findNamedRange(tab, a.BeforeNamedRange); err == nil {
+			index = nr.startIndex
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return index, checkIndex(body, text, "index", index)
+}
+
+// resolveRange resolves a deletion range in tab whose start may be anchored
+// with afterText, afterHeading or afterNamedRange and whose end may be
+// anchored with beforeText (searched from the start), atEndOfSection or
+// beforeNamedRange.
+func resolveRange(tab *google.DocumentTab, text *docindex.Text, start, end int, a anchor) (int, int, error) {
+	body := tab.Body
+	startAnchors := anchor{AfterText: a.AfterText, AfterHeading: a.AfterHeading, AfterNamedRange: a.AfterNamedRange}.names()
+	endAnchors := anchor{BeforeText: a.BeforeText, AtEndOfSection: a.AtEndOfSection, BeforeNamedRange: a.BeforeNamedRange}.names()
+	switch {
+	case len(startAnchors) > 1:
+		return 0, 0, fmt.Errorf("only one of %s may be given", strings.Join(startAnchors, ", "))
+	case len(endAnchors) > 1:
+		return 0, 0, fmt.Errorf("only one of %s may be given", strings.Join(endAnchors, ", "))
+	case start != 0 && len(startAnchors) > 0:
+		return 0, 0, fmt.Errorf("provide either startIndex or %s, not both", startAnchors[0])
+	case end != 0 && len(endAnchors) > 0:
+		return 0, 0, fmt.Errorf("provide either endIndex or %s, not both", endAnchors[0])
+	}
+
+	var err error
+	switch {
+	case a.AfterText != "":
+		_, start, err = findText(text, a.AfterText, 0, a.instance())
+	case a.AfterHeading != "":
+		var h heading
+		if h, err = findHeading(body, a.AfterHeading, a.instance()); err == nil {
+			start = h.endIndex
+		}
+	case a.AfterNamedRange != "":
+		var nr namedRange
+		if nr, err = findNamedRange(tab, a.AfterNamedRange); err == nil {
+			start = nr.endIndex
+		}
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case a.BeforeText != "":
+		end, _, err = findText(text, a.BeforeText, start, 1)
+	case a.AtEndOfSection != "":
+		var h heading
+		if h, err = findHeading(body, a.AtEndOfSection, a.instance()); err == nil {
+			end = sectionEndIndex(body, h)
+		}
+	case a.BeforeNamedRange != "":
+		var nr namedRange
+		if nr, err = findNamedRange(tab, a.BeforeNamedRange); err == nil {
+			end = nr.startIndex
+		}
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, checkRange(body, text, start, end)
+}
+
+func findText(text *docindex.Text, s string, from, instance int) (int, int, error) {
+	start, end, ok := text.FindAfter(s, from, instance)
+	if !ok {
+		if instance > 1 {
+			return 0, 0, fmt.Errorf("instance %d of text %q not found", instance, s)
+		}
+		return 0, 0, fmt.Errorf("text %q not found", s)
+	}
+	return start, end, nil
+}
+
+// heading is a heading paragraph in the body. Level is 0 for TITLE and
+// SUBTITLE and 1-6 for HEADING_1 through HEADING_6.
+type heading struct {
+	element              int
+	level                int
+	text                 string
+	id                   string
+	startIndex, endIndex int
+}
+
+// headings lists the heading paragraphs at the top level of body.
+func headings(body *google.DocumentBody) []heading {
+	var hs []heading
+	for i, el := range body.Content {
+		if el.Paragraph == nil || el.Paragraph.ParagraphStyle == nil {
+			continue
+		}
+		level, ok := headingLevel(el.Paragraph.ParagraphStyle.NamedStyleType)
+		if !ok {
+			continue
+		}
+		hs = append(hs, heading{
+			element:    i,
+			level:      level,
+			text:       strings.TrimSpace(paragraphText(el.Paragraph)),
+			id:         el.Paragraph.ParagraphStyle.HeadingID,
+			startIndex: el.StartIndex,
+			endIndex:   el.EndIndex,
+		})
+	}
+	return hs
+}
+
+func headingLevel(namedStyleType string) (int, bool) {
+	switch namedStyleType {
+	case "TITLE", "SUBTITLE":
+		return 0, true
+	}
+	var level int
+	if _, err := fmt.Sscanf(namedStyleType, "HEADING_%d", &level); err != nil || level < 1 || level > 6 {
+		return 0, false
+	}
+	return level, true
+}
+
+func paragraphText(p *google.Paragraph) string {
+	var sb strings.Builder
+	for _, pe := range p.Elements {
+		if pe.TextRun != nil {
+			sb.WriteString(pe.TextRun.Content)
+		}
+	}
+	return sb.String()
+}
+
+// findHeading returns the instance-th heading whose text matches name,
+// ignoring case and surrounding whitespace.
+func findHeading(body *google.DocumentBody, name string, instance int) (heading, error) {
+	name = strings.TrimSpace(name)
+	for _, h := range headings(body) {
+		if strings.EqualFold(h.text, name) {
+			instance--
+			if instance == 0 {
+				return h, nil
+			}
+		}
+	}
+	return heading{}, fmt.Errorf("heading %q not found", name)
+}
+
+// sectionEnd returns the index of the body element that ends h's section:
+// the next heading of the same or higher level, or len(body.Content).
+func sectionEnd(body *google.DocumentBody, h heading) int {
+	for _, next := range headings(body) {
+		if next.element > h.element && next.level <= h.level {
+			return next.element
+		}
+	}
+	return len(body.Content)
+}
+
+// sectionEndIndex returns the last insertion point in h's section: just
+// before the final newline of its last paragraph, so inserted text joins
+// the section instead of taking on the next heading's style.
+func sectionEndIndex(body *google.DocumentBody, h heading) int {
+	end := sectionEnd(body, h)
+	if end == len(body.Content) {
+		return bodyEndIndex(body)
+	}
+	if last := body.Content[end-1]; last.Paragraph != nil {
+		return last.EndIndex - 1
+	}
+	return body.Content[end].StartIndex
+}
@@ -0,0 +1,312 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/markdown"
+	"github.com/amarbel-llc/piers/internal/textdiff"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+var (
+	headingBlockPattern = regexp.MustCompile(`^(#{1,6})[ \t]`)
+
+	redlineInsertColor    = google.RGB(0.118, 0.557, 0.243) // #1E8E3E
+	redlineDeleteColor    = google.RGB(0.851, 0.188, 0.145) // #D93025
+	redlineFormattedColor = google.RGB(1, 0.949, 0.8)       // #FFF2CC
+)
+
+// blockHeadingLevel returns the level of a markdown heading block, or 0.
+func blockHeadingLevel(block string) int {
+	if m := headingBlockPattern.FindStringSubmatch(block); m != nil {
+		return len(m[1])
+	}
+	return 0
+}
+
+// blockChange is one block of a comparison: kept, added, removed, reworded
+// ("changed") or the same text with different markup ("formatting").
+type blockChange struct {
+	Type    string `json:"type"`
+	Before  string `json:"before,omitempty"`
+	After   string `json:"after,omitempty"`
+	Inline  string `json:"inline,omitempty"`
+	section string
+}
+
+// similarBlocks reports whether b reads as a rewording of a rather than
+// different text: at least half of their plain text is shared.
+func similarBlocks(a, b string) bool {
+	if (blockHeadingLevel(a) > 0) != (blockHeadingLevel(b) > 0) {
+		return false
+	}
+	shared, total := 0, 0
+	for _, seg := range textdiff.WordDiff(markdown.PlainText(a), markdown.PlainText(b)) {
+		n := len(seg.Text)
+		if seg.Op == textdiff.Equal {
+			shared += 2 * n
+			n *= 2
+		}
+		total += n
+	}
+	return total == 0 || 2*shared >= total
+}
+
+// compareBlocks lines up the markdown blocks of two documents. Within
+// each run of differing blocks, a removed block is paired with the next
+// added block that rewords it; the rest are reported as removed or added.
+func compareBlocks(before, after []string) []blockChange {
+	var changes []blockChange
+	pair := func(a, b string) blockChange {
+		if markdown.PlainText(a) == markdown.PlainText(b) {
+			return blockChange{Type: "formatting", Before: a, After: b}
+		}
+		return blockChange{Type: "changed", Before: a, After: b, Inline: textdiff.Inline(a, b)}
+	}
+	i := 0
+	for _, e := range textdiff.Diff(before, after) {
+		for ; i < e.OldStart; i++ {
+			changes = append(changes, blockChange{Type: "unchanged", After: before[i]})
+		}
+		j := e.NewStart
+		for _, old := range before[e.OldStart:e.OldEnd] {
+			k := j
+			for k < e.NewEnd && !similarBlocks(old, after[k]) {
+				k++
+			}
+			if k == e.NewEnd {
+				changes = append(changes, blockChange{Type: "removed", Before: old})
+				continue
+			}
+			for ; j < k; j++ {
+				changes = append(changes, blockChange{Type: "added", After: after[j]})
+			}
+			changes = append(changes, pair(old, after[k]))
+			j++
+		}
+		for ; j < e.NewEnd; j++ {
+			changes = append(changes, blockChange{Type: "added", After: after[j]})
+		}
+		i = e.OldEnd
+	}
+	for ; i < len(before); i++ {
+		changes = append(changes, blockChange{Type: "unchanged", After: before[i]})
+	}
+
+	// Each block belongs to the section of the heading above it, taken
+	// from whichever side the heading appears on.
+	section := ""
+	for c := range changes {
+		block := changes[c].After
+		if block == "" {
+			block = changes[c].Before
+		}
+		if blockHeadingLevel(block) > 0 {
+			section = markdown.PlainText(block)
+		}
+		changes[c].section = section
+	}
+	return changes
+}
+
+// sectionChanges are the changed blocks under one heading. A section whose
+// heading was added or removed is itself added or removed.
+type sectionChanges struct {
+	Heading string        `json:"heading,omitempty"`
+	Status  string        `json:"status"`
+	Changes []blockChange `json:"changes"`
+}
+
+func groupSections(changes []blockChange) []sectionChanges {
+	sections := []sectionChanges{}
+	var current *sectionChanges
+	for c, change := range changes {
+		if c == 0 || change.section != changes[c-1].section {
+			sections = append(sections, sectionChanges{Heading: change.section, Status: "changed"})
+			current = &sections[len(sections)-1]
+			if block := change.After + change.Before; blockHeadingLevel(block) > 0 && (change.Type == "added" || change.Type == "removed") {
+				current.Status = change.Type
+			}
+		}
+		if change.Type != "unchanged" {
+			current.Changes = append(current.Changes, change)
+		}
+	}
+	changed := sections[:0]
+	for _, s := range sections {
+		if len(s.Changes) > 0 {
+			changed = append(changed, s)
+		}
+	}
+	return changed
+}
+
+// redline builds a document that shows a comparison as plain text, with
+// insertions in green and underlined, deletions in red and struck through,
+// and blocks whose formatting changed highlighted.
+type redline struct {
+	text     strings.Builder
+	index    int
+	requests []google.Request
+}
+
+func (r *redline) write(s string, op textdiff.Op) {
+	start := r.index
+	r.text.WriteString(s)
+	r.index += docindex.UTF16Len(s)
+	var style google.TextStyle
+	var fields string
+	switch op {
+	case textdiff.Insert:
+		style, fields = google.TextStyle{Underline: google.Bool(true), ForegroundColor: redlineInsertColor}, "underline,foregroundColor"
+	case textdiff.Delete:
+		style, fields = google.TextStyle{Strikethrough: google.Bool(true), ForegroundColor: redlineDeleteColor}, "strikethrough,foregroundColor"
+	default:
+		return
+	}
+	r.requests = append(r.requests, google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+		Range: google.Range{StartIndex: start, EndIndex: r.index}, TextStyle: style, Fields: fields,
+	}})
+}
+
+// block writes one compared block as its own paragraphs, styled as a
+// heading when it is one.
+func (r *redline) block(c blockChange) {
+	start := r.index
+	before, after := markdown.PlainText(c.Before), markdown.PlainText(c.After)
+	switch c.Type {
+	case "added":
+		r.write(after, textdiff.Insert)
+	case "removed":
+		r.write(before, textdiff.Delete)
+	case "changed":
+		for _, seg := range textdiff.WordDiff(before, after) {
+			r.write(seg.Text, seg.Op)
+		}
+	default:
+		r.write(after, textdiff.Equal)
+	}
+	if c.Type == "formatting" {
+		r.requests = append(r.requests, google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+			Range: google.Range{StartIndex: start, EndIndex: r.index}, TextStyle: google.TextStyle{BackgroundColor: redlineFormattedColor}, Fields: "backgroundColor",
+		}})
+	}
+	r.write("\n", textdiff.Equal)
+	if level := blockHeadingLevel(c.After + c.Before); level > 0 {
+		r.requests = append(r.requests, google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+			Range:          google.Range{StartIndex: start, EndIndex: r.index},
+			ParagraphStyle: google.ParagraphStyle{NamedStyleType: fmt.Sprintf("HEADING_%d", level)},
+			Fields:         "namedStyleType",
+		}})
+	}
+}
+
+// redlineRequests returns the requests that fill an empty document with
+// the redline of changes.
+func redlineRequests(changes []blockChange) []google.Request {
+	r := &redline{index: 1}
+	for _, c := range changes {
+		r.block(c)
+	}
+	insert := google.Request{InsertText: &google.InsertTextRequest{Location: google.Location{Index: 1}, Text: r.text.String()}}
+	return append([]google.Request{insert}, r.requests...)
+}
+
+func registerDocsCompareCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "compareDocuments",
+		Description: command.Description{Short: "Compares a document with another document or a local markdown file, block by block. Returns the added, removed and reworded paragraphs grouped by section, with changed words marked inline as [-removed-] and {+added+}, and blocks whose text is the same but formatting differs. Can also create a redline document showing insertions in green and deletions struck through in red."},
+		Params: []command.Param{
+			{Name: "documentId", Type: command.String, Description: "The original document — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "otherDocumentId", Type: command.String, Description: "The document to compare it with. Provide this or localPath."},
+			{Name: "localPath", Type: command.String, Description: "A local markdown file to compare the document with."},
+			{Name: "tabId", Type: command.String, Description: "The tab of the original document to compare. If not specified, uses the first tab."},
+			{Name: "otherTabId", Type: command.String, Description: "The tab of the other document to compare. If not specified, uses the first tab."},
+			{Name: "redlineTitle", Type: command.String, Description: "If provided, also creates a document with this title showing the comparison as a redline."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID      string `json:"documentId"`
+				OtherDocumentID string `json:"otherDocumentId"`
+				LocalPath       string `json:"localPath"`
+				TabID           string `json:"tabId"`
+				OtherTabID      string `json:"otherTabId"`
+				RedlineTitle    string `json:"redlineTitle"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if (params.OtherDocumentID == "") == (params.LocalPath == "") {
+				return command.TextErrorResult("provide exactly one of otherDocumentId or localPath"), nil
+			}
+
+			blocks := func(documentID, tabID string) (string, []string, error) {
+				doc, err := client.Docs.Get(documentID)
+				if err != nil {
+					return "", nil, fmt.Errorf("failed to read document %s: %w", documentID, err)
+				}
+				tab, err := documentTab(doc, tabID)
+				if err != nil {
+					return "", nil, err
+				}
+				var bs []string
+				for _, b := range markdown.Blocks(tab) {
+					bs = append(bs, b.Markdown)
+				}
+				return doc.Title, bs, nil
+			}
+			fromName, before, err := blocks(params.DocumentID, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(err.Error()), nil
+			}
+			var toName string
+			var after []string
+			if params.LocalPath != "" {
+				content, err := os.ReadFile(params.LocalPath)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to read file: %v", err)), nil
+				}
+				toName, after = params.LocalPath, markdown.SplitBlocks(string(content))
+			} else if toName, after, err = blocks(params.OtherDocumentID, params.OtherTabID); err != nil {
+				return command.TextErrorResult(err.Error()), nil
+			}
+
+			changes := compareBlocks(before, after)
+			summary := map[string]int{"unchanged": 0, "added": 0, "removed": 0, "changed": 0, "formatting": 0}
+			for _, c := range changes {
+				summary[c.Type]++
+			}
+			result := map[string]any{
+				"from":     fromName,
+				"to":       toName,
+				"summary":  summary,
+				"sections": groupSections(changes),
+			}
+
+			if params.RedlineTitle != "" {
+				doc, err := client.Docs.Create(params.RedlineTitle)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to create redline document: %v", err)), nil
+				}
+				redline := map[string]any{
+					"id":  doc.DocumentID,
+					"url": fmt.Sprintf("https://docs.google.com/document/d/%s/edit", doc.DocumentID),
+				}
+				if len(changes) > 0 {
+					if _, err := client.Docs.BatchUpdate(doc.DocumentID, redlineRequests(changes), nil); err != nil {
+						redline["warning"] = fmt.Sprintf("document created but content could not be added: %v", err)
+					}
+				}
+				result["redline"] = redline
+			}
+			return command.JSONResult(result), nil
+		},
+	})
+}
@@ -0,0 +1,458 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// pageSizes are the paper sizes offered by Docs' Page setup dialog, in
+// portrait points.
+var pageSizes = map[string][2]float64{
+	"LETTER":    {612, 792},
+	"LEGAL":     {612, 1008},
+	"TABLOID":   {792, 1224},
+	"STATEMENT": {396, 612},
+	"EXECUTIVE": {522, 756},
+	"FOLIO":     {612, 936},
+	"A3":        {841.89, 1190.55},
+	"A4":        {595.28, 841.89},
+	"A5":        {419.53, 595.28},
+	"B4":        {708.66, 1000.63},
+	"B5":        {498.9, 708.66},
+}
+
+// namedStyleTypes are the paragraph styles a document defines.
+var namedStyleTypes = []string{"NORMAL_TEXT", "TITLE", "SUBTITLE", "HEADING_1", "HEADING_2", "HEADING_3", "HEADING_4", "HEADING_5", "HEADING_6"}
+
+func points(d *google.Dimension) float64 {
+	if d == nil {
+		return 0
+	}
+	return d.Magnitude
+}
+
+// pageStyle is the page setup of a document as shown in Docs, with the
+// page size already turned to its orientation.
+type pageStyle struct {
+	PageSize                 string  `json:"pageSize,omitempty"`
+	PageWidth                float64 `json:"pageWidth"`
+	PageHeight               float64 `json:"pageHeight"`
+	Orientation              string  `json:"orientation"`
+	MarginTop                float64 `json:"marginTop"`
+	MarginBottom             float64 `json:"marginBottom"`
+	MarginLeft               float64 `json:"marginLeft"`
+	MarginRight              float64 `json:"marginRight"`
+	MarginHeader             float64 `json:"marginHeader,omitempty"`
+	MarginFooter             float64 `json:"marginFooter,omitempty"`
+	PageColor                string  `json:"pageColor,omitempty"`
+	PageNumberStart          int     `json:"pageNumberStart,omitempty"`
+	DefaultHeaderID          string  `json:"defaultHeaderId,omitempty"`
+	DefaultFooterID          string  `json:"defaultFooterId,omitempty"`
+	UseFirstPageHeaderFooter bool    `json:"useFirstPageHeaderFooter"`
+	UseEvenPageHeaderFooter  bool    `json:"useEvenPageHeaderFooter"`
+}
+
+func newPageStyle(s *google.DocumentStyle) pageStyle {
+	if s == nil {
+		s = &google.DocumentStyle{}
+	}
+	p := pageStyle{
+		MarginTop:                points(s.MarginTop),
+		MarginBottom:             points(s.MarginBottom),
+		MarginLeft:               points(s.MarginLeft),
+		MarginRight:              points(s.MarginRight),
+		MarginHeader:             points(s.MarginHeader),
+		MarginFooter:             points(s.MarginFooter),
+		PageNumberStart:          s.PageNumberStart,
+		DefaultHeaderID:          s.DefaultHeaderID,
+		DefaultFooterID:          s.DefaultFooterID,
+		UseFirstPageHeaderFooter: s.UseFirstPageHeaderFooter,
+		UseEvenPageHeaderFooter:  s.UseEvenPageHeaderFooter,
+		Orientation:              "portrait",
+	}
+	if s.Background != nil {
+		p.PageColor = formatHexColor(s.Background.Color)
+	}
+	if s.PageSize != nil {
+		p.PageWidth, p.PageHeight = points(s.PageSize.Width), points(s.PageSize.Height)
+	}
+	for name, size := range pageSizes {
+		w, h := min(p.PageWidth, p.PageHeight), max(p.PageWidth, p.PageHeight)
+		if math.Abs(w-size[0]) < 1 && math.Abs(h-size[1]) < 1 {
+			p.PageSize = name
+		}
+	}
+	if s.FlipPageOrientation {
+		p.PageWidth, p.PageHeight = p.PageHeight, p.PageWidth
+	}
+	if p.PageWidth > p.PageHeight {
+		p.Orientation = "landscape"
+	}
+	return p
+}
+
+// namedStyleParagraphs returns the [start, end) ranges of every paragraph
+// in content using the named style, including those inside tables.
+func namedStyleParagraphs(content []google.ContentElement, styleType string) [][2]int {
+	var ranges [][2]int
+	for _, el := range content {
+		if p := el.Paragraph; p != nil {
+			t := "NORMAL_TEXT"
+			if p.ParagraphStyle != nil && p.ParagraphStyle.NamedStyleType != "" {
+				t = p.ParagraphStyle.NamedStyleType
+			}
+			if t == styleType {
+				ranges = append(ranges, [2]int{el.StartIndex, el.EndIndex})
+			}
+		}
+		if el.Table != nil {
+			for _, row := range el.Table.TableRows {
+				for _, cell := range row.TableCells {
+					ranges = append(ranges, namedStyleParagraphs(cell.Content, styleType)...)
+				}
+			}
+		}
+	}
+	return ranges
+}
+
+func registerDocsDocumentStyleCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "getDocumentStyle",
+		Description: command.Description{Short: "Reads a document's page setup: page size and orientation, margins, page color, page numbering and which headers and footers are in use. Sizes are in points."},
+		Params: []command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				TabID      string `json:"tabId"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to get document style: %v", err)), nil
+			}
+			return command.JSONResult(newPageStyle(tab.DocumentStyle)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "updateDocumentStyle",
+		Description: command.Description{Short: "Changes a document's page setup: page size, orientation, margins, page color, first page number and whether the first page and even pages get their own headers and footers. Only the options given are changed; sizes are in points (72 per inch)."},
+		Params: append([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "pageSize", Type: command.String, Description: "A named paper size: LETTER, LEGAL, TABLOID, STATEMENT, EXECUTIVE, FOLIO, A3, A4, A5, B4 or B5."},
+			{Name: "pageWidth", Type: command.Float, Description: "Custom page width in points (alternative to pageSize, with pageHeight)."},
+			{Name: "pageHeight", Type: command.Float, Description: "Custom page height in points (alternative to pageSize, with pageWidth)."},
+			{Name: "orientation", Type: command.String, Description: "'portrait' or 'landscape'."},
+			{Name: "marginTop", Type: command.Float, Description: "Top page margin in points."},
+			{Name: "marginBottom", Type: command.Float, Description: "Bottom page margin in points."},
+			{Name: "marginLeft", Type: command.Float, Description: "Left page margin in points."},
+			{Name: "marginRight", Type: command.Float, Description: "Right page margin in points."},
+			{Name: "marginHeader", Type: command.Float, Description: "Distance from the top of the page to the header, in points."},
+			{Name: "marginFooter", Type: command.Float, Description: "Distance from the bottom of the page to the footer, in points."},
+			{Name: "pageColor", Type: command.String, Description: "Page background color in hex format (e.g., \"#FFF8E1\")."},
+			{Name: "pageNumberStart", Type: command.Int, Description: "The number of the first page, for page number fields."},
+			{Name: "useFirstPageHeaderFooter", Type: command.Bool, Description: "Give the first page its own header and footer."},
+			{Name: "useEvenPageHeaderFooter", Type: command.Bool, Description: "Give even pages their own header and footer."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, writeControlParams...),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID               string   `json:"documentId"`
+				PageSize                 string   `json:"pageSize"`
+				PageWidth                float64  `json:"pageWidth"`
+				PageHeight               float64  `json:"pageHeight"`
+				Orientation              string   `json:"orientation"`
+				MarginTop                *float64 `json:"marginTop"`
+				MarginBottom             *float64 `json:"marginBottom"`
+				MarginLeft               *float64 `json:"marginLeft"`
+				MarginRight              *float64 `json:"marginRight"`
+				MarginHeader             *float64 `json:"marginHeader"`
+				MarginFooter             *float64 `json:"marginFooter"`
+				PageColor                string   `json:"pageColor"`
+				PageNumberStart          int      `json:"pageNumberStart"`
+				UseFirstPageHeaderFooter *bool    `json:"useFirstPageHeaderFooter"`
+				UseEvenPageHeaderFooter  *bool    `json:"useEvenPageHeaderFooter"`
+				TabID                    string   `json:"tabId"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if params.PageSize != "" && (params.PageWidth > 0 || params.PageHeight > 0) {
+				return command.TextErrorResult("provide either pageSize or pageWidth and pageHeight, not both"), nil
+			}
+			if (params.PageWidth > 0) != (params.PageHeight > 0) {
+				return command.TextErrorResult("pageWidth and pageHeight must be given together"), nil
+			}
+			if params.Orientation != "" && params.Orientation != "portrait" && params.Orientation != "landscape" {
+				return command.TextErrorResult(fmt.Sprintf("invalid orientation %q: must be portrait or landscape", params.Orientation)), nil
+			}
+
+			var style google.DocumentStyle
+			var fields []string
+			if params.PageSize != "" {
+				size, ok := pageSizes[strings.ToUpper(params.PageSize)]
+				if !ok {
+					return command.TextErrorResult(fmt.Sprintf("unknown pageSize %q", params.PageSize)), nil
+				}
+				params.PageWidth, params.PageHeight = size[0], size[1]
+			}
+			for _, m := range []struct {
+				name  string
+				value *float64
+				dst   **google.Dimension
+			}{
+				{"marginTop", params.MarginTop, &style.MarginTop},
+				{"marginBottom", params.MarginBottom, &style.MarginBottom},
+				{"marginLeft", params.MarginLeft, &style.MarginLeft},
+				{"marginRight", params.MarginRight, &style.MarginRight},
+				{"marginHeader", params.MarginHeader, &style.MarginHeader},
+				{"marginFooter", params.MarginFooter, &style.MarginFooter},
+			} {
+				if m.value == nil {
+					continue
+				}
+				if *m.value < 0 {
+					return command.TextErrorResult(fmt.Sprintf("%s cannot be negative", m.name)), nil
+				}
+				*m.dst = google.Points(*m.value)
+				fields = append(fields, m.name)
+			}
+			if params.PageColor != "" {
+				color, err := parseHexColor(params.PageColor)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("invalid pageColor: %v", err)), nil
+				}
+				style.Background = &google.Background{Color: color}
+				fields = append(fields, "background")
+			}
+			if params.PageNumberStart > 0 {
+				style.PageNumberStart = params.PageNumberStart
+				fields = append(fields, "pageNumberStart")
+			}
+			if params.UseFirstPageHeaderFooter != nil {
+				style.UseFirstPageHeaderFooter = *params.UseFirstPageHeaderFooter
+				fields = append(fields, "useFirstPageHeaderFooter")
+			}
+			if params.UseEvenPageHeaderFooter != nil {
+				style.UseEvenPageHeaderFooter = *params.UseEvenPageHeaderFooter
+				fields = append(fields, "useEvenPageHeaderFooter")
+			}
+
+			if params.PageWidth > 0 || params.Orientation != "" {
+				doc, err := client.Docs.Get(params.DocumentID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+				}
+				tab, err := documentTab(doc, params.TabID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to update document style: %v", err)), nil
+				}
+				// Orientation is kept separately from the stored page size,
+				// so a new size is stored as given and the flag decides
+				// which way round it is shown.
+				current := newPageStyle(tab.DocumentStyle)
+				width, height := params.PageWidth, params.PageHeight
+				if width == 0 {
+					width, height = current.PageWidth, current.PageHeight
+					if tab.DocumentStyle != nil && tab.DocumentStyle.FlipPageOrientation {
+						width, height = height, width
+					}
+				} else {
+					style.PageSize = &google.Size{Width: google.Points(width), Height: google.Points(height)}
+					fields = append(fields, "pageSize")
+				}
+				landscape := current.Orientation == "landscape"
+				if params.Orientation != "" {
+					landscape = params.Orientation == "landscape"
+				}
+				style.FlipPageOrientation = landscape != (width > height)
+				fields = append(fields, "flipPageOrientation")
+			}
+			if len(fields) == 0 {
+				return command.TextResult("No document style options were provided."), nil
+			}
+
+			req := google.Request{UpdateDocumentStyle: &google.UpdateDocumentStyleRequest{
+				DocumentStyle: style,
+				Fields:        strings.Join(fields, ","),
+				TabID:         params.TabID,
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(params.ExpectedRevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to update document style: %v", err)), nil
+			}
+			return command.TextResult(fmt.Sprintf("Successfully updated document style (%s).", strings.Join(fields, ", "))), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "getNamedStyles",
+		Description: command.Description{Short: "Reads the definitions of a document's named paragraph styles (NORMAL_TEXT, TITLE, SUBTITLE, HEADING_1 to HEADING_6): font, size, colors, alignment and spacing."},
+		Params: []command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				TabID      string `json:"tabId"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to get named styles: %v", err)), nil
+			}
+
+			type namedStyle struct {
+				NamedStyleType  string  `json:"namedStyleType"`
+				FontFamily      string  `json:"fontFamily,omitempty"`
+				FontSize        float64 `json:"fontSize,omitempty"`
+				Bold            bool    `json:"bold,omitempty"`
+				Italic          bool    `json:"italic,omitempty"`
+				Underline       bool    `json:"underline,omitempty"`
+				ForegroundColor string  `json:"foregroundColor,omitempty"`
+				BackgroundColor string  `json:"backgroundColor,omitempty"`
+				Alignment       string  `json:"alignment,omitempty"`
+				SpaceAbove      float64 `json:"spaceAbove,omitempty"`
+				SpaceBelow      float64 `json:"spaceBelow,omitempty"`
+			}
+			styles := []namedStyle{}
+			if tab.NamedStyles != nil {
+				for _, s := range tab.NamedStyles.Styles {
+					ns := namedStyle{NamedStyleType: s.NamedStyleType}
+					if ts := s.TextStyle; ts != nil {
+						if ts.WeightedFontFamily != nil {
+							ns.FontFamily = ts.WeightedFontFamily.FontFamily
+						}
+						ns.FontSize = points(ts.FontSize)
+						ns.Bold, ns.Italic, ns.Underline = google.Flag(ts.Bold), google.Flag(ts.Italic), google.Flag(ts.Underline)
+						ns.ForegroundColor = formatHexColor(ts.ForegroundColor)
+						ns.BackgroundColor = formatHexColor(ts.BackgroundColor)
+					}
+					if ps := s.ParagraphStyle; ps != nil {
+						ns.Alignment = ps.Alignment
+						ns.SpaceAbove, ns.SpaceBelow = points(ps.SpaceAbove), points(ps.SpaceBelow)
+					}
+					styles = append(styles, ns)
+				}
+			}
+			return command.JSONResult(styles), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "updateNamedStyle",
+		Description: command.Description{Short: "Restyles every paragraph that uses a named style, e.g. make all HEADING_2 paragraphs dark blue 16pt Georgia. The Docs API cannot edit the style definition itself, so paragraphs added later still get the old look; use 'Update heading to match' in Google Docs for that."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "namedStyleType", Type: command.String, Description: "The style to change: NORMAL_TEXT, TITLE, SUBTITLE, HEADING_1 through HEADING_6.", Required: true},
+		}, textStyleParams, []command.Param{
+			{Name: "alignment", Type: command.String, Description: "Paragraph alignment: START, END, CENTER, or JUSTIFIED."},
+			{Name: "spaceAbove", Type: command.Float, Description: "Space before each paragraph in points."},
+			{Name: "spaceBelow", Type: command.Float, Description: "Space after each paragraph in points."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID     string `json:"documentId"`
+				NamedStyleType string `json:"namedStyleType"`
+				textStyleOptions
+				Alignment  string   `json:"alignment"`
+				SpaceAbove *float64 `json:"spaceAbove"`
+				SpaceBelow *float64 `json:"spaceBelow"`
+				TabID      string   `json:"tabId"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			styleType := strings.ToUpper(params.NamedStyleType)
+			if !slices.Contains(namedStyleTypes, styleType) {
+				return command.TextErrorResult(fmt.Sprintf("invalid namedStyleType %q: must be one of %s", params.NamedStyleType, strings.Join(namedStyleTypes, ", "))), nil
+			}
+
+			textStyle, textFields, err := params.textStyleOptions.build()
+			if err != nil {
+				return command.TextErrorResult(err.Error()), nil
+			}
+			var paragraphStyle google.ParagraphStyle
+			var paragraphFields []string
+			if params.Alignment != "" {
+				a := strings.ToUpper(params.Alignment)
+				if !slices.Contains([]string{"START", "END", "CENTER", "JUSTIFIED"}, a) {
+					return command.TextErrorResult(fmt.Sprintf("invalid alignment %q: must be START, END, CENTER or JUSTIFIED", params.Alignment)), nil
+				}
+				paragraphStyle.Alignment = a
+				paragraphFields = append(paragraphFields, "alignment")
+			}
+			if params.SpaceAbove != nil {
+				paragraphStyle.SpaceAbove = google.Points(*params.SpaceAbove)
+				paragraphFields = append(paragraphFields, "spaceAbove")
+			}
+			if params.SpaceBelow != nil {
+				paragraphStyle.SpaceBelow = google.Points(*params.SpaceBelow)
+				paragraphFields = append(paragraphFields, "spaceBelow")
+			}
+			if len(textFields) == 0 && len(paragraphFields) == 0 {
+				return command.TextResult("No valid styling options were provided."), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to update named style: %v", err)), nil
+			}
+			ranges := namedStyleParagraphs(body.Content, styleType)
+			if len(ranges) == 0 {
+				return command.TextResult(fmt.Sprintf("No paragraphs use %s; nothing to change.", styleType)), nil
+			}
+
+			var requests []google.Request
+			for _, r := range ranges {
+				rng := google.Range{StartIndex: r[0], EndIndex: r[1], TabID: params.TabID}
+				if len(textFields) > 0 {
+					requests = append(requests, google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+						Range: rng, TextStyle: textStyle, Fields: strings.Join(textFields, ","),
+					}})
+				}
+				if len(paragraphFields) > 0 {
+					requests = append(requests, google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+						Range: rng, ParagraphStyle: paragraphStyle, Fields: strings.Join(paragraphFields, ","),
+					}})
+				}
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to update named style: %v", err)), nil
+			}
+			return command.TextResult(fmt.Sprintf("Successfully restyled %d %s paragraph(s) (%s).", len(ranges), styleType, strings.Join(slices.Concat(textFields, paragraphFields), ", "))), nil
+		},
+	})
+}
@@ -4,54 +4,232 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/amarbel-llc/piers/internal/docindex"
 	"github.com/amarbel-llc/piers/internal/google"
 	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
 )
 
+// parseHexColor parses "#RRGGBB" or "#RGB", with or without the leading
+// hash, into a Docs color.
+func parseHexColor(hex string) (*google.OptionalColor, error) {
+	h := strings.TrimPrefix(hex, "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) != 6 {
+		return nil, fmt.Errorf("%q is not a hex color like #FF0000", hex)
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a hex color like #FF0000", hex)
+	}
+	return google.RGB(float64(v>>16&0xff)/255, float64(v>>8&0xff)/255, float64(v&0xff)/255), nil
+}
+
+// formatHexColor is the inverse of parseHexColor, returning "" for an unset
+// color.
+func formatHexColor(c *google.OptionalColor) string {
+	if c == nil || c.Color == nil || c.Color.RGBColor == nil {
+		return ""
+	}
+	rgb := c.Color.RGBColor
+	return fmt.Sprintf("#%02X%02X%02X", int(rgb.Red*255+0.5), int(rgb.Green*255+0.5), int(rgb.Blue*255+0.5))
+}
+
+// textStyleParams are the character formatting options shared by
+// applyTextStyle and updateNamedStyle.
+var textStyleParams = []command.Param{
+	{Name: "bold", Type: command.Bool, Description: "Apply bold formatting."},
+	{Name: "italic", Type: command.Bool, Description: "Apply italic formatting."},
+	{Name: "underline", Type: command.Bool, Description: "Apply underline formatting."},
+	{Name: "strikethrough", Type: command.Bool, Description: "Apply strikethrough formatting."},
+	{Name: "fontSize", Type: command.Int, Description: "Set font size (in points, e.g., 12)."},
+	{Name: "fontFamily", Type: command.String, Description: "Set font family (e.g., \"Arial\", \"Times New Roman\")."},
+	{Name: "foregroundColor", Type: command.String, Description: "Set text color using hex format (e.g., \"#FF0000\")."},
+	{Name: "backgroundColor", Type: command.String, Description: "Set text background color using hex format (e.g., \"#FFFF00\")."},
+}
+
+// textStyleOptions holds the arguments described by textStyleParams.
+type textStyleOptions struct {
+	Bold            *bool   `json:"bold"`
+	Italic          *bool   `json:"italic"`
+	Underline       *bool   `json:"underline"`
+	Strikethrough   *bool   `json:"strikethrough"`
+	FontSize        float64 `json:"fontSize"`
+	FontFamily      string  `json:"fontFamily"`
+	ForegroundColor string  `json:"foregroundColor"`
+	BackgroundColor string  `json:"backgroundColor"`
+}
+
+// build returns the style the options describe and the field mask naming
+// the properties that were set.
+func (o textStyleOptions) build() (google.TextStyle, []string, error) {
+	var style google.TextStyle
+	var fields []string
+	for _, b := range []struct {
+		name  string
+		value *bool
+		dst   **bool
+	}{
+		{"bold", o.Bold, &style.Bold},
+		{"italic", o.Italic, &style.Italic},
+		{"underline", o.Underline, &style.Underline},
+		{"strikethrough", o.Strikethrough, &style.Strikethrough},
+	} {
+		if b.value != nil {
+			*b.dst = b.value
+			fields = append(fields, b.name)
+		}
+	}
+	if o.FontSize > 0 {
+		style.FontSize = google.Points(o.FontSize)
+		fields = append(fields, "fontSize")
+	}
+	if o.FontFamily != "" {
+		style.WeightedFontFamily = &google.WeightedFontFamily{FontFamily: o.FontFamily}
+		fields = append(fields, "weightedFontFamily")
+	}
+	for _, c := range []struct {
+		name  string
+		value string
+		dst   **google.OptionalColor
+	}{
+		{"foregroundColor", o.ForegroundColor, &style.ForegroundColor},
+		{"backgroundColor", o.BackgroundColor, &style.BackgroundColor},
+	} {
+		if c.value == "" {
+			continue
+		}
+		color, err := parseHexColor(c.value)
+		if err != nil {
+			return style, nil, fmt.Errorf("invalid %s: %v", c.name, err)
+		}
+		*c.dst = color
+		fields = append(fields, c.name)
+	}
+	return style, fields, nil
+}
+
+// textStyleFields masks every property TextStyle models, so that an
+// update with it gives text exactly the style passed.
+const textStyleFields = "bold,italic,underline,strikethrough,fontSize,weightedFontFamily,foregroundColor,backgroundColor,link"
+
+// runStyle returns the style of the text run at index in content, nil when
+// there is none or it has no formatting of its own.
+func runStyle(content []google.ContentElement, index int) *google.TextStyle {
+	var style *google.TextStyle
+	walkParagraphs(content, index, index+1, func(el google.ContentElement) {
+		for _, pe := range el.Paragraph.Elements {
+			if pe.TextRun != nil && pe.StartIndex <= index && index < pe.EndIndex {
+				style = pe.TextRun.TextStyle
+			}
+		}
+	})
+	return style
+}
+
+// restyle returns the request that gives r the style of a run read from
+// the document, replacing whatever it took on from the text around it.
+func restyle(r google.Range, style *google.TextStyle) google.Request {
+	if style == nil {
+		style = &google.TextStyle{}
+	}
+	return google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{Range: r, TextStyle: *style, Fields: textStyleFields}}
+}
+
 func registerDocsFormattingCommands(app *command.App, client *google.Client) {
 	app.AddCommand(&command.Command{
 		Name:        "applyTextStyle",
 		Description: command.Description{Short: "Applies character-level formatting (bold, italic, color, font, etc.) to text identified by a character range or by searching for a text string."},
-		Params: []command.Param{
+		Params: slices.Concat([]command.Param{
 			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
 			{Name: "startIndex", Type: command.Int, Description: "The starting index of the text range (inclusive, starts from 1)."},
 			{Name: "endIndex", Type: command.Int, Description: "The ending index of the text range (exclusive)."},
 			{Name: "textToFind", Type: command.String, Description: "The exact text string to locate (alternative to using startIndex/endIndex)."},
 			{Name: "matchInstance", Type: command.Int, Description: "Which instance of the text to target (1st, 2nd, etc.). Defaults to 1."},
-			{Name: "bold", Type: command.Bool, Description: "Apply bold formatting."},
-			{Name: "italic", Type: command.Bool, Description: "Apply italic formatting."},
-			{Name: "underline", Type: command.Bool, Description: "Apply underline formatting."},
-			{Name: "strikethrough", Type: command.Bool, Description: "Apply strikethrough formatting."},
-			{Name: "fontSize", Type: command.Int, Description: "Set font size (in points, e.g., 12)."},
-			{Name: "fontFamily", Type: command.String, Description: "Set font family (e.g., \"Arial\", \"Times New Roman\")."},
-			{Name: "foregroundColor", Type: command.String, Description: "Set text color using hex format (e.g., \"#FF0000\")."},
-			{Name: "backgroundColor", Type: command.String, Description: "Set text background color using hex format (e.g., \"#FFFF00\")."},
+		}, textStyleParams, []command.Param{
 			{Name: "linkUrl", Type: command.String, Description: "Make the text a hyperlink pointing to this URL."},
 			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to apply formatting in. If not specified, operates on the first tab."},
-		},
+		}, writeControlParams),
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
-				DocumentID string `json:"documentId"`
-				StartIndex int    `json:"startIndex"`
-				EndIndex   int    `json:"endIndex"`
+				DocumentID    string `json:"documentId"`
+				StartIndex    int    `json:"startIndex"`
+				EndIndex      int    `json:"endIndex"`
+				TextToFind    string `json:"textToFind"`
+				MatchInstance int    `json:"matchInstance"`
+				textStyleOptions
+				LinkURL string `json:"linkUrl"`
+				TabID   string `json:"tabId"`
+				writeControl
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
 			}
+			if params.MatchInstance == 0 {
+				params.MatchInstance = 1
+			}
+
+			style, fields, err := params.textStyleOptions.build()
+			if err != nil {
+				return command.TextErrorResult(err.Error()), nil
+			}
+			if params.LinkURL != "" {
+				style.Link = &google.Link{URL: params.LinkURL}
+				fields = append(fields, "link")
+			}
+			if len(fields) == 0 {
+				return command.TextResult("No valid text styling options were provided."), nil
+			}
 
-			if err := client.Docs.BatchUpdate(params.DocumentID, nil); err != nil {
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to apply text style: %v", err)), nil
+			}
+			text := docindex.New(body)
+
+			start, end := params.StartIndex, params.EndIndex
+			if params.TextToFind != "" {
+				var ok bool
+				start, end, ok = text.Find(params.TextToFind, params.MatchInstance)
+				if !ok {
+					return command.TextErrorResult(fmt.Sprintf("could not find instance %d of text %q", params.MatchInstance, params.TextToFind)), nil
+				}
+			} else {
+				if err := checkRange(body, text, start, end); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to apply text style: %v", err)), nil
+				}
+			}
+
+			req := google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+				Range:     google.Range{StartIndex: start, EndIndex: end, TabID: params.TabID},
+				TextStyle: style,
+				Fields:    strings.Join(fields, ","),
+			}}
+			base := doc.RevisionID
+			if params.TextToFind == "" {
+				base = params.ExpectedRevisionID
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(base)); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to apply text style: %v", err)), nil
 			}
 
-			return command.TextResult("Successfully applied text style."), nil
+			return command.TextResult(fmt.Sprintf("Successfully applied text style (%s) to range %d-%d.", strings.Join(fields, ", "), start, end)), nil
 		},
 	})
 
 	app.AddCommand(&command.Command{
 		Name:        "applyParagraphStyle",
 		Description: command.Description{Short: "Applies paragraph-level formatting (alignment, spacing, heading styles) to paragraphs identified by a character range or by searching for text. Use namedStyleType to set heading levels."},
-		Params: []command.Param{
+		Params: append([]command.Param{
 			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
 			{Name: "startIndex", Type: command.Int, Description: "The starting index of the paragraph range (inclusive, starts from 1)."},
 			{Name: "endIndex", Type: command.Int, Description: "The ending index of the paragraph range (exclusive)."},
@@ -66,20 +244,113 @@ func registerDocsFormattingCommands(app *command.App, client *google.Client) {
 			{Name: "namedStyleType", Type: command.String, Description: "Apply a built-in named paragraph style: NORMAL_TEXT, TITLE, SUBTITLE, HEADING_1 through HEADING_6."},
 			{Name: "keepWithNext", Type: command.Bool, Description: "Keep this paragraph together with the next one on the same page."},
 			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to apply formatting in. If not specified, operates on the first tab."},
-		},
+		}, writeControlParams...),
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
 				DocumentID string `json:"documentId"`
+				paragraphRange
+				IndexWithinParagraph int      `json:"indexWithinParagraph"`
+				Alignment            string   `json:"alignment"`
+				IndentStart          *float64 `json:"indentStart"`
+				IndentEnd            *float64 `json:"indentEnd"`
+				SpaceAbove           *float64 `json:"spaceAbove"`
+				SpaceBelow           *float64 `json:"spaceBelow"`
+				NamedStyleType       string   `json:"namedStyleType"`
+				KeepWithNext         *bool    `json:"keepWithNext"`
+				TabID                string   `json:"tabId"`
+				writeControl
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
 			}
 
-			if err := client.Docs.BatchUpdate(params.DocumentID, nil); err != nil {
+			var style google.ParagraphStyle
+			var fields []string
+			if params.NamedStyleType != "" {
+				t := strings.ToUpper(params.NamedStyleType)
+				if !slices.Contains(namedStyleTypes, t) {
+					return command.TextErrorResult(fmt.Sprintf("invalid namedStyleType %q: must be one of %s", params.NamedStyleType, strings.Join(namedStyleTypes, ", "))), nil
+				}
+				style.NamedStyleType = t
+				fields = append(fields, "namedStyleType")
+			}
+			if params.Alignment != "" {
+				a := strings.ToUpper(params.Alignment)
+				if !slices.Contains([]string{"START", "END", "CENTER", "JUSTIFIED"}, a) {
+					return command.TextErrorResult(fmt.Sprintf("invalid alignment %q: must be START, END, CENTER or JUSTIFIED", params.Alignment)), nil
+				}
+				style.Alignment = a
+				fields = append(fields, "alignment")
+			}
+			for _, d := range []struct {
+				name  string
+				value *float64
+				dst   **google.Dimension
+			}{
+				{"indentStart", params.IndentStart, &style.IndentStart},
+				{"indentEnd", params.IndentEnd, &style.IndentEnd},
+				{"spaceAbove", params.SpaceAbove, &style.SpaceAbove},
+				{"spaceBelow", params.SpaceBelow, &style.SpaceBelow},
+			} {
+				if d.value != nil {
+					*d.dst = google.Points(*d.value)
+					fields = append(fields, d.name)
+				}
+			}
+			if params.KeepWithNext != nil {
+				style.KeepWithNext = params.KeepWithNext
+				fields = append(fields, "keepWithNext")
+			}
+			if len(fields) == 0 {
+				return command.TextResult("No valid paragraph styling options were provided."), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to apply paragraph style: %v", err)), nil
+			}
+			text := docindex.New(body)
+
+			// The API restyles every paragraph the range overlaps, so an
+			// index within a paragraph stands for the paragraph around it.
+			var start, end int
+			if i := params.IndexWithinParagraph; i != 0 {
+				if params.TextToFind != "" || params.StartIndex != 0 || params.EndIndex != 0 {
+					return command.TextErrorResult("provide indexWithinParagraph, startIndex/endIndex or textToFind, not several"), nil
+				}
+				if err = params.checkIndices(doc); err == nil {
+					err = checkIndex(body, text, "indexWithinParagraph", i)
+				}
+				if err == nil {
+					walkParagraphs(body.Content, i, i+1, func(el google.ContentElement) {
+						start, end = el.StartIndex, el.EndIndex
+					})
+				}
+			} else {
+				start, end, err = params.paragraphRange.resolve(body, text)
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to apply paragraph style: %v", err)), nil
+			}
+
+			base := doc.RevisionID
+			if params.TextToFind == "" && params.IndexWithinParagraph == 0 {
+				base = params.ExpectedRevisionID
+			}
+			req := google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+				Range:          google.Range{StartIndex: start, EndIndex: end, TabID: params.TabID},
+				ParagraphStyle: style,
+				Fields:         strings.Join(fields, ","),
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(base)); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to apply paragraph style: %v", err)), nil
 			}
 
-			return command.TextResult("Successfully applied paragraph style."), nil
+			return command.TextResult(fmt.Sprintf("Successfully applied paragraph style (%s) to the paragraphs in range %d-%d.", strings.Join(fields, ", "), start, end)), nil
 		},
 	})
 }
@@ -0,0 +1,367 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// headerFooterTypes are the variants a document can have of its header and
+// of its footer.
+var headerFooterTypes = []string{"default", "firstPage", "evenPage"}
+
+// headerFooter is one header or footer variant of a tab. ID is empty when
+// the variant does not exist; inUse is false for a first-page or even-page
+// variant that the document style has switched off.
+type headerFooter struct {
+	kind, typ string
+	id        string
+	inUse     bool
+	content   []google.ContentElement
+}
+
+func findHeaderFooter(tab *google.DocumentTab, kind, typ string) (headerFooter, error) {
+	if kind != "header" && kind != "footer" {
+		return headerFooter{}, fmt.Errorf("kind must be 'header' or 'footer', got %q", kind)
+	}
+	if typ == "" {
+		typ = "default"
+	}
+	style := tab.DocumentStyle
+	if style == nil {
+		style = &google.DocumentStyle{}
+	}
+	hf := headerFooter{kind: kind, typ: typ}
+	ids := map[string][2]string{
+		"default":   {style.DefaultHeaderID, style.DefaultFooterID},
+		"firstPage": {style.FirstPageHeaderID, style.FirstPageFooterID},
+		"evenPage":  {style.EvenPageHeaderID, style.EvenPageFooterID},
+	}
+	pair, ok := ids[typ]
+	if !ok {
+		return headerFooter{}, fmt.Errorf("type must be one of %s, got %q", strings.Join(headerFooterTypes, ", "), typ)
+	}
+	switch typ {
+	case "default":
+		hf.inUse = true
+	case "firstPage":
+		hf.inUse = style.UseFirstPageHeaderFooter
+	case "evenPage":
+		hf.inUse = style.UseEvenPageHeaderFooter
+	}
+	if kind == "header" {
+		hf.id = pair[0]
+		hf.content = tab.Headers[hf.id].Content
+	} else {
+		hf.id = pair[1]
+		hf.content = tab.Footers[hf.id].Content
+	}
+	return hf, nil
+}
+
+func segmentText(content []google.ContentElement) string {
+	var sb strings.Builder
+	for _, el := range content {
+		if el.Paragraph != nil {
+			sb.WriteString(paragraphText(el.Paragraph))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// findFootnoteReference returns the ID of the footnote referenced at index
+// in the body, looking inside tables too.
+func findFootnoteReference(content []google.ContentElement, index int) string {
+	for _, el := range content {
+		switch {
+		case el.Paragraph != nil:
+			for _, pe := range el.Paragraph.Elements {
+				if pe.FootnoteReference != nil && pe.StartIndex == index {
+					return pe.FootnoteReference.FootnoteID
+				}
+			}
+		case el.Table != nil:
+			for _, row := range el.Table.TableRows {
+				for _, cell := range row.TableCells {
+					if id := findFootnoteReference(cell.Content, index); id != "" {
+						return id
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+var headerFooterParams = []command.Param{
+	{Name: "kind", Type: command.String, Description: "Either 'header' or 'footer'.", Required: true},
+	{Name: "type", Type: command.String, Description: "Which variant: 'default' (every page without a more specific one), 'firstPage' or 'evenPage'. Defaults to 'default'."},
+}
+
+func registerDocsHeaderCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "listHeadersFooters",
+		Description: command.Description{Short: "Lists the headers and footers of a document (default, first page and even pages) with their IDs, text, and whether the document currently shows them."},
+		Params: []command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				TabID      string `json:"tabId"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to list headers and footers: %v", err)), nil
+			}
+
+			type info struct {
+				Kind  string `json:"kind"`
+				Type  string `json:"type"`
+				ID    string `json:"id"`
+				InUse bool   `json:"inUse"`
+				Text  string `json:"text"`
+			}
+			infos := []info{}
+			for _, kind := range []string{"header", "footer"} {
+				for _, typ := range headerFooterTypes {
+					hf, _ := findHeaderFooter(tab, kind, typ)
+					if hf.id != "" {
+						infos = append(infos, info{Kind: kind, Type: typ, ID: hf.id, InUse: hf.inUse, Text: segmentText(hf.content)})
+					}
+				}
+			}
+			return command.JSONResult(map[string]any{"headersFooters": infos}), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "setHeaderFooter",
+		Description: command.Description{Short: "Sets the text of a header or footer, creating the default one if the document has none. A first-page or even-page variant is switched on if needed, but the Docs API can only create default headers and footers, so those variants must already exist."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "text", Type: command.String, Description: "The new text. Newlines start new paragraphs; an empty string clears it.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, headerFooterParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				Kind       string `json:"kind"`
+				Type       string `json:"type"`
+				Text       string `json:"text"`
+				TabID      string `json:"tabId"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to set %s: %v", params.Kind, err)), nil
+			}
+			hf, err := findHeaderFooter(tab, params.Kind, params.Type)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to set %s: %v", params.Kind, err)), nil
+			}
+
+			if hf.id == "" {
+				if hf.typ != "default" {
+					return command.TextErrorResult(fmt.Sprintf("failed to set %s: the document has no %s %s and the Docs API cannot create one; add it once in the Docs editor (Format > Headers & footers), then try again", hf.kind, hf.typ, hf.kind)), nil
+				}
+				req := google.Request{CreateHeader: &google.CreateHeaderFooterRequest{Type: "DEFAULT"}}
+				if hf.kind == "footer" {
+					req = google.Request{CreateFooter: &google.CreateHeaderFooterRequest{Type: "DEFAULT"}}
+				}
+				resp, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(doc.RevisionID))
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to create %s: %v", hf.kind, err)), nil
+				}
+				params.applied(resp)
+				// The new segment's ID is only known to the document.
+				if doc, err = client.Docs.Get(params.DocumentID); err == nil {
+					if tab, err = documentTab(doc, params.TabID); err == nil {
+						hf, err = findHeaderFooter(tab, params.Kind, params.Type)
+					}
+				}
+				if err == nil && hf.id == "" {
+					err = fmt.Errorf("it was not found after creating it")
+				}
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("created the %s but failed to set its text: %v", params.Kind, err)), nil
+				}
+			}
+
+			var requests []google.Request
+			if !hf.inUse {
+				style, field := google.DocumentStyle{UseFirstPageHeaderFooter: true}, "useFirstPageHeaderFooter"
+				if hf.typ == "evenPage" {
+					style, field = google.DocumentStyle{UseEvenPageHeaderFooter: true}, "useEvenPageHeaderFooter"
+				}
+				requests = append(requests, google.Request{UpdateDocumentStyle: &google.UpdateDocumentStyleRequest{
+					DocumentStyle: style, Fields: field, TabID: params.TabID,
+				}})
+			}
+			// The segment's final newline cannot be deleted, so the new
+			// text goes in front of it without one of its own.
+			start, end := 0, 0
+			if n := len(hf.content); n > 0 {
+				start, end = hf.content[0].StartIndex, hf.content[n-1].EndIndex-1
+			}
+			if end > start {
+				requests = append(requests, google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+					Range: google.Range{StartIndex: start, EndIndex: end, SegmentID: hf.id, TabID: params.TabID},
+				}})
+			}
+			if text := strings.TrimSuffix(params.Text, "\n"); text != "" {
+				requests = append(requests, google.Request{InsertText: &google.InsertTextRequest{
+					Location: google.Location{Index: start, SegmentID: hf.id, TabID: params.TabID},
+					Text:     text,
+				}})
+			}
+			if len(requests) > 0 {
+				if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to set %s: %v", hf.kind, err)), nil
+				}
+			}
+			return command.TextResult(fmt.Sprintf("Successfully set the %s %s (ID: %s) to %d characters.", hf.typ, hf.kind, hf.id, docindex.UTF16Len(params.Text))), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "deleteHeaderFooter",
+		Description: command.Description{Short: "Deletes a header or footer and its content."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, headerFooterParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				Kind       string `json:"kind"`
+				Type       string `json:"type"`
+				TabID      string `json:"tabId"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete %s: %v", params.Kind, err)), nil
+			}
+			hf, err := findHeaderFooter(tab, params.Kind, params.Type)
+			if err == nil && hf.id == "" {
+				err = fmt.Errorf("the document has no %s %s", hf.typ, hf.kind)
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete %s: %v", params.Kind, err)), nil
+			}
+
+			req := google.Request{DeleteHeader: &google.DeleteHeaderRequest{HeaderID: hf.id, TabID: params.TabID}}
+			if hf.kind == "footer" {
+				req = google.Request{DeleteFooter: &google.DeleteFooterRequest{FooterID: hf.id, TabID: params.TabID}}
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete %s: %v", hf.kind, err)), nil
+			}
+			return command.TextResult(fmt.Sprintf("Successfully deleted the %s %s (ID: %s).", hf.typ, hf.kind, hf.id)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "insertFootnote",
+		Description: command.Description{Short: "Inserts a footnote with the given text at a character index, or next to existing text, a heading or a named range. Footnotes appear in readDocument's markdown output as [^n] references with definitions at the end."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "text", Type: command.String, Description: "The footnote's text.", Required: true},
+			{Name: "index", Type: command.Int, Description: "1-based character index within the document body where the footnote reference goes, or use an anchor parameter instead."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, anchorParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				Text       string `json:"text"`
+				Index      int    `json:"index"`
+				TabID      string `json:"tabId"`
+				anchor
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if strings.TrimSpace(params.Text) == "" {
+				return command.TextErrorResult("text must not be empty"), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert footnote: %v", err)), nil
+			}
+			index, err := resolveIndex(tab, docindex.New(tab.Body), params.Index, params.anchor)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert footnote: %v", err)), nil
+			}
+
+			req := google.Request{CreateFootnote: &google.CreateFootnoteRequest{
+				Location: &google.Location{Index: index, TabID: params.TabID},
+			}}
+			resp, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(params.anchor.revision(doc, params.ExpectedRevisionID)))
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert footnote: %v", err)), nil
+			}
+			params.applied(resp)
+
+			// A new footnote holds a space and a newline; its ID is only
+			// known to the document, so find the reference just inserted.
+			var footnote google.Footnote
+			if doc, err = client.Docs.Get(params.DocumentID); err == nil {
+				if tab, err = documentTab(doc, params.TabID); err == nil {
+					footnote = tab.Footnotes[findFootnoteReference(tab.Body.Content, index)]
+				}
+			}
+			if err == nil && len(footnote.Content) == 0 {
+				err = fmt.Errorf("it was not found after inserting it")
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("inserted a footnote at index %d but failed to add its text: %v", index, err)), nil
+			}
+			insert := google.Request{InsertText: &google.InsertTextRequest{
+				Location: google.Location{Index: footnote.Content[0].StartIndex + 1, SegmentID: footnote.FootnoteID, TabID: params.TabID},
+				Text:     strings.TrimSuffix(params.Text, "\n"),
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{insert}, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("inserted a footnote at index %d but failed to add its text: %v", index, err)), nil
+			}
+			return command.TextResult(fmt.Sprintf("Successfully inserted footnote %s at index %d. Content after it moved forward by 1.", footnote.FootnoteID, index)), nil
+		},
+	})
+}
@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/google"
+)
+
+// Limits the Docs API places on inserted images.
+const (
+	maxImageBytes  = 50 << 20
+	maxImagePixels = 25_000_000
+)
+
+// imageMimeTypes are the formats Docs accepts for inline images.
+var imageMimeTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// imageFile is validated image content to upload, with its pixel size.
+type imageFile struct {
+	name          string
+	mimeType      string
+	content       []byte
+	width, height int
+}
+
+// checkImage validates content against the Docs limits, detecting its
+// format from the bytes rather than trusting a file extension.
+func checkImage(name string, content []byte) (imageFile, error) {
+	if len(content) > maxImageBytes {
+		return imageFile{}, fmt.Errorf("image is %d MB; the limit is %d MB", len(content)>>20, maxImageBytes>>20)
+	}
+	mimeType := http.DetectContentType(content)
+	ext, ok := imageMimeTypes[mimeType]
+	if !ok {
+		return imageFile{}, fmt.Errorf("unsupported image format %s; use PNG, JPEG or GIF", mimeType)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return imageFile{}, fmt.Errorf("could not read image: %w", err)
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return imageFile{}, fmt.Errorf("image is %dx%d pixels; the limit is %d megapixels", cfg.Width, cfg.Height, maxImagePixels/1_000_000)
+	}
+	if filepath.Ext(name) == "" {
+		name += ext
+	}
+	return imageFile{name: name, mimeType: mimeType, content: content, width: cfg.Width, height: cfg.Height}, nil
+}
+
+func readLocalImage(path string) (imageFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return imageFile{}, fmt.Errorf("image file not found: %s", path)
+	}
+	if info.IsDir() {
+		return imageFile{}, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxImageBytes {
+		return imageFile{}, fmt.Errorf("image is %d MB; the limit is %d MB", info.Size()>>20, maxImageBytes>>20)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return imageFile{}, err
+	}
+	return checkImage(filepath.Base(path), content)
+}
+
+// decodeDataURI reads a base64 "data:image/png;base64,..." URI.
+func decodeDataURI(uri string) (imageFile, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return imageFile{}, fmt.Errorf("data URI must be base64 encoded, like data:image/png;base64,...")
+	}
+	content, err := base64.StdEncoding.DecodeString(strings.TrimSpace(payload))
+	if err != nil {
+		return imageFile{}, fmt.Errorf("invalid base64 in data URI: %w", err)
+	}
+	return checkImage("inserted-image", content)
+}
+
+// checkImageURL accepts only absolute http and https URLs, which are the
+// only ones Docs can fetch.
+func checkImageURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid image URL %q: must be an http:// or https:// URL or a data URI", s)
+	}
+	return nil
+}
+
+// imageSize returns the object size for the requested width and height in
+// points, deriving a missing one from the image's aspect ratio. When the
+// pixel size is unknown a single dimension is passed on as it is, and Docs
+// scales the other to keep the aspect ratio. It returns nil when neither is
+// given, leaving Docs to pick the size.
+func imageSize(width, height float64, pixelWidth, pixelHeight int) *google.Size {
+	switch {
+	case width > 0 && height <= 0 && pixelWidth > 0:
+		height = width * float64(pixelHeight) / float64(pixelWidth)
+	case height > 0 && width <= 0 && pixelHeight > 0:
+		width = height * float64(pixelWidth) / float64(pixelHeight)
+	}
+	var size google.Size
+	if width > 0 {
+		size.Width = google.Points(width)
+	}
+	if height > 0 {
+		size.Height = google.Points(height)
+	}
+	if size.Width == nil && size.Height == nil {
+		return nil
+	}
+	return &size
+}
+
+// sizeNote describes size for a result message, naming only the
+// dimensions it sets.
+func sizeNote(size *google.Size) string {
+	switch {
+	case size == nil:
+		return ""
+	case size.Width != nil && size.Height != nil:
+		return fmt.Sprintf(" with size %.0fx%.0fpt", size.Width.Magnitude, size.Height.Magnitude)
+	case size.Width != nil:
+		return fmt.Sprintf(" with width %.0fpt", size.Width.Magnitude)
+	case size.Height != nil:
+		return fmt.Sprintf(" with height %.0fpt", size.Height.Magnitude)
+	}
+	return ""
+}
+
+// driveImageURL returns a URL Docs can fetch a shared Drive file from.
+func driveImageURL(file *google.DriveFile) string {
+	if file.WebContentLink != "" {
+		return file.WebContentLink
+	}
+	return "https://drive.google.com/uc?export=download&id=" + url.QueryEscape(file.ID)
+}
+
+// shareForInsert lets anyone with the link read a Drive file while Docs
+// copies it into the document, returning a function that revokes the
+// share again.
+func shareForInsert(client *google.Client, fileID string) (func() error, error) {
+	p, err := client.Drive.CreatePermission(fileID, google.Permission{Type: "anyone", Role: "reader"})
+	if err != nil {
+		return nil, fmt.Errorf("could not share image for insertion: %w", err)
+	}
+	return func() error { return client.Drive.DeletePermission(fileID, p.ID) }, nil
+}
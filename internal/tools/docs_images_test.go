@@ -0,0 +1,34 @@
+package tools
+
+import "testing"
+
+func TestImageSize(t *testing.T) {
+	tests := []struct {
+		width, height           float64
+		pixelWidth, pixelHeight int
+		want                    string
+	}{
+		{0, 0, 800, 600, ""},
+		{400, 300, 0, 0, " with size 400x300pt"},
+		{400, 0, 800, 600, " with size 400x300pt"},
+		{0, 150, 800, 600, " with size 200x150pt"},
+		{400, 0, 0, 0, " with width 400pt"},
+		{0, 150, 0, 0, " with height 150pt"},
+	}
+	for _, tt := range tests {
+		size := imageSize(tt.width, tt.height, tt.pixelWidth, tt.pixelHeight)
+		if got := sizeNote(size); got != tt.want {
+			t.Errorf("imageSize(%v, %v, %d, %d) gives %q, want %q", tt.width, tt.height, tt.pixelWidth, tt.pixelHeight, got, tt.want)
+		}
+	}
+}
+
+func TestImageSizeKeepsLoneDimension(t *testing.T) {
+	size := imageSize(400, 0, 0, 0)
+	if size == nil || size.Width == nil || size.Width.Magnitude != 400 || size.Height != nil {
+		t.Fatalf("imageSize(400, 0, 0, 0) = %+v, want only a 400pt width", size)
+	}
+	if size := imageSize(0, 0, 0, 0); size != nil {
+		t.Errorf("imageSize(0, 0, 0, 0) = %+v, want nil", size)
+	}
+}
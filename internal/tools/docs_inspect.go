@@ -0,0 +1,433 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// textStyleRun is a stretch of text sharing one effective style.
+type textStyleRun struct {
+	StartIndex      int     `json:"startIndex"`
+	EndIndex        int     `json:"endIndex"`
+	Text            string  `json:"text"`
+	NamedStyleType  string  `json:"namedStyleType"`
+	FontFamily      string  `json:"fontFamily,omitempty"`
+	FontSize        float64 `json:"fontSize,omitempty"`
+	Bold            bool    `json:"bold,omitempty"`
+	Italic          bool    `json:"italic,omitempty"`
+	Underline       bool    `json:"underline,omitempty"`
+	Strikethrough   bool    `json:"strikethrough,omitempty"`
+	ForegroundColor string  `json:"foregroundColor,omitempty"`
+	BackgroundColor string  `json:"backgroundColor,omitempty"`
+	Link            string  `json:"link,omitempty"`
+}
+
+// sameStyle reports whether two runs differ only in their position.
+func (r textStyleRun) sameStyle(o textStyleRun) bool {
+	r.StartIndex, r.EndIndex, r.Text = o.StartIndex, o.EndIndex, o.Text
+	return r == o
+}
+
+// apply layers ts over the run's style. Only properties set in ts are
+// taken, so a run that turns bold off inside a bold named style reads as
+// not bold while one that leaves it unset inherits it.
+func (r *textStyleRun) apply(ts *google.TextStyle) {
+	if ts == nil {
+		return
+	}
+	if ts.WeightedFontFamily != nil {
+		r.FontFamily = ts.WeightedFontFamily.FontFamily
+	}
+	if ts.FontSize != nil {
+		r.FontSize = ts.FontSize.Magnitude
+	}
+	for _, f := range []struct {
+		value *bool
+		dst   *bool
+	}{
+		{ts.Bold, &r.Bold},
+		{ts.Italic, &r.Italic},
+		{ts.Underline, &r.Underline},
+		{ts.Strikethrough, &r.Strikethrough},
+	} {
+		if f.value != nil {
+			*f.dst = *f.value
+		}
+	}
+	if c := formatHexColor(ts.ForegroundColor); c != "" {
+		r.ForegroundColor = c
+	}
+	if c := formatHexColor(ts.BackgroundColor); c != "" {
+		r.BackgroundColor = c
+	}
+	if l := ts.Link; l != nil {
+		switch {
+		case l.URL != "":
+			r.Link = l.URL
+		case l.HeadingID != "":
+			r.Link = "#heading=" + l.HeadingID
+		case l.BookmarkID != "":
+			r.Link = "#bookmark=" + l.BookmarkID
+		}
+	}
+}
+
+// paragraphStyleInfo is the effective style of one paragraph.
+type paragraphStyleInfo struct {
+	StartIndex      int     `json:"startIndex"`
+	EndIndex        int     `json:"endIndex"`
+	Text            string  `json:"text"`
+	NamedStyleType  string  `json:"namedStyleType"`
+	HeadingID       string  `json:"headingId,omitempty"`
+	Alignment       string  `json:"alignment"`
+	IndentStart     float64 `json:"indentStart,omitempty"`
+	IndentEnd       float64 `json:"indentEnd,omitempty"`
+	IndentFirstLine float64 `json:"indentFirstLine,omitempty"`
+	SpaceAbove      float64 `json:"spaceAbove,omitempty"`
+	SpaceBelow      float64 `json:"spaceBelow,omitempty"`
+	KeepWithNext    bool    `json:"keepWithNext,omitempty"`
+	ListID          string  `json:"listId,omitempty"`
+	NestingLevel    int     `json:"nestingLevel,omitempty"`
+}
+
+func (p *paragraphStyleInfo) apply(ps *google.ParagraphStyle) {
+	if ps == nil {
+		return
+	}
+	if ps.Alignment != "" {
+		p.Alignment = ps.Alignment
+	}
+	for _, d := range []struct {
+		value *google.Dimension
+		dst   *float64
+	}{
+		{ps.IndentStart, &p.IndentStart},
+		{ps.IndentEnd, &p.IndentEnd},
+		{ps.IndentFirstLine, &p.IndentFirstLine},
+		{ps.SpaceAbove, &p.SpaceAbove},
+		{ps.SpaceBelow, &p.SpaceBelow},
+	} {
+		if d.value != nil {
+			*d.dst = d.value.Magnitude
+		}
+	}
+	if ps.KeepWithNext != nil {
+		p.KeepWithNext = *ps.KeepWithNext
+	}
+}
+
+// namedStyle returns the definition of styleType in tab, if it has one.
+func namedStyle(tab *google.DocumentTab, styleType string) google.NamedStyle {
+	if tab.NamedStyles != nil {
+		for _, s := range tab.NamedStyles.Styles {
+			if s.NamedStyleType == styleType {
+				return s
+			}
+		}
+	}
+	return google.NamedStyle{NamedStyleType: styleType}
+}
+
+// paragraphStyleType returns the named style of p, NORMAL_TEXT when unset.
+func paragraphStyleType(p *google.Paragraph) string {
+	if p.ParagraphStyle != nil && p.ParagraphStyle.NamedStyleType != "" {
+		return p.ParagraphStyle.NamedStyleType
+	}
+	return "NORMAL_TEXT"
+}
+
+// effectiveParagraphStyle returns the style of the paragraph el: its own
+// formatting over its named style over NORMAL_TEXT.
+func effectiveParagraphStyle(tab *google.DocumentTab, el google.ContentElement) paragraphStyleInfo {
+	p := paragraphStyleInfo{
+		StartIndex:     el.StartIndex,
+		EndIndex:       el.EndIndex,
+		Text:           paragraphText(el.Paragraph),
+		NamedStyleType: paragraphStyleType(el.Paragraph),
+		Alignment:      "START",
+	}
+	p.apply(namedStyle(tab, "NORMAL_TEXT").ParagraphStyle)
+	p.apply(namedStyle(tab, p.NamedStyleType).ParagraphStyle)
+	if ps := el.Paragraph.ParagraphStyle; ps != nil {
+		p.HeadingID = ps.HeadingID
+		p.apply(ps)
+	}
+	if b := el.Paragraph.Bullet; b != nil {
+		p.ListID, p.NestingLevel = b.ListID, b.NestingLevel
+	}
+	return p
+}
+
+// walkParagraphs calls fn for every paragraph in content overlapping
+// [start, end), including those inside tables.
+func walkParagraphs(content []google.ContentElement, start, end int, fn func(el google.ContentElement)) {
+	for _, el := range content {
+		if el.EndIndex <= start || el.StartIndex >= end {
+			continue
+		}
+		if el.Paragraph != nil {
+			fn(el)
+		}
+		if el.Table != nil {
+			for _, row := range el.Table.TableRows {
+				for _, cell := range row.TableCells {
+					walkParagraphs(cell.Content, start, end, fn)
+				}
+			}
+		}
+	}
+}
+
+// textStyleRuns returns the effective text style of [start, end): each
+// run's own formatting over its paragraph's named style over NORMAL_TEXT.
+// Neighbouring runs that end up alike are merged.
+func textStyleRuns(tab *google.DocumentTab, text *docindex.Text, start, end int) []textStyleRun {
+	var runs []textStyleRun
+	walkParagraphs(tab.Body.Content, start, end, func(el google.ContentElement) {
+		styleType := paragraphStyleType(el.Paragraph)
+		for _, pe := range el.Paragraph.Elements {
+			if pe.TextRun == nil || pe.EndIndex <= start || pe.StartIndex >= end {
+				continue
+			}
+			r := textStyleRun{
+				StartIndex:     max(pe.StartIndex, start),
+				EndIndex:       min(pe.EndIndex, end),
+				NamedStyleType: styleType,
+			}
+			r.Text = text.Slice(r.StartIndex, r.EndIndex)
+			r.apply(namedStyle(tab, "NORMAL_TEXT").TextStyle)
+			r.apply(namedStyle(tab, styleType).TextStyle)
+			r.apply(pe.TextRun.TextStyle)
+			if n := len(runs); n > 0 && runs[n-1].EndIndex == r.StartIndex && runs[n-1].sameStyle(r) {
+				runs[n-1].EndIndex = r.EndIndex
+				runs[n-1].Text += r.Text
+				continue
+			}
+			runs = append(runs, r)
+		}
+	})
+	return runs
+}
+
+// paragraphQuery holds findParagraphs' criteria; unset fields match
+// anything. Text criteria must hold for every run of visible text in the
+// paragraph.
+type paragraphQuery struct {
+	NamedStyleType  string  `json:"namedStyleType"`
+	FontFamily      string  `json:"fontFamily"`
+	FontSize        float64 `json:"fontSize"`
+	Bold            *bool   `json:"bold"`
+	Italic          *bool   `json:"italic"`
+	ForegroundColor string  `json:"foregroundColor"`
+	Alignment       string  `json:"alignment"`
+	InList          *bool   `json:"inList"`
+	ListID          string  `json:"listId"`
+	TextPattern     string  `json:"textPattern"`
+	pattern         *regexp.Regexp
+}
+
+// matchesParagraph checks the paragraph-level criteria.
+func (q paragraphQuery) matchesParagraph(p paragraphStyleInfo) bool {
+	switch {
+	case q.NamedStyleType != "" && !strings.EqualFold(q.NamedStyleType, p.NamedStyleType):
+		return false
+	case q.Alignment != "" && !strings.EqualFold(q.Alignment, p.Alignment):
+		return false
+	case q.InList != nil && *q.InList != (p.ListID != ""):
+		return false
+	case q.ListID != "" && q.ListID != p.ListID:
+		return false
+	case q.pattern != nil && !q.pattern.MatchString(strings.TrimSuffix(p.Text, "\n")):
+		return false
+	}
+	return true
+}
+
+// matchesRun checks the text criteria against one run.
+func (q paragraphQuery) matchesRun(r textStyleRun) bool {
+	switch {
+	case q.FontFamily != "" && !strings.EqualFold(q.FontFamily, r.FontFamily):
+		return false
+	case q.FontSize > 0 && math.Abs(q.FontSize-r.FontSize) > 0.01:
+		return false
+	case q.Bold != nil && *q.Bold != r.Bold:
+		return false
+	case q.Italic != nil && *q.Italic != r.Italic:
+		return false
+	case q.ForegroundColor != "" && q.ForegroundColor != r.ForegroundColor:
+		return false
+	}
+	return true
+}
+
+func registerDocsInspectCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "getTextStyle",
+		Description: command.Description{Short: "Reads the formatting of text identified by a character range or by searching for a text string: runs of font, size, colors, bold, italic, underline, strikethrough, link and named style, as they appear once the paragraph's named style is taken into account. Use it to copy or check formatting before applyTextStyle."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, paragraphRangeParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				TabID      string `json:"tabId"`
+				paragraphRange
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to get text style: %v", err)), nil
+			}
+			text := docindex.New(tab.Body)
+			start, end, err := params.paragraphRange.resolve(tab.Body, text)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to get text style: %v", err)), nil
+			}
+
+			runs := textStyleRuns(tab, text, start, end)
+			if runs == nil {
+				runs = []textStyleRun{}
+			}
+			return command.JSONResult(runs), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "getParagraphStyle",
+		Description: command.Description{Short: "Reads the paragraph formatting of the paragraphs overlapping a character range or a piece of text: named style, heading ID, alignment, indents, spacing and list membership, as they appear once the named style is taken into account. Use it to copy or check formatting before applyParagraphStyle."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, paragraphRangeParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				TabID      string `json:"tabId"`
+				paragraphRange
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to get paragraph style: %v", err)), nil
+			}
+			start, end, err := params.paragraphRange.resolve(tab.Body, docindex.New(tab.Body))
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to get paragraph style: %v", err)), nil
+			}
+
+			paragraphs := []paragraphStyleInfo{}
+			walkParagraphs(tab.Body.Content, start, end, func(el google.ContentElement) {
+				paragraphs = append(paragraphs, effectiveParagraphStyle(tab, el))
+			})
+			return command.JSONResult(paragraphs), nil
+		},
+	})
+	app.AddCommand(&command.Command{
+		Name:        "findParagraphs",
+		Description: command.Description{Short: "Finds paragraphs by style or text: named style, font, size, bold, italic, text color, alignment, list membership or a regular expression. All given criteria must match, and text criteria must hold for all of a paragraph's text. Returns each paragraph's index range and text, ready for applyTextStyle or applyParagraphStyle."},
+		Params: []command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "namedStyleType", Type: command.String, Description: "Named paragraph style: NORMAL_TEXT, TITLE, SUBTITLE, HEADING_1 through HEADING_6."},
+			{Name: "fontFamily", Type: command.String, Description: "Font family, e.g. \"Arial\"."},
+			{Name: "fontSize", Type: command.Float, Description: "Font size in points."},
+			{Name: "bold", Type: command.Bool, Description: "Whether the text is bold."},
+			{Name: "italic", Type: command.Bool, Description: "Whether the text is italic."},
+			{Name: "foregroundColor", Type: command.String, Description: "Text color in hex format (e.g., \"#FF0000\")."},
+			{Name: "alignment", Type: command.String, Description: "Paragraph alignment: START, END, CENTER, or JUSTIFIED."},
+			{Name: "inList", Type: command.Bool, Description: "Whether the paragraph is a list item."},
+			{Name: "listId", Type: command.String, Description: "Only items of this list."},
+			{Name: "textPattern", Type: command.String, Description: "Regular expression the paragraph's text must match (Go syntax, e.g. \"(?i)^appendix\")."},
+			{Name: "maxResults", Type: command.Int, Description: "Maximum number of paragraphs to return. Defaults to 100."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				MaxResults int    `json:"maxResults"`
+				TabID      string `json:"tabId"`
+				paragraphQuery
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if params.MaxResults <= 0 {
+				params.MaxResults = 100
+			}
+			q := params.paragraphQuery
+			if q.TextPattern != "" {
+				re, err := regexp.Compile(q.TextPattern)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("invalid textPattern: %v", err)), nil
+				}
+				q.pattern = re
+			}
+			if q.ForegroundColor != "" {
+				color, err := parseHexColor(q.ForegroundColor)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("invalid foregroundColor: %v", err)), nil
+				}
+				q.ForegroundColor = formatHexColor(color)
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to find paragraphs: %v", err)), nil
+			}
+			text := docindex.New(tab.Body)
+
+			type match struct {
+				StartIndex     int    `json:"startIndex"`
+				EndIndex       int    `json:"endIndex"`
+				Text           string `json:"text"`
+				NamedStyleType string `json:"namedStyleType"`
+				ListID         string `json:"listId,omitempty"`
+			}
+			matches := []match{}
+			total := 0
+			walkParagraphs(tab.Body.Content, 0, math.MaxInt, func(el google.ContentElement) {
+				p := effectiveParagraphStyle(tab, el)
+				if !q.matchesParagraph(p) {
+					return
+				}
+				for _, r := range textStyleRuns(tab, text, el.StartIndex, el.EndIndex) {
+					if strings.TrimSpace(r.Text) != "" && !q.matchesRun(r) {
+						return
+					}
+				}
+				total++
+				if len(matches) < params.MaxResults {
+					matches = append(matches, match{el.StartIndex, el.EndIndex, strings.TrimSuffix(p.Text, "\n"), p.NamedStyleType, p.ListID})
+				}
+			})
+			return command.JSONResult(map[string]any{"total": total, "paragraphs": matches}), nil
+		},
+	})
+}
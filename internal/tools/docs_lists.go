@@ -0,0 +1,623 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/markdown"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// maxNestingLevel is the deepest list level Docs supports, counting from 0.
+const maxNestingLevel = 8
+
+var bulletPresets = []string{
+	"BULLET_DISC_CIRCLE_SQUARE",
+	"BULLET_DIAMONDX_ARROW3D_SQUARE",
+	"BULLET_CHECKBOX",
+	"BULLET_ARROW_DIAMOND_DISC",
+	"BULLET_STAR_CIRCLE_SQUARE",
+	"BULLET_ARROW3D_CIRCLE_SQUARE",
+	"BULLET_LEFTTRIANGLE_DIAMOND_DISC",
+	"BULLET_DIAMONDX_HOLLOWDIAMOND_SQUARE",
+	"BULLET_DIAMOND_CIRCLE_SQUARE",
+	"NUMBERED_DECIMAL_ALPHA_ROMAN",
+	"NUMBERED_DECIMAL_ALPHA_ROMAN_PARENS",
+	"NUMBERED_DECIMAL_NESTED",
+	"NUMBERED_UPPERALPHA_ALPHA_ROMAN",
+	"NUMBERED_UPPERROMAN_UPPERALPHA_DECIMAL",
+	"NUMBERED_ZERODECIMAL_ALPHA_ROMAN",
+}
+
+// listMarker matches a typed list marker at the start of a paragraph:
+// indentation, then a bullet character, a number or a letter, then an
+// optional task box. Letters and roman numerals only count when indented,
+// so sentences like "A. Smith wrote" are left alone.
+var listMarker = regexp.MustCompile(`^([ \t]*)(?:([-*+•◦▪])|(\d{1,3}[.)])|([a-z][.)]|[ivx]{1,4}[.)]))[ \t]+(\[[ xX]\][ \t]+)?`)
+
+// paragraphRange selects the paragraphs overlapping an index range or a
+// piece of text.
+type paragraphRange struct {
+	StartIndex    int    `json:"startIndex"`
+	EndIndex      int    `json:"endIndex"`
+	TextToFind    string `json:"textToFind"`
+	MatchInstance int    `json:"matchInstance"`
+}
+
+var paragraphRangeParams = []command.Param{
+	{Name: "startIndex", Type: command.Int, Description: "The starting index of the range (inclusive, starts from 1)."},
+	{Name: "endIndex", Type: command.Int, Description: "The ending index of the range (exclusive)."},
+	{Name: "textToFind", Type: command.String, Description: "The exact text to target (alternative to using startIndex/endIndex). May span several paragraphs."},
+	{Name: "matchInstance", Type: command.Int, Description: "Which instance of the text to target (1st, 2nd, etc.). Defaults to 1."},
+}
+
+// listParagraph is a top-level paragraph of the body and its bullet, if
+// any.
+type listParagraph struct {
+	element              int
+	startIndex, endIndex int
+	bullet               *google.Bullet
+}
+
+func (p listParagraph) level() int {
+	if p.bullet == nil {
+		return 0
+	}
+	return p.bullet.NestingLevel
+}
+
+// resolve returns the index range r selects in body.
+func (r paragraphRange) resolve(body *google.DocumentBody, text *docindex.Text) (int, int, error) {
+	start, end := r.StartIndex, r.EndIndex
+	var err error
+	switch {
+	case r.TextToFind != "" && (start != 0 || end != 0):
+		err = fmt.Errorf("provide either startIndex/endIndex or textToFind, not both")
+	case r.TextToFind != "":
+		start, end, err = findText(text, r.TextToFind, 0, max(r.MatchInstance, 1))
+	case start == 0 && end == 0:
+		err = fmt.Errorf("provide startIndex and endIndex, or textToFind")
+	default:
+		err = checkRange(body, text, start, end)
+	}
+	return start, end, err
+}
+
+// selectParagraphs returns the top-level paragraphs overlapping r.
+func selectParagraphs(body *google.DocumentBody, r paragraphRange) ([]listParagraph, error) {
+	start, end, err := r.resolve(body, docindex.New(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var ps []listParagraph
+	for i, el := range body.Content {
+		if el.Paragraph != nil && el.StartIndex < end && el.EndIndex > start {
+			ps = append(ps, listParagraph{element: i, startIndex: el.StartIndex, endIndex: el.EndIndex, bullet: el.Paragraph.Bullet})
+		}
+	}
+	if len(ps) == 0 {
+		return nil, fmt.Errorf("no paragraphs found between indices %d and %d", start, end)
+	}
+	return ps, nil
+}
+
+// paragraphSpan returns the range covering ps, stopping short of the
+// body's final newline, which no request may touch.
+func paragraphSpan(body *google.DocumentBody, ps []listParagraph) (int, int) {
+	return ps[0].startIndex, min(ps[len(ps)-1].endIndex, bodyEndIndex(body))
+}
+
+// rebullet turns ps into a single list with the given preset and nesting
+// levels. createParagraphBullets reads each paragraph's level from its
+// leading tabs and then removes them, so existing bullets are dropped, the
+// tabs inserted bottom-to-top, and the list created over the grown range.
+func rebullet(body *google.DocumentBody, tabID string, ps []listParagraph, levels []int, preset string) []google.Request {
+	start, end := paragraphSpan(body, ps)
+	var requests []google.Request
+	if slices.ContainsFunc(ps, func(p listParagraph) bool { return p.bullet != nil }) {
+		requests = append(requests, google.Request{DeleteParagraphBullets: &google.DeleteParagraphBulletsRequest{
+			Range: google.Range{StartIndex: start, EndIndex: end, TabID: tabID},
+		}})
+	}
+	tabs := 0
+	for i := len(ps) - 1; i >= 0; i-- {
+		if levels[i] > 0 {
+			requests = append(requests, google.Request{InsertText: &google.InsertTextRequest{
+				Location: google.Location{Index: ps[i].startIndex, TabID: tabID},
+				Text:     strings.Repeat("\t", levels[i]),
+			}})
+			tabs += levels[i]
+		}
+	}
+	return append(requests, google.Request{CreateParagraphBullets: &google.CreateParagraphBulletsRequest{
+		Range:        google.Range{StartIndex: start, EndIndex: end + tabs, TabID: tabID},
+		BulletPreset: preset,
+	}})
+}
+
+// wholeList extends ps, which must all belong to one list, to every
+// adjacent paragraph of that list, returning the extended paragraphs and
+// which of them were in ps.
+func wholeList(body *google.DocumentBody, ps []listParagraph) ([]listParagraph, []bool, error) {
+	listID := ""
+	for _, p := range ps {
+		switch {
+		case p.bullet == nil:
+			return nil, nil, fmt.Errorf("paragraph at index %d is not part of a list", p.startIndex)
+		case listID == "":
+			listID = p.bullet.ListID
+		case p.bullet.ListID != listID:
+			return nil, nil, fmt.Errorf("the range spans more than one list")
+		}
+	}
+	inList := func(i int) bool {
+		p := body.Content[i].Paragraph
+		return p != nil && p.Bullet != nil && p.Bullet.ListID == listID
+	}
+	first, last := ps[0].element, ps[len(ps)-1].element
+	for first > 0 && inList(first-1) {
+		first--
+	}
+	for last+1 < len(body.Content) && inList(last+1) {
+		last++
+	}
+
+	var list []listParagraph
+	var selected []bool
+	for i := first; i <= last; i++ {
+		el := body.Content[i]
+		list = append(list, listParagraph{element: i, startIndex: el.StartIndex, endIndex: el.EndIndex, bullet: el.Paragraph.Bullet})
+		selected = append(selected, i >= ps[0].element && i <= ps[len(ps)-1].element)
+	}
+	return list, selected, nil
+}
+
+// typedItem is a plain paragraph that starts with a typed list marker.
+type typedItem struct {
+	listParagraph
+	indent    int
+	markerLen int
+	preset    string
+}
+
+// typedList is a run of adjacent typed items to turn into one list.
+type typedList struct {
+	items  []typedItem
+	levels []int
+}
+
+// detectTypedLists finds runs of plain paragraphs in [start, end) that
+// begin with "- ", "* ", "1. ", "- [ ] " and similar markers. A top-level
+// item of a different kind, or any paragraph without a marker, ends a run.
+// Nesting levels rank the distinct indentation widths within a run, with a
+// tab counting as four spaces.
+func detectTypedLists(body *google.DocumentBody, start, end int) []typedList {
+	var lists []typedList
+	var current []typedItem
+	flush := func() {
+		if len(current) > 0 {
+			lists = append(lists, typedList{items: current})
+			current = nil
+		}
+	}
+	for i, el := range body.Content {
+		if el.EndIndex <= start || el.StartIndex >= end {
+			continue
+		}
+		item, ok := typedListItem(i, el)
+		if !ok {
+			flush()
+			continue
+		}
+		if len(current) > 0 && item.indent <= current[0].indent && item.preset != current[0].preset {
+			flush()
+		}
+		current = append(current, item)
+	}
+	flush()
+
+	for i, list := range lists {
+		var widths []int
+		for _, item := range list.items {
+			widths = append(widths, item.indent)
+		}
+		slices.Sort(widths)
+		widths = slices.Compact(widths)
+		for _, item := range list.items {
+			lists[i].levels = append(lists[i].levels, min(slices.Index(widths, item.indent), maxNestingLevel))
+		}
+	}
+	return lists
+}
+
+func typedListItem(element int, el google.ContentElement) (typedItem, bool) {
+	p := el.Paragraph
+	if p == nil || p.Bullet != nil || len(p.Elements) == 0 || p.Elements[0].TextRun == nil {
+		return typedItem{}, false
+	}
+	if p.ParagraphStyle != nil {
+		if _, ok := headingLevel(p.ParagraphStyle.NamedStyleType); ok {
+			return typedItem{}, false
+		}
+	}
+	text := paragraphText(p)
+	m := listMarker.FindStringSubmatch(text)
+	if m == nil || strings.TrimSpace(text[len(m[0]):]) == "" || (m[4] != "" && m[1] == "") {
+		return typedItem{}, false
+	}
+	preset := markdown.BulletPresetNumbered
+	switch {
+	case m[5] != "":
+		preset = markdown.BulletPresetCheckbox
+	case m[2] != "":
+		preset = markdown.BulletPresetDisc
+	}
+	return typedItem{
+		listParagraph: listParagraph{element: element, startIndex: el.StartIndex, endIndex: el.EndIndex},
+		indent:        len(m[1]) + 3*strings.Count(m[1], "\t"),
+		markerLen:     docindex.UTF16Len(m[0]),
+		preset:        preset,
+	}, true
+}
+
+// typedListRequests strips the typed markers of each list and bullets it,
+// working bottom-to-top so earlier indices stay valid.
+func typedListRequests(body *google.DocumentBody, tabID string, lists []typedList) []google.Request {
+	var requests []google.Request
+	for i := len(lists) - 1; i >= 0; i-- {
+		list := lists[i]
+		start := list.items[0].startIndex
+		end := min(list.items[len(list.items)-1].endIndex, bodyEndIndex(body))
+		shift := 0
+		for j := len(list.items) - 1; j >= 0; j-- {
+			item := list.items[j]
+			requests = append(requests, google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+				Range: google.Range{StartIndex: item.startIndex, EndIndex: item.startIndex + item.markerLen, TabID: tabID},
+			}})
+			if level := list.levels[j]; level > 0 {
+				requests = append(requests, google.Request{InsertText: &google.InsertTextRequest{
+					Location: google.Location{Index: item.startIndex, TabID: tabID},
+					Text:     strings.Repeat("\t", level),
+				}})
+			}
+			shift += list.levels[j] - item.markerLen
+		}
+		requests = append(requests, google.Request{CreateParagraphBullets: &google.CreateParagraphBulletsRequest{
+			Range:        google.Range{StartIndex: start, EndIndex: end + shift, TabID: tabID},
+			BulletPreset: list.items[0].preset,
+		}})
+	}
+	return requests
+}
+
+func registerDocsListCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "createList",
+		Description: command.Description{Short: "Turns the paragraphs in a range, or around a piece of text, into a bulleted, numbered or checkbox list. Paragraphs already in a list keep their nesting level; leading tabs on plain paragraphs set theirs."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "bulletPreset", Type: command.String, Description: "The list style: " + strings.Join(bulletPresets, ", ") + ". Defaults to BULLET_DISC_CIRCLE_SQUARE."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, paragraphRangeParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID   string `json:"documentId"`
+				BulletPreset string `json:"bulletPreset"`
+				TabID        string `json:"tabId"`
+				paragraphRange
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if params.BulletPreset == "" {
+				params.BulletPreset = markdown.BulletPresetDisc
+			}
+			if !slices.Contains(bulletPresets, params.BulletPreset) {
+				return command.TextErrorResult(fmt.Sprintf("invalid bulletPreset %q: must be one of %s", params.BulletPreset, strings.Join(bulletPresets, ", "))), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to create list: %v", err)), nil
+			}
+			if params.TextToFind == "" {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to create list: %v", err)), nil
+				}
+			}
+			ps, err := selectParagraphs(body, params.paragraphRange)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to create list: %v", err)), nil
+			}
+
+			levels := make([]int, len(ps))
+			for i, p := range ps {
+				levels[i] = p.level()
+			}
+			requests := rebullet(body, params.TabID, ps, levels, params.BulletPreset)
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to create list: %v", err)), nil
+			}
+
+			start, end := paragraphSpan(body, ps)
+			return command.TextResult(fmt.Sprintf("Successfully created a %s list of %d paragraphs (range %d-%d).", params.BulletPreset, len(ps), start, end)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "removeList",
+		Description: command.Description{Short: "Removes the bullets or numbers from the paragraphs in a range, or around a piece of text, leaving plain paragraphs."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "keepIndent", Type: command.Bool, Description: "If true, keeps the indentation of nested items, which Docs adds when removing bullets. Defaults to false."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, paragraphRangeParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				KeepIndent bool   `json:"keepIndent"`
+				TabID      string `json:"tabId"`
+				paragraphRange
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to remove list: %v", err)), nil
+			}
+			if params.TextToFind == "" {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to remove list: %v", err)), nil
+				}
+			}
+			ps, err := selectParagraphs(body, params.paragraphRange)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to remove list: %v", err)), nil
+			}
+			count := 0
+			for _, p := range ps {
+				if p.bullet != nil {
+					count++
+				}
+			}
+			if count == 0 {
+				return command.TextResult("No list paragraphs found in the range."), nil
+			}
+
+			start, end := paragraphSpan(body, ps)
+			r := google.Range{StartIndex: start, EndIndex: end, TabID: params.TabID}
+			requests := []google.Request{{DeleteParagraphBullets: &google.DeleteParagraphBulletsRequest{Range: r}}}
+			if !params.KeepIndent {
+				requests = append(requests, google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+					Range:  r,
+					Fields: "indentStart,indentFirstLine",
+				}})
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to remove list: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully removed bullets from %d paragraphs (range %d-%d).", count, start, end)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "changeListNesting",
+		Description: command.Description{Short: "Indents or outdents list items in a range, or around a piece of text, by a number of levels. The rest of the list keeps its levels and the list keeps its style."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "delta", Type: command.Int, Description: "Levels to move the items by: positive indents (e.g. 1), negative outdents (e.g. -1).", Required: true},
+			{Name: "bulletPreset", Type: command.String, Description: "Restyle the list with this preset while re-nesting. Defaults to the list's current style."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, paragraphRangeParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID   string `json:"documentId"`
+				Delta        int    `json:"delta"`
+				BulletPreset string `json:"bulletPreset"`
+				TabID        string `json:"tabId"`
+				paragraphRange
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if params.Delta == 0 {
+				return command.TextErrorResult("delta must not be 0"), nil
+			}
+			if params.BulletPreset != "" && !slices.Contains(bulletPresets, params.BulletPreset) {
+				return command.TextErrorResult(fmt.Sprintf("invalid bulletPreset %q: must be one of %s", params.BulletPreset, strings.Join(bulletPresets, ", "))), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to change list nesting: %v", err)), nil
+			}
+			if params.TextToFind == "" {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to change list nesting: %v", err)), nil
+				}
+			}
+			ps, err := selectParagraphs(tab.Body, params.paragraphRange)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to change list nesting: %v", err)), nil
+			}
+			list, selected, err := wholeList(tab.Body, ps)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to change list nesting: %v", err)), nil
+			}
+
+			levels := make([]int, len(list))
+			moved := 0
+			for i, p := range list {
+				levels[i] = p.level()
+				if selected[i] {
+					levels[i] = min(max(levels[i]+params.Delta, 0), maxNestingLevel)
+					if levels[i] != p.level() {
+						moved++
+					}
+				}
+			}
+			if moved == 0 {
+				limit := "top"
+				if params.Delta > 0 {
+					limit = "deepest"
+				}
+				return command.TextResult(fmt.Sprintf("The items are already at the %s nesting level.", limit)), nil
+			}
+			preset := params.BulletPreset
+			if preset == "" {
+				preset = markdown.BulletPreset(tab.Lists, list[0].bullet)
+			}
+			requests := rebullet(tab.Body, params.TabID, list, levels, preset)
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to change list nesting: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully moved %d list items by %+d levels.", moved, params.Delta)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "convertToChecklist",
+		Description: command.Description{Short: "Turns the paragraphs in a range, or around a piece of text, into a checklist with a checkbox per item. List items keep their nesting level."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, paragraphRangeParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				TabID      string `json:"tabId"`
+				paragraphRange
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to convert to checklist: %v", err)), nil
+			}
+			if params.TextToFind == "" {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to convert to checklist: %v", err)), nil
+				}
+			}
+			ps, err := selectParagraphs(body, params.paragraphRange)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to convert to checklist: %v", err)), nil
+			}
+
+			levels := make([]int, len(ps))
+			for i, p := range ps {
+				levels[i] = p.level()
+			}
+			requests := rebullet(body, params.TabID, ps, levels, markdown.BulletPresetCheckbox)
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to convert to checklist: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully converted %d paragraphs to a checklist.", len(ps))), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "detectAndFormatLists",
+		Description: command.Description{Short: "Finds plain paragraphs typed as lists, such as \"- item\", \"* item\", \"1. item\" or \"- [ ] task\", and converts them into real Docs lists, removing the typed markers. Indentation before the markers becomes nesting."},
+		Params: append([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "startIndex", Type: command.Int, Description: "Only convert paragraphs from this index (inclusive). Defaults to the start of the document."},
+			{Name: "endIndex", Type: command.Int, Description: "Only convert paragraphs before this index (exclusive). Defaults to the end of the document."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, writeControlParams...),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				StartIndex int    `json:"startIndex"`
+				EndIndex   int    `json:"endIndex"`
+				TabID      string `json:"tabId"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to format lists: %v", err)), nil
+			}
+			if params.StartIndex != 0 || params.EndIndex != 0 {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to format lists: %v", err)), nil
+				}
+			}
+			start, end := max(params.StartIndex, 1), params.EndIndex
+			if end == 0 {
+				end = bodyEndIndex(body)
+			}
+			if err := checkRange(body, docindex.New(body), start, end); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to format lists: %v", err)), nil
+			}
+
+			lists := detectTypedLists(body, start, end)
+			if len(lists) == 0 {
+				return command.TextResult("No list-like paragraphs found."), nil
+			}
+			items := 0
+			kinds := map[string]int{}
+			for _, list := range lists {
+				items += len(list.items)
+				kinds[list.items[0].preset]++
+			}
+			requests := typedListRequests(body, params.TabID, lists)
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to format lists: %v", err)), nil
+			}
+
+			var parts []string
+			for _, k := range []struct{ preset, name string }{
+				{markdown.BulletPresetDisc, "bulleted"},
+				{markdown.BulletPresetNumbered, "numbered"},
+				{markdown.BulletPresetCheckbox, "checklist"},
+			} {
+				if n := kinds[k.preset]; n > 0 {
+					parts = append(parts, fmt.Sprintf("%d %s", n, k.name))
+				}
+			}
+			return command.TextResult(fmt.Sprintf("Successfully converted %d paragraphs into %d lists (%s).\nApplied %s.", items, len(lists), strings.Join(parts, ", "), summarizeRequests(requests))), nil
+		},
+	})
+}
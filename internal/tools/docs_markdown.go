@@ -4,63 +4,286 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/markdown"
+	"github.com/amarbel-llc/piers/internal/textdiff"
 	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
 )
 
+// summarizeRequests describes a batch as e.g. "12 requests (7 insertText,
+// 5 updateTextStyle)", counting kinds in first-seen order.
+func summarizeRequests(requests []google.Request) string {
+	var kinds []string
+	counts := map[string]int{}
+	for _, r := range requests {
+		k := r.Kind()
+		if counts[k] == 0 {
+			kinds = append(kinds, k)
+		}
+		counts[k]++
+	}
+	parts := make([]string, len(kinds))
+	for i, k := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", counts[k], k)
+	}
+	return fmt.Sprintf("%d requests (%s)", len(requests), strings.Join(parts, ", "))
+}
+
+// blockUpdate is how updateDocumentFromMarkdown turns a body's blocks into
+// new ones: the requests to send, and a diff hunk for each edit.
+type blockUpdate struct {
+	requests       []google.Request
+	hunks          []string
+	removed, added int
+}
+
+// updateBlocks diffs blocks, read from body, against updated and works
+// out the edits for the blocks that changed.
+func updateBlocks(body *google.DocumentBody, blocks []markdown.Block, updated []string, tabID string) blockUpdate {
+	current := make([]string, len(blocks))
+	for i, b := range blocks {
+		current[i] = b.Markdown
+	}
+	edits := textdiff.Diff(current, updated)
+	u := blockUpdate{hunks: make([]string, len(edits))}
+	end := bodyEndIndex(body)
+	// Edits are applied last to first so each one's indices are still
+	// those of the document as read.
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		u.removed += e.OldEnd - e.OldStart
+		u.added += e.NewEnd - e.NewStart
+
+		var startIndex, endIndex int
+		switch {
+		case e.OldEnd > e.OldStart:
+			startIndex, endIndex = blocks[e.OldStart].StartIndex, blocks[e.OldEnd-1].EndIndex
+		case e.OldStart < len(blocks):
+			startIndex, endIndex = blocks[e.OldStart].StartIndex, blocks[e.OldStart].StartIndex
+		default:
+			startIndex, endIndex = end+1, end+1
+		}
+		// The body's final newline cannot be deleted, so an edit reaching
+		// it writes its last block in front of it.
+		atEnd := endIndex > end
+		endIndex = min(endIndex, end)
+
+		var hunk strings.Builder
+		where := fmt.Sprintf("at index %d", min(startIndex, end))
+		if endIndex > startIndex {
+			where = fmt.Sprintf("indices %d-%d", startIndex, endIndex)
+		}
+		fmt.Fprintf(&hunk, "@@ %s: -%d +%d blocks\n", where, e.OldEnd-e.OldStart, e.NewEnd-e.NewStart)
+		for _, b := range current[e.OldStart:e.OldEnd] {
+			fmt.Fprintf(&hunk, "- %s\n", strings.ReplaceAll(b, "\n", "\n  "))
+		}
+		for _, b := range updated[e.NewStart:e.NewEnd] {
+			fmt.Fprintf(&hunk, "+ %s\n", strings.ReplaceAll(b, "\n", "\n  "))
+		}
+		u.hunks[i] = hunk.String()
+
+		if endIndex > startIndex {
+			u.requests = append(u.requests, google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+				Range: google.Range{StartIndex: startIndex, EndIndex: endIndex, TabID: tabID},
+			}})
+		}
+		if e.NewEnd == e.NewStart {
+			continue
+		}
+		if startIndex > end {
+			// Appending: start a paragraph after the last one.
+			startIndex = end
+			u.requests = append(u.requests, google.Request{InsertText: &google.InsertTextRequest{
+				Location: google.Location{Index: startIndex, TabID: tabID},
+				Text:     "\n",
+			}})
+			startIndex++
+		}
+		u.requests = append(u.requests, markdown.ToRequests(strings.Join(updated[e.NewStart:e.NewEnd], "\n\n"), markdown.Options{
+			StartIndex:  startIndex,
+			TabID:       tabID,
+			ResetStyles: true,
+			EndOfBody:   atEnd,
+		})...)
+	}
+	return u
+}
+
 func registerDocsMarkdownCommands(app *command.App, client *google.Client) {
 	app.AddCommand(&command.Command{
 		Name:        "replaceDocumentWithMarkdown",
-		Description: command.Description{Short: "Replaces the entire document body with content parsed from markdown. Supports headings, bold, italic, strikethrough, links, and bullet/numbered lists. Use readDocument with format='markdown' first to get the current content, edit it, then call this tool to apply changes."},
-		Params: []command.Param{
+		Description: command.Description{Short: "Replaces the entire document body with content parsed from markdown. Supports headings, bold, italic, strikethrough, inline code, links, images, nested bullet/numbered/task lists, tables, code blocks, blockquotes and horizontal rules. Use readDocument with format='markdown' first to get the current content, edit it, then call this tool to apply changes."},
+		Params: append([]command.Param{
 			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
 			{Name: "markdown", Type: command.String, Description: "The markdown content to apply to the document.", Required: true},
 			{Name: "preserveTitle", Type: command.Bool, Description: "If true, preserves the first heading/title and replaces content after it."},
 			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to replace content in. If not specified, replaces content in the first tab."},
-			{Name: "firstHeadingAsTitle", Type: command.Bool, Description: "If true, the first H1 heading in the markdown is styled as a Google Docs TITLE instead of Heading 1."},
-		},
+			{Name: "firstHeadingAsTitle", Type: command.Bool, Description: "If true (default), the first H1 heading in the markdown is styled as a Google Docs TITLE instead of Heading 1. Set to false if the first H1 should remain a Heading 1."},
+		}, writeControlParams...),
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
-				DocumentID string `json:"documentId"`
-				Markdown   string `json:"markdown"`
+				DocumentID          string `json:"documentId"`
+				Markdown            string `json:"markdown"`
+				PreserveTitle       bool   `json:"preserveTitle"`
+				TabID               string `json:"tabId"`
+				FirstHeadingAsTitle *bool  `json:"firstHeadingAsTitle"`
+				writeControl
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
 			}
 
-			if err := client.Docs.BatchUpdate(params.DocumentID, nil); err != nil {
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to replace document with markdown: %v", err)), nil
 			}
 
-			return command.TextResult(fmt.Sprintf("Successfully replaced document content with %d characters of markdown.", len(params.Markdown))), nil
+			startIndex := 1
+			endIndex := bodyEndIndex(body)
+			if params.PreserveTitle {
+				for _, el := range body.Content {
+					if el.Paragraph != nil && el.EndIndex > 0 {
+						startIndex = el.EndIndex
+						break
+					}
+				}
+			}
+
+			// The delete goes in its own batch so the converted requests can
+			// assume an empty document from startIndex on.
+			base := doc.RevisionID
+			if endIndex > startIndex {
+				del := google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+					Range: google.Range{StartIndex: startIndex, EndIndex: endIndex, TabID: params.TabID},
+				}}
+				resp, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{del}, params.control(base))
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to clear document: %v", err)), nil
+				}
+				params.applied(resp)
+				base = params.ExpectedRevisionID
+			}
+
+			requests := markdown.ToRequests(params.Markdown, markdown.Options{
+				StartIndex:          startIndex,
+				TabID:               params.TabID,
+				FirstHeadingAsTitle: params.FirstHeadingAsTitle == nil || *params.FirstHeadingAsTitle,
+			})
+			if len(requests) > 0 {
+				if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(base)); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to replace document with markdown: %v", err)), nil
+				}
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully replaced document content with %d characters of markdown.\nApplied %s.", len(params.Markdown), summarizeRequests(requests))), nil
 		},
 	})
 
 	app.AddCommand(&command.Command{
 		Name:        "appendMarkdown",
-		Description: command.Description{Short: "Appends formatted content to the end of a document using markdown syntax. Supports headings, bold, italic, strikethrough, links, and bullet/numbered lists. Use this instead of appendText when you need formatting."},
-		Params: []command.Param{
+		Description: command.Description{Short: "Appends formatted content to the end of a document using markdown syntax. Supports headings, bold, italic, strikethrough, inline code, links, images, nested bullet/numbered/task lists, tables, code blocks, blockquotes and horizontal rules. Use this instead of appendText when you need formatting."},
+		Params: append([]command.Param{
 			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
 			{Name: "markdown", Type: command.String, Description: "The markdown content to append.", Required: true},
-			{Name: "addNewlineIfNeeded", Type: command.Bool, Description: "Add spacing before appended content if needed."},
+			{Name: "addNewlineIfNeeded", Type: command.Bool, Description: "Add spacing before appended content if needed. Defaults to true."},
 			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to append to. If not specified, appends to the first tab."},
 			{Name: "firstHeadingAsTitle", Type: command.Bool, Description: "If true, the first H1 heading in the markdown is styled as a Google Docs TITLE instead of Heading 1."},
+		}, writeControlParams...),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID          string `json:"documentId"`
+				Markdown            string `json:"markdown"`
+				AddNewlineIfNeeded  *bool  `json:"addNewlineIfNeeded"`
+				TabID               string `json:"tabId"`
+				FirstHeadingAsTitle bool   `json:"firstHeadingAsTitle"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to append markdown: %v", err)), nil
+			}
+
+			startIndex := bodyEndIndex(body)
+			var requests []google.Request
+			if (params.AddNewlineIfNeeded == nil || *params.AddNewlineIfNeeded) && startIndex > 1 {
+				requests = append(requests, google.Request{InsertText: &google.InsertTextRequest{
+					Location: google.Location{Index: startIndex, TabID: params.TabID},
+					Text:     "\n\n",
+				}})
+				startIndex += 2
+			}
+			requests = append(requests, markdown.ToRequests(params.Markdown, markdown.Options{
+				StartIndex:          startIndex,
+				TabID:               params.TabID,
+				FirstHeadingAsTitle: params.FirstHeadingAsTitle,
+			})...)
+
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to append markdown: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully appended %d characters of markdown.\nApplied %s.", len(params.Markdown), summarizeRequests(requests))), nil
 		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "updateDocumentFromMarkdown",
+		Description: command.Description{Short: "Updates a document to match edited markdown while touching only the blocks that changed, so comments, suggestions and formatting elsewhere survive. Read the document with readDocument format='markdown', edit the text, and pass the whole result back. Footnote definitions are ignored."},
+		Params: append([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "markdown", Type: command.String, Description: "The complete new markdown for the document.", Required: true},
+			{Name: "dryRun", Type: command.Bool, Description: "If true, lists the blocks that would change without modifying the document."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to update. If not specified, updates the first tab."},
+		}, writeControlParams...),
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
 				DocumentID string `json:"documentId"`
 				Markdown   string `json:"markdown"`
+				DryRun     bool   `json:"dryRun"`
+				TabID      string `json:"tabId"`
+				writeControl
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
 			}
 
-			if err := client.Docs.BatchUpdate(params.DocumentID, nil); err != nil {
-				return command.TextErrorResult(fmt.Sprintf("failed to append markdown: %v", err)), nil
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to update document from markdown: %v", err)), nil
+			}
+
+			blocks := markdown.Blocks(tab)
+			u := updateBlocks(tab.Body, blocks, markdown.SplitBlocks(params.Markdown), params.TabID)
+			if len(u.hunks) == 0 {
+				return command.TextResult("Document already matches the markdown; nothing to update."), nil
 			}
 
-			return command.TextResult(fmt.Sprintf("Successfully appended %d characters of markdown.", len(params.Markdown))), nil
+			summary := fmt.Sprintf("%d of %d blocks unchanged, %d removed, %d added", len(blocks)-u.removed, len(blocks), u.removed, u.added)
+			if params.DryRun {
+				return command.TextResult(fmt.Sprintf("Dry run: %s.\n\n%s", summary, strings.Join(u.hunks, ""))), nil
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, u.requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to update document from markdown: %v", err)), nil
+			}
+			return command.TextResult(fmt.Sprintf("Successfully updated document: %s.\nApplied %s.", summary, summarizeRequests(u.requests))), nil
 		},
 	})
 }
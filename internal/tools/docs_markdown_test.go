@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/markdown"
+)
+
+// paragraphs builds a body from index 1 with one paragraph per text, each
+// ending in a newline; a text starting "# " becomes a HEADING_1.
+func paragraphs(texts ...string) *google.DocumentBody {
+	var content []google.ContentElement
+	index := 1
+	for _, text := range texts {
+		p := &google.Paragraph{}
+		if len(text) > 2 && text[:2] == "# " {
+			text = text[2:]
+			p.ParagraphStyle = &google.ParagraphStyle{NamedStyleType: "HEADING_1"}
+		}
+		end := index + len(text) + 1
+		p.Elements = []google.ParagraphElement{{StartIndex: index, EndIndex: end, TextRun: &google.TextRun{Content: text + "\n"}}}
+		content = append(content, google.ContentElement{StartIndex: index, EndIndex: end, Paragraph: p})
+		index = end
+	}
+	return &google.DocumentBody{Content: content}
+}
+
+func TestUpdateBlocksReplacesLastBlockWithoutTrailingParagraph(t *testing.T) {
+	tests := []struct {
+		name      string
+		updated   []string
+		want      []string
+		wantReset [2]int
+	}{
+		// "Risks" is 7-13; its newline at 12 stays and ends "Done.".
+		{"changed", []string{"Intro", "Done."}, []string{"del 7-12", `ins 7 "Done."`}, [2]int{7, 13}},
+		// The new paragraph after "Risks" takes the body's final newline.
+		{"appended", []string{"Intro", "# Risks", "Done."}, []string{`ins 12 "\n"`, `ins 13 "Done."`}, [2]int{13, 19}},
+	}
+	for _, tt := range tests {
+		body := paragraphs("Intro", "# Risks")
+		blocks := markdown.Blocks(&google.DocumentTab{Body: body})
+		u := updateBlocks(body, blocks, tt.updated, "")
+
+		var edits []string
+		var reset *google.UpdateParagraphStyleRequest
+		for _, d := range describe(u.requests) {
+			if d[:5] != "style" {
+				edits = append(edits, d)
+			}
+		}
+		for _, r := range u.requests {
+			if p := r.UpdateParagraphStyle; p != nil && p.ParagraphStyle.NamedStyleType == "NORMAL_TEXT" {
+				reset = p
+			}
+		}
+		if !reflect.DeepEqual(edits, tt.want) {
+			t.Errorf("%s: edits %q, want %q", tt.name, edits, tt.want)
+		}
+		// The reset reaches the body's final newline, so the heading style
+		// that newline had does not linger.
+		if reset == nil || [2]int{reset.Range.StartIndex, reset.Range.EndIndex} != tt.wantReset {
+			t.Errorf("%s: NORMAL_TEXT reset %+v, want over %v", tt.name, reset, tt.wantReset)
+		}
+	}
+}
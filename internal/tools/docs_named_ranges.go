@@ -0,0 +1,396 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// maxNamedRangeName is the longest name the Docs API accepts, in UTF-16
+// code units.
+const maxNamedRangeName = 256
+
+// namedRange is a named range in a tab with the span [startIndex, endIndex)
+// covering all of its ranges.
+type namedRange struct {
+	id, name             string
+	ranges               []google.Range
+	startIndex, endIndex int
+}
+
+// namedRanges lists the named ranges of a tab in document order.
+func namedRanges(tab *google.DocumentTab) []namedRange {
+	var nrs []namedRange
+	for _, group := range tab.NamedRanges {
+		for _, r := range group.NamedRanges {
+			if len(r.Ranges) == 0 {
+				continue
+			}
+			nr := namedRange{id: r.NamedRangeID, name: r.Name, ranges: r.Ranges, startIndex: r.Ranges[0].StartIndex, endIndex: r.Ranges[0].EndIndex}
+			for _, rng := range r.Ranges[1:] {
+				nr.startIndex = min(nr.startIndex, rng.StartIndex)
+				nr.endIndex = max(nr.endIndex, rng.EndIndex)
+			}
+			nrs = append(nrs, nr)
+		}
+	}
+	sort.Slice(nrs, func(i, j int) bool {
+		if nrs[i].startIndex != nrs[j].startIndex {
+			return nrs[i].startIndex < nrs[j].startIndex
+		}
+		return nrs[i].id < nrs[j].id
+	})
+	return nrs
+}
+
+// findNamedRange looks a named range up by ID, or by name when the name is
+// unique in the tab.
+func findNamedRange(tab *google.DocumentTab, nameOrID string) (namedRange, error) {
+	var byName []namedRange
+	for _, nr := range namedRanges(tab) {
+		if nr.id == nameOrID {
+			return nr, nil
+		}
+		if nr.name == nameOrID {
+			byName = append(byName, nr)
+		}
+	}
+	switch len(byName) {
+	case 0:
+		return namedRange{}, fmt.Errorf("named range %q not found", nameOrID)
+	case 1:
+		return byName[0], nil
+	}
+	return namedRange{}, fmt.Errorf("%d named ranges are called %q; use a namedRangeId from listNamedRanges", len(byName), nameOrID)
+}
+
+// bodySpans returns the [start, end) spans covered by ranges in index
+// order, merging any that overlap or touch. The Docs API does not return a
+// named range's ranges sorted, and they may lie in a header, footer or
+// footnote, whose indices are not the body's; those are refused.
+func bodySpans(ranges []google.Range) ([][2]int, error) {
+	var spans [][2]int
+	for _, rng := range ranges {
+		if rng.SegmentID != "" {
+			return nil, fmt.Errorf("part of the range is outside the document body (segment %s)", rng.SegmentID)
+		}
+		spans = append(spans, [2]int{rng.StartIndex, rng.EndIndex})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	merged := spans[:0]
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], s[1])
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged, nil
+}
+
+// namedRangeText returns the text covered by a named range, joining its
+// ranges with newlines.
+func namedRangeText(text *docindex.Text, nr namedRange) string {
+	parts := make([]string, len(nr.ranges))
+	for i, rng := range nr.ranges {
+		parts[i] = text.Slice(rng.StartIndex, rng.EndIndex)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func registerDocsNamedRangeCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "createNamedRange",
+		Description: command.Description{Short: "Names a region of a document so it can be found again after edits. Named ranges move with their content; address them with readNamedRange, replaceNamedRangeContent, or the afterNamedRange/beforeNamedRange anchors of insertText and deleteRange. Cover the region by index range, by exact text, or by the section under a heading."},
+		Params: append([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "name", Type: command.String, Description: "Name for the range, e.g. \"exec-summary\". Names need not be unique, but unique names can be used in place of IDs.", Required: true},
+			{Name: "startIndex", Type: command.Int, Description: "1-based character index within the document body. The start of the range (inclusive)."},
+			{Name: "endIndex", Type: command.Int, Description: "1-based character index within the document body. The end of the range (exclusive)."},
+			{Name: "text", Type: command.String, Description: "Cover this exact text (alternative to the indices)."},
+			{Name: "section", Type: command.String, Description: "Cover the content under the heading with this text, up to the next heading of the same or higher level (alternative to the indices)."},
+			{Name: "matchInstance", Type: command.Int, Description: "Which instance of text or the heading to target (1st, 2nd, etc.). Defaults to 1."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, writeControlParams...),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID    string `json:"documentId"`
+				Name          string `json:"name"`
+				StartIndex    int    `json:"startIndex"`
+				EndIndex      int    `json:"endIndex"`
+				Text          string `json:"text"`
+				Section       string `json:"section"`
+				MatchInstance int    `json:"matchInstance"`
+				TabID         string `json:"tabId"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if n := docindex.UTF16Len(params.Name); n == 0 || n > maxNamedRangeName {
+				return command.TextErrorResult(fmt.Sprintf("name must be 1-%d characters long", maxNamedRangeName)), nil
+			}
+			given := 0
+			for _, set := range []bool{params.StartIndex != 0 || params.EndIndex != 0, params.Text != "", params.Section != ""} {
+				if set {
+					given++
+				}
+			}
+			if given != 1 {
+				return command.TextErrorResult("provide exactly one of startIndex/endIndex, text or section"), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to create named range: %v", err)), nil
+			}
+			text := docindex.New(body)
+			start, end := params.StartIndex, params.EndIndex
+			switch {
+			case params.Text != "":
+				start, end, err = findText(text, params.Text, 0, max(params.MatchInstance, 1))
+			case params.Section != "":
+				var h heading
+				if h, err = findHeading(body, params.Section, max(params.MatchInstance, 1)); err == nil {
+					start, end = h.endIndex, sectionEndIndex(body, h)
+				}
+			}
+			if err == nil {
+				err = checkRange(body, text, start, end)
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to create named range: %v", err)), nil
+			}
+
+			req := google.Request{CreateNamedRange: &google.CreateNamedRangeRequest{
+				Name:  params.Name,
+				Range: google.Range{StartIndex: start, EndIndex: end, TabID: params.TabID},
+			}}
+			base := doc.RevisionID
+			if params.Text == "" && params.Section == "" {
+				base = params.ExpectedRevisionID
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(base)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to create named range: %v", err)), nil
+			}
+
+			// The ID is only known to the document, so look the new range up.
+			created := fmt.Sprintf("Successfully created named range %q over indices %d-%d", params.Name, start, end)
+			if doc, err := client.Docs.Get(params.DocumentID); err == nil {
+				if tab, err := documentTab(doc, params.TabID); err == nil {
+					for _, nr := range namedRanges(tab) {
+						if nr.name == params.Name && nr.startIndex == start && nr.endIndex == end {
+							return command.TextResult(fmt.Sprintf("%s (ID: %s).", created, nr.id)), nil
+						}
+					}
+				}
+			}
+			return command.TextResult(created + "."), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "listNamedRanges",
+		Description: command.Description{Short: "Lists the named ranges of a document with their IDs, current indices and a preview of their text."},
+		Params: []command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				TabID      string `json:"tabId"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to list named ranges: %v", err)), nil
+			}
+
+			text := docindex.New(tab.Body)
+			type rangeInfo struct {
+				StartIndex int `json:"startIndex"`
+				EndIndex   int `json:"endIndex"`
+			}
+			type namedRangeInfo struct {
+				Name         string      `json:"name"`
+				NamedRangeID string      `json:"namedRangeId"`
+				Ranges       []rangeInfo `json:"ranges"`
+				Text         string      `json:"text"`
+			}
+			infos := []namedRangeInfo{}
+			for _, nr := range namedRanges(tab) {
+				info := namedRangeInfo{Name: nr.name, NamedRangeID: nr.id, Text: namedRangeText(text, nr)}
+				for _, rng := range nr.ranges {
+					info.Ranges = append(info.Ranges, rangeInfo{rng.StartIndex, rng.EndIndex})
+				}
+				if docindex.RuneCount(info.Text) > 80 {
+					info.Text = docindex.TruncateRunes(info.Text, 80) + "..."
+				}
+				infos = append(infos, info)
+			}
+			return command.JSONResult(map[string]any{"namedRanges": infos}), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "readNamedRange",
+		Description: command.Description{Short: "Reads the text covered by a named range, with its current indices."},
+		Params: []command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "name", Type: command.String, Description: "Name or ID of the named range.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				Name       string `json:"name"`
+				TabID      string `json:"tabId"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read named range: %v", err)), nil
+			}
+			nr, err := findNamedRange(tab, params.Name)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read named range: %v", err)), nil
+			}
+			return command.TextResult(fmt.Sprintf("Named range %q (ID: %s, indices %d-%d):\n---\n%s", nr.name, nr.id, nr.startIndex, nr.endIndex, namedRangeText(docindex.New(tab.Body), nr))), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "replaceNamedRangeContent",
+		Description: command.Description{Short: "Replaces the text covered by a named range. The range keeps its name and ID and covers the new text afterwards."},
+		Params: append([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "name", Type: command.String, Description: "Name or ID of the named range.", Required: true},
+			{Name: "text", Type: command.String, Description: "The new text for the range.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, writeControlParams...),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				Name       string `json:"name"`
+				Text       string `json:"text"`
+				TabID      string `json:"tabId"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to replace named range content: %v", err)), nil
+			}
+			nr, err := findNamedRange(tab, params.Name)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to replace named range content: %v", err)), nil
+			}
+
+			req := &google.ReplaceNamedRangeContentRequest{NamedRangeID: nr.id, Text: params.Text}
+			if params.TabID != "" {
+				req.TabsCriteria = &google.TabsCriteria{TabIDs: []string{params.TabID}}
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{{ReplaceNamedRangeContent: req}}, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to replace named range content: %v", err)), nil
+			}
+			return command.TextResult(fmt.Sprintf("Successfully replaced the content of named range %q (indices %d-%d) with %d characters.", nr.name, nr.startIndex, nr.endIndex, docindex.UTF16Len(params.Text))), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "deleteNamedRange",
+		Description: command.Description{Short: "Deletes a named range. The text it covered is kept unless deleteContent is set."},
+		Params: append([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "name", Type: command.String, Description: "Name or ID of the named range.", Required: true},
+			{Name: "deleteContent", Type: command.Bool, Description: "Also delete the text the range covers."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, writeControlParams...),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID    string `json:"documentId"`
+				Name          string `json:"name"`
+				DeleteContent bool   `json:"deleteContent"`
+				TabID         string `json:"tabId"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete named range: %v", err)), nil
+			}
+			nr, err := findNamedRange(tab, params.Name)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete named range: %v", err)), nil
+			}
+
+			del := &google.DeleteNamedRangeRequest{NamedRangeID: nr.id}
+			if params.TabID != "" {
+				del.TabsCriteria = &google.TabsCriteria{TabIDs: []string{params.TabID}}
+			}
+			requests := []google.Request{{DeleteNamedRange: del}}
+			if params.DeleteContent {
+				spans, err := bodySpans(nr.ranges)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to delete named range: %v", err)), nil
+				}
+				// Delete the spans last to first so earlier ones keep their
+				// indices; the body's final newline cannot be deleted.
+				for i := len(spans) - 1; i >= 0; i-- {
+					end := min(spans[i][1], bodyEndIndex(tab.Body))
+					if end > spans[i][0] {
+						requests = append(requests, google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+							Range: google.Range{StartIndex: spans[i][0], EndIndex: end, TabID: params.TabID},
+						}})
+					}
+				}
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete named range: %v", err)), nil
+			}
+			if params.DeleteContent {
+				return command.TextResult(fmt.Sprintf("Successfully deleted named range %q and its content (indices %d-%d).", nr.name, nr.startIndex, nr.endIndex)), nil
+			}
+			return command.TextResult(fmt.Sprintf("Successfully deleted named range %q; its text was kept.", nr.name)), nil
+		},
+	})
+}
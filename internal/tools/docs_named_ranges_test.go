@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amarbel-llc/piers/internal/google"
+)
+
+func TestBodySpans(t *testing.T) {
+	ranges := []google.Range{
+		{StartIndex: 40, EndIndex: 50},
+		{StartIndex: 5, EndIndex: 10},
+		{StartIndex: 20, EndIndex: 30},
+		{StartIndex: 25, EndIndex: 35},
+		{StartIndex: 10, EndIndex: 12},
+	}
+	got, err := bodySpans(ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][2]int{{5, 12}, {20, 35}, {40, 50}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bodySpans = %v, want %v", got, want)
+	}
+	if ranges[0].StartIndex != 40 {
+		t.Errorf("bodySpans reordered its argument: %v", ranges)
+	}
+}
+
+func TestBodySpansRefusesOtherSegments(t *testing.T) {
+	if _, err := bodySpans([]google.Range{{StartIndex: 1, EndIndex: 5}, {StartIndex: 1, EndIndex: 3, SegmentID: "kix.header"}}); err == nil {
+		t.Error("bodySpans accepted a range in a header")
+	}
+}
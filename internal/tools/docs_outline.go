@@ -0,0 +1,345 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+const (
+	defaultTOCLevels = 3
+	defaultTOCTitle  = "Table of Contents"
+	// tocIndent is the extra indentation, in points, of each TOC level.
+	tocIndent = 18
+)
+
+// outlineEntry is a heading in the outline tree. Headings nest under the
+// closest preceding heading of a higher level.
+type outlineEntry struct {
+	Level      int             `json:"level"`
+	Text       string          `json:"text"`
+	HeadingID  string          `json:"headingId,omitempty"`
+	StartIndex int             `json:"startIndex"`
+	EndIndex   int             `json:"endIndex"`
+	TabID      string          `json:"tabId,omitempty"`
+	Children   []*outlineEntry `json:"children,omitempty"`
+}
+
+// outlineTree nests hs by level, dropping headings deeper than maxLevel.
+func outlineTree(hs []heading, tabID string, maxLevel int) []*outlineEntry {
+	var roots []*outlineEntry
+	var stack []*outlineEntry
+	for _, h := range hs {
+		if h.level > maxLevel {
+			continue
+		}
+		e := &outlineEntry{Level: h.level, Text: h.text, HeadingID: h.id, StartIndex: h.startIndex, EndIndex: h.endIndex, TabID: tabID}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, e)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, e)
+		}
+		stack = append(stack, e)
+	}
+	return roots
+}
+
+// tocRangeName names the named range that marks a generated table of
+// contents, recording its depth so a refresh can rebuild it the same way.
+func tocRangeName(maxLevel int) string {
+	return fmt.Sprintf("table-of-contents (levels 1-%d)", maxLevel)
+}
+
+// findTOC returns the generated table of contents in tab and its depth.
+func findTOC(tab *google.DocumentTab) (namedRange, int, bool) {
+	for _, nr := range namedRanges(tab) {
+		var maxLevel int
+		if _, err := fmt.Sscanf(nr.name, "table-of-contents (levels 1-%d)", &maxLevel); err == nil {
+			return nr, maxLevel, true
+		}
+	}
+	return namedRange{}, 0, false
+}
+
+// tocRequests builds a table of contents at index and returns its number
+// of entries: an optional bold title, then one linked paragraph per
+// heading up to maxLevel, indented by level, all covered by a named range.
+// Headings inside skip, the range of an existing table of contents, are
+// left out. The block is forced to plain normal text so it does not take
+// on the style of the paragraph it was inserted into.
+func tocRequests(body *google.DocumentBody, tabID string, index int, title string, maxLevel int, skip namedRange) ([]google.Request, int) {
+	var at *google.Paragraph
+	splits := true
+	for _, el := range body.Content {
+		if el.Paragraph != nil && el.StartIndex <= index && index < el.EndIndex {
+			at, splits = el.Paragraph, el.StartIndex != index
+		}
+	}
+
+	var sb strings.Builder
+	pos := index
+	add := func(s string) (int, int) {
+		start := pos
+		sb.WriteString(s)
+		pos += docindex.UTF16Len(s)
+		return start, pos - 1
+	}
+	if splits {
+		add("\n")
+	}
+	blockStart := pos
+
+	var formats []google.Request
+	textRange := func(start, end int) google.Range {
+		return google.Range{StartIndex: start, EndIndex: end, TabID: tabID}
+	}
+	if title != "" {
+		start, end := add(title + "\n")
+		formats = append(formats, google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+			Range: textRange(start, end), TextStyle: google.TextStyle{Bold: google.Bool(true)}, Fields: "bold",
+		}})
+	}
+	entries := 0
+	for _, h := range headings(body) {
+		if h.level < 1 || h.level > maxLevel || h.text == "" || (skip.id != "" && h.startIndex >= skip.startIndex && h.startIndex < skip.endIndex) {
+			continue
+		}
+		start, end := add(h.text + "\n")
+		entries++
+		if h.id != "" {
+			formats = append(formats, google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+				Range: textRange(start, end), TextStyle: google.TextStyle{Link: &google.Link{HeadingID: h.id}}, Fields: "link",
+			}})
+		}
+		if h.level > 1 {
+			formats = append(formats, google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+				Range: textRange(start, end), ParagraphStyle: google.ParagraphStyle{IndentStart: google.Points(float64(tocIndent * (h.level - 1)))}, Fields: "indentStart",
+			}})
+		}
+	}
+	block := textRange(blockStart, pos)
+
+	requests := []google.Request{
+		{InsertText: &google.InsertTextRequest{Location: google.Location{Index: index, TabID: tabID}, Text: sb.String()}},
+	}
+	if at != nil && at.Bullet != nil {
+		requests = append(requests, google.Request{DeleteParagraphBullets: &google.DeleteParagraphBulletsRequest{Range: block}})
+	}
+	requests = append(requests,
+		google.Request{UpdateParagraphStyle: &google.UpdateParagraphStyleRequest{
+			Range: block, ParagraphStyle: google.ParagraphStyle{NamedStyleType: "NORMAL_TEXT"}, Fields: "namedStyleType,indentStart,indentFirstLine,alignment",
+		}},
+		google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+			Range: block, Fields: "bold,italic,underline,link",
+		}},
+	)
+	requests = append(requests, formats...)
+	return append(requests, google.Request{CreateNamedRange: &google.CreateNamedRangeRequest{Name: tocRangeName(maxLevel), Range: block}}), entries
+}
+
+func registerDocsOutlineCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "getDocumentOutline",
+		Description: command.Description{Short: "Returns the document's headings as a tree, with each heading's level (0 for title and subtitle, 1-6 for Heading 1-6), text, heading ID, start/end index and tab. Heading IDs can be used as link targets and with readSection."},
+		Params: []command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "maxLevel", Type: command.Int, Description: "Deepest heading level to include (1-6). Defaults to 6."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, returns the outline of every tab."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				MaxLevel   int    `json:"maxLevel"`
+				TabID      string `json:"tabId"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if params.MaxLevel == 0 {
+				params.MaxLevel = 6
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+
+			var outline []*outlineEntry
+			if params.TabID != "" {
+				tab, err := documentTab(doc, params.TabID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to get outline: %v", err)), nil
+				}
+				outline = outlineTree(headings(tab.Body), params.TabID, params.MaxLevel)
+			} else if len(doc.Tabs) == 0 {
+				tab, err := documentTab(doc, "")
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to get outline: %v", err)), nil
+				}
+				outline = outlineTree(headings(tab.Body), "", params.MaxLevel)
+			} else {
+				var walk func([]google.Tab)
+				walk = func(ts []google.Tab) {
+					for _, t := range ts {
+						if dt := t.DocumentTab; dt != nil && dt.Body != nil {
+							outline = append(outline, outlineTree(headings(dt.Body), t.TabProperties.TabID, params.MaxLevel)...)
+						}
+						walk(t.ChildTabs)
+					}
+				}
+				walk(doc.Tabs)
+			}
+			if outline == nil {
+				outline = []*outlineEntry{}
+			}
+
+			return command.JSONResult(outline), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "insertTableOfContents",
+		Description: command.Description{Short: "Inserts a table of contents listing the document's headings as links to them, indented by level, at a character index or next to existing text, a heading or a named range. The table is marked with a named range so refreshTableOfContents can rebuild it after headings change."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "maxLevel", Type: command.Int, Description: fmt.Sprintf("Deepest heading level to list (1-6). Defaults to %d.", defaultTOCLevels)},
+			{Name: "title", Type: command.String, Description: fmt.Sprintf("Bold title line above the entries. Defaults to %q; pass an empty string for none.", defaultTOCTitle)},
+			{Name: "index", Type: command.Int, Description: "1-based character index within the document body, or use an anchor parameter instead. Defaults to the start of the document."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to insert into. If not specified, inserts into the first tab."},
+		}, anchorParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string  `json:"documentId"`
+				MaxLevel   int     `json:"maxLevel"`
+				Title      *string `json:"title"`
+				Index      int     `json:"index"`
+				TabID      string  `json:"tabId"`
+				anchor
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if params.MaxLevel == 0 {
+				params.MaxLevel = defaultTOCLevels
+			}
+			if params.MaxLevel < 1 || params.MaxLevel > 6 {
+				return command.TextErrorResult("maxLevel must be between 1 and 6"), nil
+			}
+			title := defaultTOCTitle
+			if params.Title != nil {
+				title = strings.TrimSpace(*params.Title)
+			}
+			if params.Index == 0 && len(params.anchor.names()) == 0 {
+				params.Index = 1
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table of contents: %v", err)), nil
+			}
+			if toc, _, ok := findTOC(tab); ok {
+				return command.TextErrorResult(fmt.Sprintf("document already has a table of contents at indices %d-%d; use refreshTableOfContents to rebuild it", toc.startIndex, toc.endIndex)), nil
+			}
+			index, err := resolveIndex(tab, docindex.New(tab.Body), params.Index, params.anchor)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table of contents: %v", err)), nil
+			}
+
+			requests, entries := tocRequests(tab.Body, params.TabID, index, title, params.MaxLevel, namedRange{})
+			if entries == 0 {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table of contents: the document has no headings of level 1-%d", params.MaxLevel)), nil
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(params.anchor.revision(doc, params.ExpectedRevisionID))); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table of contents: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully inserted a table of contents with %d entries at index %d.", entries, index)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "refreshTableOfContents",
+		Description: command.Description{Short: "Rebuilds a table of contents created by insertTableOfContents so it matches the document's current headings, keeping its position, title and depth."},
+		Params: append([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "maxLevel", Type: command.Int, Description: "Deepest heading level to list (1-6). Defaults to the depth the table was created with."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, writeControlParams...),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				MaxLevel   int    `json:"maxLevel"`
+				TabID      string `json:"tabId"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if params.MaxLevel < 0 || params.MaxLevel > 6 {
+				return command.TextErrorResult("maxLevel must be between 1 and 6"), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to refresh table of contents: %v", err)), nil
+			}
+			toc, maxLevel, ok := findTOC(tab)
+			if !ok {
+				return command.TextErrorResult("failed to refresh table of contents: none found; use insertTableOfContents to create one"), nil
+			}
+			if params.MaxLevel != 0 {
+				maxLevel = params.MaxLevel
+			}
+
+			// The title is the first paragraph of the range when it is not
+			// a link to a heading.
+			title := ""
+			for _, el := range tab.Body.Content {
+				if el.Paragraph == nil || el.StartIndex != toc.startIndex {
+					continue
+				}
+				linked := false
+				for _, pe := range el.Paragraph.Elements {
+					if pe.TextRun != nil && pe.TextRun.TextStyle != nil && pe.TextRun.TextStyle.Link != nil && pe.TextRun.TextStyle.Link.HeadingID != "" {
+						linked = true
+					}
+				}
+				if !linked {
+					title = strings.TrimSpace(paragraphText(el.Paragraph))
+				}
+			}
+
+			end := min(toc.endIndex, bodyEndIndex(tab.Body))
+			requests := []google.Request{
+				{DeleteNamedRange: &google.DeleteNamedRangeRequest{NamedRangeID: toc.id}},
+				{DeleteContentRange: &google.DeleteContentRangeRequest{Range: google.Range{StartIndex: toc.startIndex, EndIndex: end, TabID: params.TabID}}},
+			}
+			rebuilt, entries := tocRequests(tab.Body, params.TabID, toc.startIndex, title, maxLevel, toc)
+			requests = append(requests, rebuilt...)
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to refresh table of contents: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully rebuilt the table of contents at index %d with %d entries.", toc.startIndex, entries)), nil
+		},
+	})
+}
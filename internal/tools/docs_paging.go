@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/markdown"
+)
+
+// charsPerToken is the rough size of a token used by chunkBy='tokens'.
+const charsPerToken = 4
+
+// bodyUnit is a top-level body element, the smallest piece a paged read
+// starts or stops at.
+type bodyUnit struct {
+	element    int
+	start, end int
+	heading    string
+	size       int
+	blank      bool
+}
+
+// docChunk is a run of whole body elements returned by a chunked read.
+type docChunk struct {
+	StartIndex int    `json:"startIndex"`
+	EndIndex   int    `json:"endIndex"`
+	Heading    string `json:"heading,omitempty"`
+	Characters int    `json:"characters"`
+	Text       string `json:"text"`
+}
+
+// bodyReader renders ranges of a tab's body elements as text or markdown.
+type bodyReader struct {
+	tab    *google.DocumentTab
+	text   *docindex.Text
+	format string
+}
+
+func (r bodyReader) render(from, to int) string {
+	if r.format == "markdown" {
+		return markdown.FromDocsElements(r.tab, from, to)
+	}
+	content := r.tab.Body.Content
+	return r.text.Slice(content[from].StartIndex, content[to-1].EndIndex)
+}
+
+// units lists the body elements from the one holding cursor onwards, each
+// sized as it renders on its own.
+func (r bodyReader) units(cursor int) []bodyUnit {
+	headingText := map[int]string{}
+	for _, h := range headings(r.tab.Body) {
+		headingText[h.element] = h.text
+	}
+	var us []bodyUnit
+	for i, el := range r.tab.Body.Content {
+		if el.SectionBreak != nil || el.EndIndex <= cursor {
+			continue
+		}
+		text := r.render(i, i+1)
+		us = append(us, bodyUnit{
+			element: i,
+			start:   el.StartIndex,
+			end:     el.EndIndex,
+			heading: headingText[i],
+			size:    docindex.RuneCount(text),
+			blank:   strings.TrimSpace(text) == "",
+		})
+	}
+	return us
+}
+
+// groupUnits splits units into chunks: one per paragraph or table, one per
+// heading and the content under it, or runs of about chunkSize tokens.
+// Blank paragraphs are left out of paragraph chunks.
+func groupUnits(us []bodyUnit, chunkBy string, chunkSize int) [][]bodyUnit {
+	var groups [][]bodyUnit
+	size := 0
+	for _, u := range us {
+		n := len(groups)
+		switch chunkBy {
+		case "paragraph":
+			if u.blank {
+				continue
+			}
+			groups = append(groups, []bodyUnit{u})
+			continue
+		case "heading":
+			if n == 0 || u.heading != "" {
+				groups = append(groups, nil)
+			}
+		case "tokens":
+			if n == 0 || (size+u.size)/charsPerToken > chunkSize && size > 0 {
+				groups, size = append(groups, nil), 0
+			}
+			size += u.size
+		}
+		n = len(groups)
+		groups[n-1] = append(groups[n-1], u)
+	}
+	return groups
+}
+
+// chunk renders a group of units.
+func (r bodyReader) chunk(g []bodyUnit) docChunk {
+	text := r.render(g[0].element, g[len(g)-1].element+1)
+	return docChunk{
+		StartIndex: g[0].start,
+		EndIndex:   g[len(g)-1].end,
+		Heading:    g[0].heading,
+		Characters: docindex.RuneCount(text),
+		Text:       text,
+	}
+}
+
+// page takes groups from the front of groups until their size reaches
+// maxLength, always taking at least one. A maxLength of 0 takes them all.
+func page(groups [][]bodyUnit, maxLength int) int {
+	n, total := 0, 0
+	for _, g := range groups {
+		size := 0
+		for _, u := range g {
+			size += u.size
+		}
+		if maxLength > 0 && n > 0 && total+size > maxLength {
+			break
+		}
+		n, total = n+1, total+size
+	}
+	return n
+}
+
+func unitsSize(us []bodyUnit) int {
+	n := 0
+	for _, u := range us {
+		n += u.size
+	}
+	return n
+}
+
+// pagedRead is the result of a chunked read.
+type pagedRead struct {
+	RevisionID          string     `json:"revisionId,omitempty"`
+	TotalCharacters     int        `json:"totalCharacters"`
+	RemainingCharacters int        `json:"remainingCharacters"`
+	NextCursor          int        `json:"nextCursor,omitempty"`
+	Chunks              []docChunk `json:"chunks"`
+}
+
+// readPage reads tab from cursor onwards, stopping at an element boundary
+// once maxLength characters have been collected. With chunkBy it returns
+// the chunks as JSON, marked with revisionID; otherwise the page is
+// returned as one text with a note on how to continue.
+func readPage(tab *google.DocumentTab, revisionID, format string, cursor, maxLength int, chunkBy string, chunkSize int) (string, error) {
+	if err := checkCursor(tab.Body, cursor); err != nil {
+		return "", err
+	}
+	r := bodyReader{tab: tab, text: docindex.New(tab.Body), format: format}
+	total := unitsSize(r.units(0))
+	us := r.units(cursor)
+
+	groups := [][]bodyUnit{}
+	if chunkBy == "" {
+		for _, u := range us {
+			groups = append(groups, []bodyUnit{u})
+		}
+	} else {
+		groups = groupUnits(us, chunkBy, chunkSize)
+	}
+	n := page(groups, maxLength)
+	var rest []bodyUnit
+	for _, g := range groups[n:] {
+		rest = append(rest, g...)
+	}
+	next := 0
+	if len(rest) > 0 {
+		next = rest[0].start
+	}
+
+	if chunkBy != "" {
+		result := pagedRead{RevisionID: revisionID, TotalCharacters: total, RemainingCharacters: unitsSize(rest), NextCursor: next, Chunks: []docChunk{}}
+		for _, g := range groups[:n] {
+			result.Chunks = append(result.Chunks, r.chunk(g))
+		}
+		b, err := json.Marshal(result)
+		return string(b), err
+	}
+
+	if n == 0 {
+		return "", fmt.Errorf("no content at or after cursor %d", cursor)
+	}
+	c := r.chunk(slices.Concat(groups[:n]...))
+	var sb strings.Builder
+	if format == "markdown" {
+		sb.WriteString(c.Text)
+	} else {
+		fmt.Fprintf(&sb, "Content (indices %d-%d, %d of %d characters):\n---\n%s", c.StartIndex, c.EndIndex, c.Characters, total, c.Text)
+	}
+	if next > 0 {
+		fmt.Fprintf(&sb, "\n\n... [Document continues for %d more characters. Read on with cursor=%d.]", unitsSize(rest), next)
+	}
+	return sb.String(), nil
+}
+
+// checkCursor rejects a cursor past the last body element.
+func checkCursor(body *google.DocumentBody, cursor int) error {
+	if cursor < 0 {
+		return fmt.Errorf("cursor cannot be negative")
+	}
+	if end := body.Content[len(body.Content)-1].EndIndex; cursor >= end {
+		return fmt.Errorf("cursor %d is past the end of the document (last index %d)", cursor, end-1)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// previewContext is how many characters of the surrounding paragraph a
+// preview shows on each side of a match.
+const previewContext = 30
+
+// maxPreviewMatches caps the matches listed in a preview; the count still
+// covers all of them.
+const maxPreviewMatches = 50
+
+// tabBody is a body to search along with the tab it belongs to.
+type tabBody struct {
+	tabID string
+	body  *google.DocumentBody
+}
+
+// searchBodies returns the body of the given tab, or when tabID is empty
+// every document tab (or the legacy body of a document without tabs),
+// matching what a request without tab criteria applies to.
+func searchBodies(doc *google.Document, tabID string) ([]tabBody, error) {
+	if tabID != "" || len(doc.Tabs) == 0 {
+		body, err := documentBody(doc, tabID)
+		if err != nil {
+			return nil, err
+		}
+		return []tabBody{{tabID: tabID, body: body}}, nil
+	}
+	var bodies []tabBody
+	var walk func(tabs []google.Tab)
+	walk = func(tabs []google.Tab) {
+		for _, t := range tabs {
+			if t.DocumentTab != nil && t.DocumentTab.Body != nil {
+				bodies = append(bodies, tabBody{tabID: t.TabProperties.TabID, body: t.DocumentTab.Body})
+			}
+			walk(t.ChildTabs)
+		}
+	}
+	walk(doc.Tabs)
+	return bodies, nil
+}
+
+type textMatch struct {
+	TabID       string `json:"tabId,omitempty"`
+	StartIndex  int    `json:"startIndex"`
+	EndIndex    int    `json:"endIndex"`
+	Text        string `json:"text"`
+	Replacement string `json:"replacement"`
+	Context     string `json:"context"`
+	style       *google.TextStyle
+}
+
+// wordRune reports whether r can be part of a word, in any script.
+func wordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+// wordMatches is like re.FindAllStringSubmatchIndex, but keeps only matches
+// that are whole words: not preceded or followed by a letter, digit or
+// underscore. RE2's \b only knows ASCII letters, so the boundaries are
+// matched as characters around a group holding re, and the search resumes
+// at the end of that group so that a boundary can serve two matches.
+func wordMatches(re *regexp.Regexp, s string) [][]int {
+	const boundary = `[^\p{L}\p{N}\p{M}_]`
+	word := regexp.MustCompile(`(?:^|` + boundary + `)(` + re.String() + `)(?:` + boundary + `|$)`)
+	var locs [][]int
+	for at := 0; at <= len(s); {
+		loc := word.FindStringSubmatchIndex(s[at:])
+		if loc == nil {
+			break
+		}
+		// Drop the whole match, leaving the group as match 0 and re's own
+		// groups numbered as re numbers them.
+		loc = loc[2:]
+		for i := range loc {
+			if loc[i] >= 0 {
+				loc[i] += at
+			}
+		}
+		// ^ matches wherever the search resumes, so check what precedes.
+		before, _ := utf8.DecodeLastRuneInString(s[:loc[0]])
+		if loc[1] > loc[0] && (loc[0] == 0 || !wordRune(before)) {
+			locs = append(locs, loc)
+			at = loc[1]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[loc[0]:])
+		at = loc[0] + max(size, 1)
+	}
+	return locs
+}
+
+// findMatches returns the matches of re in the body's text, only whole
+// words when wholeWord is set. Matches that cross a paragraph break or skip
+// over non-text content such as a table boundary are left out, since they
+// cannot be replaced as one range. When expand is set, replacement is
+// expanded with the match's capture groups.
+func findMatches(tb tabBody, re *regexp.Regexp, wholeWord bool, replacement string, expand bool) []textMatch {
+	text := docindex.New(tb.body)
+	s := text.String()
+	locs := re.FindAllStringSubmatchIndex(s, -1)
+	if wholeWord {
+		locs = wordMatches(re, s)
+	}
+	var matches []textMatch
+	for _, loc := range locs {
+		matched := s[loc[0]:loc[1]]
+		if matched == "" || strings.Contains(matched, "\n") {
+			continue
+		}
+		start, end := text.Range(loc[0], loc[1])
+		if end-start != docindex.UTF16Len(matched) {
+			continue
+		}
+		repl := replacement
+		if expand {
+			repl = string(re.ExpandString(nil, replacement, s, loc))
+		}
+		matches = append(matches, textMatch{
+			TabID:       tb.tabID,
+			StartIndex:  start,
+			EndIndex:    end,
+			Text:        matched,
+			Replacement: repl,
+			Context:     matchContext(s, loc[0], loc[1], repl),
+			style:       runStyle(tb.body.Content, start),
+		})
+	}
+	return matches
+}
+
+// matchContext shows a match within its paragraph as
+// "before [match → replacement] after".
+func matchContext(s string, start, end int, replacement string) string {
+	before := s[:start]
+	if i := strings.LastIndexByte(before, '\n'); i >= 0 {
+		before = before[i+1:]
+	}
+	if runes := []rune(before); len(runes) > previewContext {
+		before = "..." + string(runes[len(runes)-previewContext:])
+	}
+	after := s[end:]
+	if i := strings.IndexByte(after, '\n'); i >= 0 {
+		after = after[:i]
+	}
+	if docindex.RuneCount(after) > previewContext {
+		after = docindex.TruncateRunes(after, previewContext) + "..."
+	}
+	return fmt.Sprintf("%s[%s → %s]%s", before, s[start:end], replacement, after)
+}
+
+func registerDocsReplaceCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "findAndReplace",
+		Description: command.Description{Short: "Finds and replaces text in a document, as literal text or a regular expression. Use preview=true first to list the matches with surrounding context; the applied result reports how many occurrences were replaced. Plain text replacements keep the formatting of each match; with useRegex or wholeWord, each replacement takes the formatting of the first character of its match."},
+		Params: append([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "find", Type: command.String, Description: "The text or regular expression to find. Matches never span paragraphs.", Required: true},
+			{Name: "replace", Type: command.String, Description: "The replacement text. With useRegex, $1 or ${name} insert capture groups. Use an empty string to delete the matches.", Required: true},
+			{Name: "useRegex", Type: command.Bool, Description: "Treat find as a regular expression (RE2 syntax)."},
+			{Name: "matchCase", Type: command.Bool, Description: "Match letter case exactly. Defaults to false."},
+			{Name: "wholeWord", Type: command.Bool, Description: "Only match whole words: matches next to a letter, digit or underscore, in any script, are skipped."},
+			{Name: "preview", Type: command.Bool, Description: "If true, lists the matches and their replacements without changing the document."},
+			{Name: "tabId", Type: command.String, Description: "The ID of a specific tab to search. If not specified, searches every tab."},
+		}, writeControlParams...),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				Find       string `json:"find"`
+				Replace    string `json:"replace"`
+				UseRegex   bool   `json:"useRegex"`
+				MatchCase  bool   `json:"matchCase"`
+				WholeWord  bool   `json:"wholeWord"`
+				Preview    bool   `json:"preview"`
+				TabID      string `json:"tabId"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if params.Find == "" {
+				return command.TextErrorResult("find must not be empty"), nil
+			}
+
+			pattern := params.Find
+			if !params.UseRegex {
+				pattern = regexp.QuoteMeta(pattern)
+			}
+			if !params.MatchCase {
+				pattern = "(?i)" + pattern
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid regular expression: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			bodies, err := searchBodies(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to find and replace: %v", err)), nil
+			}
+			matches := []textMatch{}
+			for _, tb := range bodies {
+				matches = append(matches, findMatches(tb, re, params.WholeWord, params.Replace, params.UseRegex)...)
+			}
+
+			if params.Preview {
+				shown := matches
+				if len(shown) > maxPreviewMatches {
+					shown = shown[:maxPreviewMatches]
+				}
+				return command.JSONResult(map[string]any{
+					"matchCount": len(matches),
+					"matches":    shown,
+					"truncated":  len(matches) > len(shown),
+				}), nil
+			}
+			if len(matches) == 0 {
+				return command.TextResult(fmt.Sprintf("No matches found for %q.", params.Find)), nil
+			}
+
+			var requests []google.Request
+			if !params.UseRegex && !params.WholeWord {
+				// Plain text is left to replaceAllText, which keeps the
+				// formatting of each match.
+				req := &google.ReplaceAllTextRequest{
+					ContainsText: google.SubstringMatchCriteria{Text: params.Find, MatchCase: params.MatchCase},
+					ReplaceText:  params.Replace,
+				}
+				if params.TabID != "" {
+					req.TabsCriteria = &google.TabsCriteria{TabIDs: []string{params.TabID}}
+				}
+				requests = append(requests, google.Request{ReplaceAllText: req})
+			} else {
+				// Replace from the end of each tab backwards so earlier
+				// matches keep their indices.
+				sorted := append([]textMatch(nil), matches...)
+				sort.SliceStable(sorted, func(i, j int) bool {
+					if sorted[i].TabID != sorted[j].TabID {
+						return sorted[i].TabID < sorted[j].TabID
+					}
+					return sorted[i].StartIndex > sorted[j].StartIndex
+				})
+				for _, m := range sorted {
+					requests = append(requests, google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+						Range: google.Range{StartIndex: m.StartIndex, EndIndex: m.EndIndex, TabID: m.TabID},
+					}})
+					if m.Replacement != "" {
+						requests = append(requests, google.Request{InsertText: &google.InsertTextRequest{
+							Location: google.Location{Index: m.StartIndex, TabID: m.TabID},
+							Text:     m.Replacement,
+						}}, restyle(google.Range{StartIndex: m.StartIndex, EndIndex: m.StartIndex + docindex.UTF16Len(m.Replacement), TabID: m.TabID}, m.style))
+					}
+				}
+			}
+
+			resp, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID))
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to find and replace: %v", err)), nil
+			}
+			replaced := len(matches)
+			if len(resp.Replies) > 0 && resp.Replies[0].ReplaceAllText != nil {
+				// The count Docs reports can differ from the matches found
+				// above when the document changed in between.
+				replaced = resp.Replies[0].ReplaceAllText.OccurrencesChanged
+			}
+			return command.TextResult(fmt.Sprintf("Successfully replaced %d occurrence(s) of %q.", replaced, params.Find)), nil
+		},
+	})
+}
@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/markdown"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// findSection locates a section's heading by heading ID when one is given,
+// otherwise by its text.
+func findSection(body *google.DocumentBody, name, id string, instance int) (heading, error) {
+	switch {
+	case id != "":
+		for _, h := range headings(body) {
+			if h.id == id {
+				return h, nil
+			}
+		}
+		return heading{}, fmt.Errorf("heading with ID %q not found", id)
+	case name != "":
+		return findHeading(body, name, instance)
+	}
+	return heading{}, fmt.Errorf("provide heading or headingId")
+}
+
+// sectionElements returns the body elements [from, to) making up h's
+// section, optionally including the heading paragraph itself.
+func sectionElements(body *google.DocumentBody, h heading, includeHeading bool) (int, int) {
+	from := h.element
+	if !includeHeading {
+		from++
+	}
+	return from, sectionEnd(body, h)
+}
+
+var sectionParams = []command.Param{
+	{Name: "heading", Type: command.String, Description: "Text of the section's heading, matched ignoring case and surrounding whitespace."},
+	{Name: "headingId", Type: command.String, Description: "ID of the section's heading (paragraphStyle.headingId in readDocument format='json'), as an alternative to heading."},
+	{Name: "matchInstance", Type: command.Int, Description: "Which heading with this text to target (1st, 2nd, etc.). Defaults to 1."},
+}
+
+func registerDocsSectionCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "readSection",
+		Description: command.Description{Short: "Reads one section of a document as markdown: the content under a heading, up to the next heading of the same or higher level."},
+		Params: append([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "includeHeading", Type: command.Bool, Description: "Include the heading itself in the output. Defaults to true."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to read. If not specified, reads the first tab."},
+		}, sectionParams...),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID     string `json:"documentId"`
+				Heading        string `json:"heading"`
+				HeadingID      string `json:"headingId"`
+				MatchInstance  int    `json:"matchInstance"`
+				IncludeHeading *bool  `json:"includeHeading"`
+				TabID          string `json:"tabId"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read section: %v", err)), nil
+			}
+			h, err := findSection(tab.Body, params.Heading, params.HeadingID, max(params.MatchInstance, 1))
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read section: %v", err)), nil
+			}
+
+			from, to := sectionElements(tab.Body, h, params.IncludeHeading == nil || *params.IncludeHeading)
+			// A section is part of the body, so the headers and footers
+			// FromDocs notes for the whole tab are left out.
+			section := *tab
+			section.Body = &google.DocumentBody{Content: tab.Body.Content[from:to]}
+			section.DocumentStyle = nil
+			md := markdown.FromDocs(&section)
+			if md == "" {
+				return command.TextResult(fmt.Sprintf("Section %q is empty.", h.text)), nil
+			}
+			start, end := h.endIndex, h.endIndex
+			if from < to {
+				start, end = tab.Body.Content[from].StartIndex, tab.Body.Content[to-1].EndIndex
+			}
+			return command.TextResult(fmt.Sprintf("Section %q (indices %d-%d):\n---\n%s", h.text, start, end, md)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "replaceSection",
+		Description: command.Description{Short: "Replaces the content under a heading, up to the next heading of the same or higher level, with content parsed from markdown. The heading and the rest of the document are kept unless includeHeading is set."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "markdown", Type: command.String, Description: "The markdown content for the section.", Required: true},
+			{Name: "includeHeading", Type: command.Bool, Description: "Replace the heading too, so the markdown should start with the section's new heading. Defaults to false."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to edit. If not specified, edits the first tab."},
+		}, sectionParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID     string `json:"documentId"`
+				Markdown       string `json:"markdown"`
+				Heading        string `json:"heading"`
+				HeadingID      string `json:"headingId"`
+				MatchInstance  int    `json:"matchInstance"`
+				IncludeHeading bool   `json:"includeHeading"`
+				TabID          string `json:"tabId"`
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to replace section: %v", err)), nil
+			}
+			h, err := findSection(body, params.Heading, params.HeadingID, max(params.MatchInstance, 1))
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to replace section: %v", err)), nil
+			}
+
+			// Whole paragraphs are deleted up to the next heading, except at
+			// the end of the body, whose final newline cannot be deleted.
+			from, to := sectionElements(body, h, params.IncludeHeading)
+			startIndex := h.endIndex
+			if from < len(body.Content) {
+				startIndex = body.Content[from].StartIndex
+			}
+			endIndex := bodyEndIndex(body)
+			if to < len(body.Content) {
+				endIndex = body.Content[to].StartIndex
+			}
+
+			base := doc.RevisionID
+			if endIndex > startIndex {
+				del := google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+					Range: google.Range{StartIndex: startIndex, EndIndex: endIndex, TabID: params.TabID},
+				}}
+				resp, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{del}, params.control(base))
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to clear section: %v", err)), nil
+				}
+				params.applied(resp)
+				base = params.ExpectedRevisionID
+			}
+
+			// A heading that ends the body has no paragraph after it to
+			// insert into, so start one.
+			var requests []google.Request
+			if startIndex > bodyEndIndex(body) {
+				startIndex = bodyEndIndex(body)
+				requests = append(requests, google.Request{InsertText: &google.InsertTextRequest{
+					Location: google.Location{Index: startIndex, TabID: params.TabID},
+					Text:     "\n",
+				}})
+				startIndex++
+			}
+			requests = append(requests, markdown.ToRequests(params.Markdown, markdown.Options{
+				StartIndex:  startIndex,
+				TabID:       params.TabID,
+				ResetStyles: true,
+			})...)
+			if len(requests) > 0 {
+				if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(base)); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to replace section: %v", err)), nil
+				}
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully replaced section %q (indices %d-%d) with %d characters of markdown.\nApplied %s.", h.text, startIndex, endIndex, len(params.Markdown), summarizeRequests(requests))), nil
+		},
+	})
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/amarbel-llc/piers/internal/docindex"
 	"github.com/amarbel-llc/piers/internal/google"
 	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
 )
@@ -12,14 +16,14 @@ import (
 func registerDocsStructureCommands(app *command.App, client *google.Client) {
 	app.AddCommand(&command.Command{
 		Name:        "insertTable",
-		Description: command.Description{Short: "Inserts an empty table with the specified number of rows and columns at a character index in the document."},
-		Params: []command.Param{
+		Description: command.Description{Short: "Inserts an empty table with the specified number of rows and columns at a character index, or next to existing text, a heading or a named range. Returns the resolved index."},
+		Params: slices.Concat([]command.Param{
 			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
 			{Name: "rows", Type: command.Int, Description: "Number of rows for the new table.", Required: true},
 			{Name: "columns", Type: command.Int, Description: "Number of columns for the new table.", Required: true},
-			{Name: "index", Type: command.Int, Description: "1-based character index within the document body. Use readDocument with format='json' to inspect indices.", Required: true},
+			{Name: "index", Type: command.Int, Description: "1-based character index within the document body. Use readDocument with format='json' to inspect indices, or use an anchor parameter instead."},
 			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to insert into. If not specified, inserts into the first tab."},
-		},
+		}, anchorParams, writeControlParams),
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
 				DocumentID string `json:"documentId"`
@@ -27,79 +31,238 @@ func registerDocsStructureCommands(app *command.App, client *google.Client) {
 				Columns    int    `json:"columns"`
 				Index      int    `json:"index"`
 				TabID      string `json:"tabId"`
+				anchor
+				writeControl
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
 			}
 
-			if err := client.Docs.BatchUpdate(params.DocumentID, nil); err != nil {
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table: %v", err)), nil
+			}
+			index, err := resolveIndex(tab, docindex.New(tab.Body), params.Index, params.anchor)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table: %v", err)), nil
+			}
+
+			req := google.Request{InsertTable: &google.InsertTableRequest{
+				Location: google.Location{Index: index, TabID: params.TabID},
+				Rows:     params.Rows,
+				Columns:  params.Columns,
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(params.anchor.revision(doc, params.ExpectedRevisionID))); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to insert table: %v", err)), nil
 			}
 
-			return command.TextResult(fmt.Sprintf("Successfully inserted a %dx%d table at index %d.", params.Rows, params.Columns, params.Index)), nil
+			return command.TextResult(fmt.Sprintf("Successfully inserted a %dx%d table at index %d.", params.Rows, params.Columns, index)), nil
 		},
 	})
 
 	app.AddCommand(&command.Command{
 		Name:        "insertPageBreak",
-		Description: command.Description{Short: "Inserts a page break at a character index in the document."},
-		Params: []command.Param{
+		Description: command.Description{Short: "Inserts a page break at a character index, or next to existing text, a heading or a named range. Returns the resolved index."},
+		Params: slices.Concat([]command.Param{
 			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
-			{Name: "index", Type: command.Int, Description: "1-based character index within the document body. Use readDocument with format='json' to inspect indices.", Required: true},
+			{Name: "index", Type: command.Int, Description: "1-based character index within the document body. Use readDocument with format='json' to inspect indices, or use an anchor parameter instead."},
 			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to insert into. If not specified, inserts into the first tab."},
-		},
+		}, anchorParams, writeControlParams),
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
 				DocumentID string `json:"documentId"`
 				Index      int    `json:"index"`
 				TabID      string `json:"tabId"`
+				anchor
+				writeControl
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
 			}
 
-			if err := client.Docs.BatchUpdate(params.DocumentID, nil); err != nil {
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert page break: %v", err)), nil
+			}
+			index, err := resolveIndex(tab, docindex.New(tab.Body), params.Index, params.anchor)
+			if err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to insert page break: %v", err)), nil
 			}
 
-			return command.TextResult(fmt.Sprintf("Successfully inserted page break at index %d.", params.Index)), nil
+			req := google.Request{InsertPageBreak: &google.InsertPageBreakRequest{
+				Location: google.Location{Index: index, TabID: params.TabID},
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(params.anchor.revision(doc, params.ExpectedRevisionID))); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert page break: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully inserted page break at index %d.", index)), nil
 		},
 	})
 
 	app.AddCommand(&command.Command{
 		Name:        "insertImage",
-		Description: command.Description{Short: "Inserts an inline image into a Google Document from a publicly accessible URL."},
-		Params: []command.Param{
+		Description: command.Description{Short: "Inserts an inline image into a Google Document at a character index or next to existing text, a heading or a named range. The image can come from a public URL, a data URI, a local file or a Drive file; local files and data URIs are uploaded to Drive and Drive files are shared with a temporary link while Docs copies them in. PNG, JPEG and GIF images up to 50 MB and 25 megapixels are supported. Returns the resolved index."},
+		Params: slices.Concat([]command.Param{
 			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
-			{Name: "imageUrl", Type: command.String, Description: "Publicly accessible URL to the image (http:// or https://).", Required: true},
-			{Name: "index", Type: command.Int, Description: "1-based character index in the document body where the image should be inserted.", Required: true},
-			{Name: "width", Type: command.Float, Description: "Width of the image in points."},
+			{Name: "imageUrl", Type: command.String, Description: "Publicly accessible URL to the image (http:// or https://), or a base64 data URI (data:image/png;base64,...)."},
+			{Name: "localPath", Type: command.String, Description: "Path to an image file on this machine to upload and insert (alternative to imageUrl)."},
+			{Name: "driveFileId", Type: command.String, Description: "ID of an image file in Google Drive to insert (alternative to imageUrl)."},
+			{Name: "index", Type: command.Int, Description: "1-based character index in the document body where the image should be inserted, or use an anchor parameter instead."},
+			{Name: "width", Type: command.Float, Description: "Width of the image in points. If only width or height is given, the other follows the image's aspect ratio."},
 			{Name: "height", Type: command.Float, Description: "Height of the image in points."},
+			{Name: "parentFolderId", Type: command.String, Description: "Drive folder to upload local files and data URIs into. If not specified, uploads to My Drive."},
+			{Name: "keepUploadedFile", Type: command.Bool, Description: "If true, keeps the uploaded copy in Drive after insertion. By default it is deleted, as the document holds its own copy."},
 			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to insert into. If not specified, inserts into the first tab."},
-		},
+		}, anchorParams, writeControlParams),
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
-				DocumentID string  `json:"documentId"`
-				ImageURL   string  `json:"imageUrl"`
-				Index      int     `json:"index"`
-				Width      float64 `json:"width"`
-				Height     float64 `json:"height"`
-				TabID      string  `json:"tabId"`
+				DocumentID       string  `json:"documentId"`
+				ImageURL         string  `json:"imageUrl"`
+				LocalPath        string  `json:"localPath"`
+				DriveFileID      string  `json:"driveFileId"`
+				Index            int     `json:"index"`
+				Width            float64 `json:"width"`
+				Height           float64 `json:"height"`
+				ParentFolderID   string  `json:"parentFolderId"`
+				KeepUploadedFile bool    `json:"keepUploadedFile"`
+				TabID            string  `json:"tabId"`
+				anchor
+				writeControl
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
 			}
+			given := 0
+			for _, s := range []string{params.ImageURL, params.LocalPath, params.DriveFileID} {
+				if s != "" {
+					given++
+				}
+			}
+			if given != 1 {
+				return command.TextErrorResult("provide exactly one of imageUrl, localPath or driveFileId"), nil
+			}
+			if params.Width < 0 || params.Height < 0 {
+				return command.TextErrorResult("width and height must be positive"), nil
+			}
 
-			if err := client.Docs.BatchUpdate(params.DocumentID, nil); err != nil {
+			// Read and validate the image before touching Drive, so a bad
+			// file or anchor leaves nothing behind.
+			var upload *imageFile
+			var pixelWidth, pixelHeight int
+			switch {
+			case strings.HasPrefix(params.ImageURL, "data:"):
+				img, err := decodeDataURI(params.ImageURL)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to insert image: %v", err)), nil
+				}
+				upload = &img
+			case params.ImageURL != "":
+				if err := checkImageURL(params.ImageURL); err != nil {
+					return command.TextErrorResult(err.Error()), nil
+				}
+			case params.LocalPath != "":
+				img, err := readLocalImage(params.LocalPath)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to insert image: %v", err)), nil
+				}
+				upload = &img
+			}
+			if upload != nil {
+				pixelWidth, pixelHeight = upload.width, upload.height
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert image: %v", err)), nil
+			}
+			index, err := resolveIndex(tab, docindex.New(tab.Body), params.Index, params.anchor)
+			if err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to insert image: %v", err)), nil
 			}
 
-			sizeInfo := ""
-			if params.Width > 0 && params.Height > 0 {
-				sizeInfo = fmt.Sprintf(" with size %.0fx%.0fpt", params.Width, params.Height)
+			uri := params.ImageURL
+			var cleanups []func() error
+			var source string
+			switch {
+			case upload != nil:
+				file, err := client.Drive.UploadFile(upload.name, upload.mimeType, params.ParentFolderID, upload.content)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to upload image: %v", err)), nil
+				}
+				source = fmt.Sprintf(" from %s, uploaded to Drive as %s", upload.name, file.ID)
+				if !params.KeepUploadedFile {
+					cleanups = append(cleanups, func() error { return client.Drive.DeleteFile(file.ID) })
+					source += " (removed after insertion)"
+				}
+				uri = driveImageURL(file)
+				unshare, err := shareForInsert(client, file.ID)
+				if err != nil {
+					for _, cleanup := range cleanups {
+						cleanup()
+					}
+					return command.TextErrorResult(fmt.Sprintf("failed to insert image: %v", err)), nil
+				}
+				if params.KeepUploadedFile {
+					cleanups = append(cleanups, unshare)
+				}
+			case params.DriveFileID != "":
+				file, err := client.Drive.GetFile(params.DriveFileID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to read Drive file: %v", err)), nil
+				}
+				if _, ok := imageMimeTypes[file.MimeType]; !ok {
+					return command.TextErrorResult(fmt.Sprintf("failed to insert image: Drive file %s is %s, not a PNG, JPEG or GIF image", file.ID, file.MimeType)), nil
+				}
+				if size, err := strconv.ParseInt(file.Size, 10, 64); err == nil && size > maxImageBytes {
+					return command.TextErrorResult(fmt.Sprintf("failed to insert image: image is %d MB; the limit is %d MB", size>>20, maxImageBytes>>20)), nil
+				}
+				if m := file.ImageMediaMetadata; m != nil {
+					pixelWidth, pixelHeight = m.Width, m.Height
+				}
+				uri = driveImageURL(file)
+				unshare, err := shareForInsert(client, file.ID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to insert image: %v", err)), nil
+				}
+				cleanups = append(cleanups, unshare)
+				source = fmt.Sprintf(" from Drive file %s", file.Name)
 			}
 
-			return command.TextResult(fmt.Sprintf("Successfully inserted image at index %d%s.", params.Index, sizeInfo)), nil
+			size := imageSize(params.Width, params.Height, pixelWidth, pixelHeight)
+			req := google.Request{InsertInlineImage: &google.InsertInlineImageRequest{
+				Location:   google.Location{Index: index, TabID: params.TabID},
+				URI:        uri,
+				ObjectSize: size,
+			}}
+			_, err = client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(params.anchor.revision(doc, params.ExpectedRevisionID)))
+			var warnings []string
+			for _, cleanup := range cleanups {
+				if cerr := cleanup(); cerr != nil {
+					warnings = append(warnings, cerr.Error())
+				}
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert image: %v", err)), nil
+			}
+
+			result := fmt.Sprintf("Successfully inserted image%s at index %d%s.", source, index, sizeNote(size))
+			if len(warnings) > 0 {
+				result += "\nWarning: cleanup failed: " + strings.Join(warnings, "; ")
+			}
+			return command.TextResult(result), nil
 		},
 	})
 }
@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// suggestionViews maps readDocument's suggestions option to a view mode.
+// The empty mode leaves the choice to the API.
+var suggestionViews = map[string]string{
+	"":         "",
+	"inline":   google.SuggestionsInline,
+	"accepted": google.PreviewSuggestionsAccepted,
+	"rejected": google.PreviewWithoutSuggestions,
+}
+
+// suggestionPiece is one text run belonging to a suggestion, with every
+// suggested insertion and deletion the run is part of.
+type suggestionPiece struct {
+	start, end            int
+	text                  string
+	style                 *google.TextStyle
+	insertions, deletions []string
+}
+
+// suggestion gathers the runs of one suggested insertion, deletion or text
+// style change, which may be split across paragraphs and table cells.
+type suggestion struct {
+	ID         string `json:"id"`
+	Kind       string `json:"kind"`
+	Text       string `json:"text"`
+	StartIndex int    `json:"startIndex"`
+	EndIndex   int    `json:"endIndex"`
+	pieces     []suggestionPiece
+}
+
+// suggestions lists the suggestions in content, which must have been read
+// with google.SuggestionsInline, in document order.
+func suggestions(content []google.ContentElement) []*suggestion {
+	byID := map[string]*suggestion{}
+	var order []*suggestion
+	add := func(id, kind string, pe google.ParagraphElement) {
+		key := kind + "/" + id
+		s, ok := byID[key]
+		if !ok {
+			s = &suggestion{ID: id, Kind: kind, StartIndex: pe.StartIndex}
+			byID[key] = s
+			order = append(order, s)
+		}
+		run := pe.TextRun
+		s.pieces = append(s.pieces, suggestionPiece{pe.StartIndex, pe.EndIndex, run.Content, run.TextStyle, run.SuggestedInsertionIDs, run.SuggestedDeletionIDs})
+		s.Text += pe.TextRun.Content
+		s.StartIndex, s.EndIndex = min(s.StartIndex, pe.StartIndex), max(s.EndIndex, pe.EndIndex)
+	}
+	var walk func([]google.ContentElement)
+	walk = func(content []google.ContentElement) {
+		for _, el := range content {
+			if el.Paragraph != nil {
+				for _, pe := range el.Paragraph.Elements {
+					if pe.TextRun == nil {
+						continue
+					}
+					for _, id := range pe.TextRun.SuggestedInsertionIDs {
+						add(id, "insertion", pe)
+					}
+					for _, id := range pe.TextRun.SuggestedDeletionIDs {
+						add(id, "deletion", pe)
+					}
+					for id := range pe.TextRun.SuggestedTextStyleChanges {
+						add(id, "textStyle", pe)
+					}
+				}
+			}
+			if el.Table != nil {
+				for _, row := range el.Table.TableRows {
+					for _, cell := range row.TableCells {
+						walk(cell.Content)
+					}
+				}
+			}
+		}
+	}
+	walk(content)
+	sort.SliceStable(order, func(i, j int) bool { return order[i].StartIndex < order[j].StartIndex })
+	return order
+}
+
+// resolveSuggestionRequests emulates accepting or rejecting suggestions,
+// which the Docs API cannot do directly. Each run gets one outcome from all
+// the suggestions on it: accepting drops a run any of them deletes,
+// rejecting drops a run any of them inserts. Dropped text is deleted; kept
+// text is deleted and inserted again as ordinary text, with the style its
+// run had. A run that is also part of a suggestion not in ss cannot be
+// resolved on its own, so that is an error. Style suggestions cannot be
+// emulated and are returned as skipped. Edits run bottom-to-top so each
+// range is still valid when its turn comes.
+func resolveSuggestionRequests(ss []*suggestion, accept bool, tabID string) ([]google.Request, []*suggestion, error) {
+	selected := map[string]bool{}
+	for _, s := range ss {
+		if s.Kind != "textStyle" {
+			selected[s.ID] = true
+		}
+	}
+	runs := map[int]suggestionPiece{}
+	var skipped []*suggestion
+	for _, s := range ss {
+		if s.Kind == "textStyle" {
+			skipped = append(skipped, s)
+			continue
+		}
+		for _, p := range s.pieces {
+			for _, id := range slices.Concat(p.insertions, p.deletions) {
+				if !selected[id] {
+					return nil, nil, fmt.Errorf("suggestion %s overlaps suggestion %s; resolve them together", s.ID, id)
+				}
+			}
+			runs[p.start] = p
+		}
+	}
+	starts := make([]int, 0, len(runs))
+	for start := range runs {
+		starts = append(starts, start)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(starts)))
+
+	var requests []google.Request
+	for _, start := range starts {
+		r := runs[start]
+		requests = append(requests, google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+			Range: google.Range{StartIndex: r.start, EndIndex: r.end, TabID: tabID},
+		}})
+		keep := len(r.deletions) == 0
+		if !accept {
+			keep = len(r.insertions) == 0
+		}
+		if keep {
+			requests = append(requests, google.Request{InsertText: &google.InsertTextRequest{
+				Location: google.Location{Index: r.start, TabID: tabID},
+				Text:     r.text,
+			}}, restyle(google.Range{StartIndex: r.start, EndIndex: r.end, TabID: tabID}, r.style))
+		}
+	}
+	return requests, skipped, nil
+}
+
+func registerDocsSuggestionCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "listSuggestions",
+		Description: command.Description{Short: "Lists the suggested edits in a document — insertions, deletions and text style changes — with their IDs, text and index ranges. The Docs API does not report who made a suggestion, and cannot create suggestions: edits made through these tools are always applied directly."},
+		Params: []command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				TabID      string `json:"tabId"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.GetView(params.DocumentID, google.SuggestionsInline)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to list suggestions: %v", err)), nil
+			}
+
+			ss := suggestions(body.Content)
+			if ss == nil {
+				ss = []*suggestion{}
+			}
+			return command.JSONResult(ss), nil
+		},
+	})
+
+	for _, accept := range []bool{true, false} {
+		name, verb, past := "acceptSuggestions", "accept", "Accepted"
+		effect := "suggested insertions become ordinary text and suggested deletions are removed"
+		if !accept {
+			name, verb, past = "rejectSuggestions", "reject", "Rejected"
+			effect = "suggested insertions are removed and suggested deletions become ordinary text"
+		}
+		app.AddCommand(&command.Command{
+			Name:        name,
+			Description: command.Description{Short: fmt.Sprintf("%ss suggested edits by ID, or all of them: %s. The Docs API has no call for this, so the text is edited directly; text that stays is rewritten with its formatting. Suggestions that share text must be resolved together. Text style suggestions are skipped.", strings.ToUpper(verb[:1])+verb[1:], effect)},
+			Params: append([]command.Param{
+				{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+				{Name: "suggestionIds", Type: command.Array, Description: "IDs of the suggestions to " + verb + ", as returned by listSuggestions."},
+				{Name: "all", Type: command.Bool, Description: "If true, " + verb + "s every suggestion in the document (alternative to suggestionIds)."},
+				{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+			}, writeControlParams...),
+			Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+				var params struct {
+					DocumentID    string   `json:"documentId"`
+					SuggestionIDs []string `json:"suggestionIds"`
+					All           bool     `json:"all"`
+					TabID         string   `json:"tabId"`
+					writeControl
+				}
+				if err := json.Unmarshal(args, &params); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+				}
+				if params.All == (len(params.SuggestionIDs) > 0) {
+					return command.TextErrorResult("provide either suggestionIds or all=true"), nil
+				}
+
+				doc, err := client.Docs.GetView(params.DocumentID, google.SuggestionsInline)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+				}
+				body, err := documentBody(doc, params.TabID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to %s suggestions: %v", verb, err)), nil
+				}
+
+				ss := suggestions(body.Content)
+				if !params.All {
+					var selected []*suggestion
+					var missing []string
+					for _, id := range params.SuggestionIDs {
+						n := len(selected)
+						for _, s := range ss {
+							if s.ID == id {
+								selected = append(selected, s)
+							}
+						}
+						if len(selected) == n {
+							missing = append(missing, id)
+						}
+					}
+					if len(missing) > 0 {
+						return command.TextErrorResult(fmt.Sprintf("failed to %s suggestions: not found: %s", verb, strings.Join(missing, ", "))), nil
+					}
+					ss = selected
+				}
+				if len(ss) == 0 {
+					return command.TextResult("The document has no suggestions."), nil
+				}
+
+				requests, skipped, err := resolveSuggestionRequests(ss, accept, params.TabID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to %s suggestions: %v", verb, err)), nil
+				}
+				if len(requests) > 0 {
+					if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+						return command.TextErrorResult(fmt.Sprintf("failed to %s suggestions: %v", verb, err)), nil
+					}
+				}
+
+				var ids []string
+				for _, s := range ss {
+					if !slices.Contains(skipped, s) && !slices.Contains(ids, s.ID) {
+						ids = append(ids, s.ID)
+					}
+				}
+				result := fmt.Sprintf("Successfully %s %d suggestion(s)", strings.ToLower(past), len(ids))
+				if len(ids) > 0 {
+					result += ": " + strings.Join(ids, ", ")
+				}
+				result += "."
+				if len(skipped) > 0 {
+					var names []string
+					for _, s := range skipped {
+						names = append(names, s.ID)
+					}
+					result += fmt.Sprintf("\nSkipped text style suggestions, which can only be resolved in Google Docs: %s.", strings.Join(names, ", "))
+				}
+				return command.TextResult(result), nil
+			},
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amarbel-llc/piers/internal/google"
+)
+
+// suggestedParagraph builds a paragraph of runs from index 1. Each run is
+// "text" or "text|ins:a,b|del:c" naming the suggestions it belongs to.
+func suggestedParagraph(runs ...string) []google.ContentElement {
+	var elements []google.ParagraphElement
+	index := 1
+	for _, r := range runs {
+		parts := strings.Split(r, "|")
+		run := &google.TextRun{Content: parts[0]}
+		for _, p := range parts[1:] {
+			kind, ids, _ := strings.Cut(p, ":")
+			if kind == "ins" {
+				run.SuggestedInsertionIDs = strings.Split(ids, ",")
+			} else {
+				run.SuggestedDeletionIDs = strings.Split(ids, ",")
+			}
+		}
+		end := index + len(parts[0])
+		elements = append(elements, google.ParagraphElement{StartIndex: index, EndIndex: end, TextRun: run})
+		index = end
+	}
+	return []google.ContentElement{{StartIndex: 1, EndIndex: index, Paragraph: &google.Paragraph{Elements: elements}}}
+}
+
+// describe summarizes requests as "del 3-6", "ins 3 \"abc\"" and "style 3-6".
+func describe(requests []google.Request) []string {
+	var out []string
+	for _, r := range requests {
+		switch {
+		case r.DeleteContentRange != nil:
+			out = append(out, fmt.Sprintf("del %d-%d", r.DeleteContentRange.Range.StartIndex, r.DeleteContentRange.Range.EndIndex))
+		case r.InsertText != nil:
+			out = append(out, fmt.Sprintf("ins %d %q", r.InsertText.Location.Index, r.InsertText.Text))
+		case r.UpdateTextStyle != nil:
+			out = append(out, fmt.Sprintf("style %d-%d", r.UpdateTextStyle.Range.StartIndex, r.UpdateTextStyle.Range.EndIndex))
+		}
+	}
+	return out
+}
+
+func TestResolveOverlappingSuggestions(t *testing.T) {
+	// "new" is a suggested insertion that another suggestion deletes.
+	content := suggestedParagraph("ab", "new|ins:i1|del:d1", "cd|ins:i1", "ef\n")
+	tests := []struct {
+		accept bool
+		want   []string
+	}{
+		{true, []string{"del 6-8", `ins 6 "cd"`, "style 6-8", "del 3-6"}},
+		{false, []string{"del 6-8", "del 3-6"}},
+	}
+	for _, tt := range tests {
+		requests, skipped, err := resolveSuggestionRequests(suggestions(content), tt.accept, "")
+		if err != nil {
+			t.Fatalf("accept=%v: %v", tt.accept, err)
+		}
+		if len(skipped) > 0 {
+			t.Errorf("accept=%v skipped %v", tt.accept, skipped)
+		}
+		if got := describe(requests); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("accept=%v gives %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestResolveSuggestionEditsEachRunOnce(t *testing.T) {
+	// Both insertions cover "x", which must be rewritten once, not twice.
+	content := suggestedParagraph("ab", "x|ins:i1,i2", "cd|del:d1", "\n")
+	requests, _, err := resolveSuggestionRequests(suggestions(content), true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"del 4-6", "del 3-4", `ins 3 "x"`, "style 3-4"}
+	if got := describe(requests); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveSuggestionRefusesUnselectedOverlap(t *testing.T) {
+	content := suggestedParagraph("ab", "new|ins:i1|del:d1", "cd\n")
+	var ss []*suggestion
+	for _, s := range suggestions(content) {
+		if s.ID == "i1" {
+			ss = append(ss, s)
+		}
+	}
+	if _, _, err := resolveSuggestionRequests(ss, true, ""); err == nil || !strings.Contains(err.Error(), "d1") {
+		t.Errorf("resolving i1 alone gives %v, want an error naming d1", err)
+	}
+}
@@ -0,0 +1,829 @@
+package tools
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/docindex"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/markdown"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// minColumnWidth is the narrowest column, in points, the Docs API accepts.
+const minColumnWidth = 5
+
+// tableSelector picks a top-level table in the body. At most one field may
+// be set; with none, the body must hold exactly one table.
+type tableSelector struct {
+	TableIndex      int    `json:"tableIndex"`
+	TableStartIndex int    `json:"tableStartIndex"`
+	NearText        string `json:"nearText"`
+}
+
+var tableSelectorParams = []command.Param{
+	{Name: "tableIndex", Type: command.Int, Description: "Which table to use, counting from 1 in document order. May be omitted when the document has a single table."},
+	{Name: "tableStartIndex", Type: command.Int, Description: "The startIndex of the table, as shown by readDocument with format='json' (alternative to tableIndex)."},
+	{Name: "nearText", Type: command.String, Description: "Use the table containing this exact text, or else the first table after it (alternative to tableIndex)."},
+}
+
+// table is a top-level table together with its position in the body.
+type table struct {
+	ordinal              int
+	startIndex, endIndex int
+	*google.Table
+}
+
+// tables lists the tables at the top level of body in document order.
+func tables(body *google.DocumentBody) []table {
+	var ts []table
+	for _, el := range body.Content {
+		if el.Table != nil {
+			ts = append(ts, table{ordinal: len(ts) + 1, startIndex: el.StartIndex, endIndex: el.EndIndex, Table: el.Table})
+		}
+	}
+	return ts
+}
+
+// findTable resolves sel against body.
+func findTable(body *google.DocumentBody, sel tableSelector) (table, error) {
+	ts := tables(body)
+	if len(ts) == 0 {
+		return table{}, fmt.Errorf("document has no tables")
+	}
+	given := 0
+	for _, set := range []bool{sel.TableIndex != 0, sel.TableStartIndex != 0, sel.NearText != ""} {
+		if set {
+			given++
+		}
+	}
+	switch {
+	case given > 1:
+		return table{}, fmt.Errorf("only one of tableIndex, tableStartIndex, nearText may be given")
+	case given == 0 && len(ts) > 1:
+		return table{}, fmt.Errorf("document has %d tables; provide tableIndex, tableStartIndex or nearText", len(ts))
+	case given == 0:
+		return ts[0], nil
+	case sel.TableIndex != 0:
+		if sel.TableIndex < 1 || sel.TableIndex > len(ts) {
+			return table{}, fmt.Errorf("tableIndex %d is out of range; document has %d tables", sel.TableIndex, len(ts))
+		}
+		return ts[sel.TableIndex-1], nil
+	case sel.TableStartIndex != 0:
+		for _, t := range ts {
+			if t.startIndex == sel.TableStartIndex {
+				return t, nil
+			}
+		}
+		return table{}, fmt.Errorf("no table found at index %d", sel.TableStartIndex)
+	}
+
+	start, _, err := findText(docindex.New(body), sel.NearText, 0, 1)
+	if err != nil {
+		return table{}, err
+	}
+	for _, t := range ts {
+		if t.endIndex > start {
+			return t, nil
+		}
+	}
+	return table{}, fmt.Errorf("no table found at or after text %q", sel.NearText)
+}
+
+// cell returns the cell at the 0-based row and column, reporting
+// out-of-range positions the way the rest of the table tools count them.
+func (t table) cell(row, column int) (*google.TableCell, error) {
+	if row < 0 || row >= len(t.TableRows) {
+		return nil, fmt.Errorf("row index %d is out of range; table has %d rows (0-based)", row, len(t.TableRows))
+	}
+	cells := t.TableRows[row].TableCells
+	if column < 0 || column >= len(cells) {
+		return nil, fmt.Errorf("column index %d is out of range; row %d has %d columns (0-based)", column, row, len(cells))
+	}
+	return &cells[column], nil
+}
+
+// cellRange returns the editable [start, end) range of a cell's content,
+// which excludes the cell's final newline. Empty cells give start == end.
+func cellRange(cell *google.TableCell) (int, int, error) {
+	if len(cell.Content) == 0 {
+		return 0, 0, fmt.Errorf("cell at index %d has no content", cell.StartIndex)
+	}
+	return cell.Content[0].StartIndex, cell.Content[len(cell.Content)-1].EndIndex - 1, nil
+}
+
+// cells returns the text of every cell, row by row.
+func (t table) cells() [][]string {
+	out := make([][]string, len(t.TableRows))
+	for r, row := range t.TableRows {
+		out[r] = make([]string, len(row.TableCells))
+		for c, cell := range row.TableCells {
+			out[r][c] = segmentText(cell.Content)
+		}
+	}
+	return out
+}
+
+func (t table) location(tabID string) google.Location {
+	return google.Location{Index: t.startIndex, TabID: tabID}
+}
+
+func (t table) cellLocation(tabID string, row, column int) google.TableCellLocation {
+	return google.TableCellLocation{TableStartLocation: t.location(tabID), RowIndex: row, ColumnIndex: column}
+}
+
+// cellString renders one JSON value of a data grid as cell text, keeping
+// numbers exactly as written.
+func cellString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	if v := strings.TrimSpace(string(raw)); v != "null" {
+		return v
+	}
+	return ""
+}
+
+func registerDocsTableCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "insertTableWithData",
+		Description: command.Description{Short: "Inserts a table filled with content, given either as a 2D array of cell values or as a markdown table, at a character index or next to existing text, a heading or a named range. Use insertTable for an empty grid."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "data", Type: command.String, Description: "JSON array of rows, each an array of cell values, e.g. [[\"Region\",\"Sales\"],[\"North\",10]]. Short rows are padded with empty cells."},
+			{Name: "markdown", Type: command.String, Description: "A markdown table to insert instead of data. Cells may use inline formatting such as **bold** and links."},
+			{Name: "headerRow", Type: command.Bool, Description: "If true (default), the first row of data is bold. Markdown tables always have a header row."},
+			{Name: "index", Type: command.Int, Description: "1-based character index within the document body. Use readDocument with format='json' to inspect indices, or use an anchor parameter instead."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab to insert into. If not specified, inserts into the first tab."},
+		}, anchorParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string          `json:"documentId"`
+				Data       json.RawMessage `json:"data"`
+				Markdown   string          `json:"markdown"`
+				HeaderRow  *bool           `json:"headerRow"`
+				Index      int             `json:"index"`
+				TabID      string          `json:"tabId"`
+				anchor
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			var data [][]json.RawMessage
+			if err := decodeJSONParam(params.Data, &data); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid data: %v", err)), nil
+			}
+			if (len(data) > 0) == (params.Markdown != "") {
+				return command.TextErrorResult("provide exactly one of data or markdown"), nil
+			}
+			if params.Markdown != "" && !strings.Contains(params.Markdown, "|") {
+				return command.TextErrorResult("markdown must contain a table"), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			tab, err := documentTab(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table: %v", err)), nil
+			}
+			index, err := resolveIndex(tab, docindex.New(tab.Body), params.Index, params.anchor)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table: %v", err)), nil
+			}
+
+			opts := markdown.Options{StartIndex: index, TabID: params.TabID}
+			var requests []google.Request
+			if params.Markdown != "" {
+				requests = markdown.ToRequests(params.Markdown, opts)
+			} else {
+				cells := make([][]string, len(data))
+				for r, row := range data {
+					cells[r] = make([]string, len(row))
+					for c, raw := range row {
+						cells[r][c] = cellString(raw)
+					}
+				}
+				requests = markdown.TableRequests(cells, params.HeaderRow == nil || *params.HeaderRow, opts)
+			}
+			var size string
+			for _, r := range requests {
+				if r.InsertTable != nil {
+					size = fmt.Sprintf("%dx%d ", r.InsertTable.Rows, r.InsertTable.Columns)
+					break
+				}
+			}
+			if size == "" {
+				return command.TextErrorResult("failed to insert table: no table rows found"), nil
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(params.anchor.revision(doc, params.ExpectedRevisionID))); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully inserted a %stable at index %d.\nApplied %s.", size, index, summarizeRequests(requests))), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "readTable",
+		Description: command.Description{Short: "Reads the text of a table's cells as a 2D array (JSON) or as CSV. Select the table by ordinal, start index or nearby text."},
+		Params: append([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "format", Type: command.String, Description: "Output format: 'json' (default) for an object with the table's startIndex, size and cells, or 'csv'."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, tableSelectorParams...),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				Format     string `json:"format"`
+				TabID      string `json:"tabId"`
+				tableSelector
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if params.Format != "" && params.Format != "json" && params.Format != "csv" {
+				return command.TextErrorResult(fmt.Sprintf("invalid format %q: must be json or csv", params.Format)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read table: %v", err)), nil
+			}
+			t, err := findTable(body, params.tableSelector)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read table: %v", err)), nil
+			}
+
+			if params.Format == "csv" {
+				var sb strings.Builder
+				w := csv.NewWriter(&sb)
+				if err := w.WriteAll(t.cells()); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to read table: %v", err)), nil
+				}
+				return command.TextResult(sb.String()), nil
+			}
+			return command.JSONResult(struct {
+				TableIndex int        `json:"tableIndex"`
+				StartIndex int        `json:"startIndex"`
+				Rows       int        `json:"rows"`
+				Columns    int        `json:"columns"`
+				Cells      [][]string `json:"cells"`
+			}{t.ordinal, t.startIndex, t.Rows, t.Columns, t.cells()}), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "setTableCell",
+		Description: command.Description{Short: "Replaces the text of one table cell. Rows and columns are counted from 0."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "row", Type: command.Int, Description: "0-based row index of the cell.", Required: true},
+			{Name: "column", Type: command.Int, Description: "0-based column index of the cell.", Required: true},
+			{Name: "text", Type: command.String, Description: "The new cell text. An empty string clears the cell.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, tableSelectorParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				Row        int    `json:"row"`
+				Column     int    `json:"column"`
+				Text       string `json:"text"`
+				TabID      string `json:"tabId"`
+				tableSelector
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to set table cell: %v", err)), nil
+			}
+			if params.TableStartIndex != 0 {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to set table cell: %v", err)), nil
+				}
+			}
+			t, err := findTable(body, params.tableSelector)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to set table cell: %v", err)), nil
+			}
+			cell, err := t.cell(params.Row, params.Column)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to set table cell: %v", err)), nil
+			}
+			start, end, err := cellRange(cell)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to set table cell: %v", err)), nil
+			}
+
+			var requests []google.Request
+			if end > start {
+				requests = append(requests, google.Request{DeleteContentRange: &google.DeleteContentRangeRequest{
+					Range: google.Range{StartIndex: start, EndIndex: end, TabID: params.TabID},
+				}})
+			}
+			if params.Text != "" {
+				requests = append(requests, google.Request{InsertText: &google.InsertTextRequest{
+					Location: google.Location{Index: start, TabID: params.TabID},
+					Text:     params.Text,
+				}})
+			}
+			if len(requests) == 0 {
+				return command.TextResult(fmt.Sprintf("Cell (%d, %d) is already empty.", params.Row, params.Column)), nil
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to set table cell: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully set cell (%d, %d) of table %d.", params.Row, params.Column, t.ordinal)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "insertTableRow",
+		Description: command.Description{Short: "Inserts an empty row above or below a row of a table. Rows are counted from 0."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "row", Type: command.Int, Description: "0-based index of the row to insert next to.", Required: true},
+			{Name: "insertBelow", Type: command.Bool, Description: "If true, inserts below the row instead of above it."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, tableSelectorParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID  string `json:"documentId"`
+				Row         int    `json:"row"`
+				InsertBelow bool   `json:"insertBelow"`
+				TabID       string `json:"tabId"`
+				tableSelector
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table row: %v", err)), nil
+			}
+			if params.TableStartIndex != 0 {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to insert table row: %v", err)), nil
+				}
+			}
+			t, err := findTable(body, params.tableSelector)
+			if err == nil {
+				_, err = t.cell(params.Row, 0)
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table row: %v", err)), nil
+			}
+
+			req := google.Request{InsertTableRow: &google.InsertTableRowRequest{
+				TableCellLocation: t.cellLocation(params.TabID, params.Row, 0),
+				InsertBelow:       params.InsertBelow,
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table row: %v", err)), nil
+			}
+
+			where := "above"
+			if params.InsertBelow {
+				where = "below"
+			}
+			return command.TextResult(fmt.Sprintf("Successfully inserted a row %s row %d of table %d.", where, params.Row, t.ordinal)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "deleteTableRow",
+		Description: command.Description{Short: "Deletes a row of a table. Rows are counted from 0."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "row", Type: command.Int, Description: "0-based index of the row to delete.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, tableSelectorParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				Row        int    `json:"row"`
+				TabID      string `json:"tabId"`
+				tableSelector
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete table row: %v", err)), nil
+			}
+			if params.TableStartIndex != 0 {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to delete table row: %v", err)), nil
+				}
+			}
+			t, err := findTable(body, params.tableSelector)
+			if err == nil {
+				_, err = t.cell(params.Row, 0)
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete table row: %v", err)), nil
+			}
+			if len(t.TableRows) == 1 {
+				return command.TextErrorResult("failed to delete table row: it is the table's only row; use deleteRange to remove the table"), nil
+			}
+
+			req := google.Request{DeleteTableRow: &google.DeleteTableRowRequest{
+				TableCellLocation: t.cellLocation(params.TabID, params.Row, 0),
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete table row: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully deleted row %d of table %d.", params.Row, t.ordinal)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "insertTableColumn",
+		Description: command.Description{Short: "Inserts an empty column to the left or right of a column of a table. Columns are counted from 0."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "column", Type: command.Int, Description: "0-based index of the column to insert next to.", Required: true},
+			{Name: "insertRight", Type: command.Bool, Description: "If true, inserts to the right of the column instead of to the left."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, tableSelectorParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID  string `json:"documentId"`
+				Column      int    `json:"column"`
+				InsertRight bool   `json:"insertRight"`
+				TabID       string `json:"tabId"`
+				tableSelector
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table column: %v", err)), nil
+			}
+			if params.TableStartIndex != 0 {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to insert table column: %v", err)), nil
+				}
+			}
+			t, err := findTable(body, params.tableSelector)
+			if err == nil {
+				_, err = t.cell(0, params.Column)
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table column: %v", err)), nil
+			}
+
+			req := google.Request{InsertTableColumn: &google.InsertTableColumnRequest{
+				TableCellLocation: t.cellLocation(params.TabID, 0, params.Column),
+				InsertRight:       params.InsertRight,
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to insert table column: %v", err)), nil
+			}
+
+			where := "left of"
+			if params.InsertRight {
+				where = "right of"
+			}
+			return command.TextResult(fmt.Sprintf("Successfully inserted a column %s column %d of table %d.", where, params.Column, t.ordinal)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "deleteTableColumn",
+		Description: command.Description{Short: "Deletes a column of a table. Columns are counted from 0."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "column", Type: command.Int, Description: "0-based index of the column to delete.", Required: true},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, tableSelectorParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string `json:"documentId"`
+				Column     int    `json:"column"`
+				TabID      string `json:"tabId"`
+				tableSelector
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete table column: %v", err)), nil
+			}
+			if params.TableStartIndex != 0 {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to delete table column: %v", err)), nil
+				}
+			}
+			t, err := findTable(body, params.tableSelector)
+			if err == nil {
+				_, err = t.cell(0, params.Column)
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete table column: %v", err)), nil
+			}
+			if t.Columns == 1 {
+				return command.TextErrorResult("failed to delete table column: it is the table's only column; use deleteRange to remove the table"), nil
+			}
+
+			req := google.Request{DeleteTableColumn: &google.DeleteTableColumnRequest{
+				TableCellLocation: t.cellLocation(params.TabID, 0, params.Column),
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to delete table column: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully deleted column %d of table %d.", params.Column, t.ordinal)), nil
+		},
+	})
+
+	for _, merge := range []bool{true, false} {
+		name, verb, past := "mergeTableCells", "merge", "merged"
+		summary := "Merges a rectangular block of table cells into one cell, keeping the text of every cell."
+		if !merge {
+			name, verb, past = "unmergeTableCells", "unmerge", "unmerged"
+			summary = "Splits merged cells within a rectangular block of a table back into individual cells."
+		}
+		app.AddCommand(&command.Command{
+			Name:        name,
+			Description: command.Description{Short: summary + " Rows and columns are counted from 0."},
+			Params: slices.Concat([]command.Param{
+				{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+				{Name: "row", Type: command.Int, Description: "0-based row index of the block's top-left cell.", Required: true},
+				{Name: "column", Type: command.Int, Description: "0-based column index of the block's top-left cell.", Required: true},
+				{Name: "rowSpan", Type: command.Int, Description: "Number of rows in the block. Defaults to 1."},
+				{Name: "columnSpan", Type: command.Int, Description: "Number of columns in the block. Defaults to 1."},
+				{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+			}, tableSelectorParams, writeControlParams),
+			Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+				var params struct {
+					DocumentID string `json:"documentId"`
+					Row        int    `json:"row"`
+					Column     int    `json:"column"`
+					RowSpan    int    `json:"rowSpan"`
+					ColumnSpan int    `json:"columnSpan"`
+					TabID      string `json:"tabId"`
+					tableSelector
+					writeControl
+				}
+				if err := json.Unmarshal(args, &params); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+				}
+				params.RowSpan, params.ColumnSpan = max(params.RowSpan, 1), max(params.ColumnSpan, 1)
+				if merge && params.RowSpan == 1 && params.ColumnSpan == 1 {
+					return command.TextErrorResult("rowSpan or columnSpan must be greater than 1 to merge cells"), nil
+				}
+
+				doc, err := client.Docs.Get(params.DocumentID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+				}
+				body, err := documentBody(doc, params.TabID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to %s table cells: %v", verb, err)), nil
+				}
+				if params.TableStartIndex != 0 {
+					if err := params.checkIndices(doc); err != nil {
+						return command.TextErrorResult(fmt.Sprintf("failed to %s table cells: %v", verb, err)), nil
+					}
+				}
+				t, err := findTable(body, params.tableSelector)
+				if err == nil {
+					_, err = t.cell(params.Row, params.Column)
+				}
+				if err == nil {
+					_, err = t.cell(params.Row+params.RowSpan-1, params.Column+params.ColumnSpan-1)
+				}
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to %s table cells: %v", verb, err)), nil
+				}
+
+				tr := &google.TableRangeRequest{TableRange: google.TableRange{
+					TableCellLocation: t.cellLocation(params.TabID, params.Row, params.Column),
+					RowSpan:           params.RowSpan,
+					ColumnSpan:        params.ColumnSpan,
+				}}
+				req := google.Request{MergeTableCells: tr}
+				if !merge {
+					req = google.Request{UnmergeTableCells: tr}
+				}
+				if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(doc.RevisionID)); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to %s table cells: %v", verb, err)), nil
+				}
+
+				return command.TextResult(fmt.Sprintf("Successfully %s a %dx%d block at (%d, %d) of table %d.", past, params.RowSpan, params.ColumnSpan, params.Row, params.Column, t.ordinal)), nil
+			},
+		})
+	}
+
+	app.AddCommand(&command.Command{
+		Name:        "setTableColumnWidth",
+		Description: command.Description{Short: "Sets a fixed width for one column of a table, or for every column when column is omitted."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "width", Type: command.Float, Description: fmt.Sprintf("Column width in points (at least %d).", minColumnWidth), Required: true},
+			{Name: "column", Type: command.Int, Description: "0-based index of the column. If not specified, sets every column."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, tableSelectorParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID string  `json:"documentId"`
+				Width      float64 `json:"width"`
+				Column     *int    `json:"column"`
+				TabID      string  `json:"tabId"`
+				tableSelector
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if params.Width < minColumnWidth {
+				return command.TextErrorResult(fmt.Sprintf("width must be at least %d points", minColumnWidth)), nil
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to set column width: %v", err)), nil
+			}
+			if params.TableStartIndex != 0 {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to set column width: %v", err)), nil
+				}
+			}
+			t, err := findTable(body, params.tableSelector)
+			var columns []int
+			if err == nil && params.Column != nil {
+				_, err = t.cell(0, *params.Column)
+				columns = []int{*params.Column}
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to set column width: %v", err)), nil
+			}
+
+			req := google.Request{UpdateTableColumnProperties: &google.UpdateTableColumnPropertiesRequest{
+				TableStartLocation:    t.location(params.TabID),
+				ColumnIndices:         columns,
+				TableColumnProperties: google.TableColumnProperties{WidthType: "FIXED_WIDTH", Width: google.Points(params.Width)},
+				Fields:                "width,widthType",
+			}}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, []google.Request{req}, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to set column width: %v", err)), nil
+			}
+
+			which := "every column"
+			if params.Column != nil {
+				which = fmt.Sprintf("column %d", *params.Column)
+			}
+			return command.TextResult(fmt.Sprintf("Successfully set %s of table %d to %gpt wide.", which, t.ordinal, params.Width)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "formatTableHeaderRow",
+		Description: command.Description{Short: "Styles the first rows of a table as a header: bold text, an optional background color, and pinned so they repeat on every page the table spans."},
+		Params: slices.Concat([]command.Param{
+			{Name: "documentId", Type: command.String, Description: "The document ID — the long string between /d/ and /edit in a Google Docs URL.", Required: true},
+			{Name: "rows", Type: command.Int, Description: "Number of header rows. Defaults to 1."},
+			{Name: "bold", Type: command.Bool, Description: "If true (default), makes the header text bold; false removes bold."},
+			{Name: "backgroundColor", Type: command.String, Description: "Header cell background color in hex format (e.g., \"#D9D9D9\")."},
+			{Name: "pin", Type: command.Bool, Description: "If true (default), repeats the header rows at the top of each page."},
+			{Name: "tabId", Type: command.String, Description: "The ID of the specific tab. If not specified, uses the first tab."},
+		}, tableSelectorParams, writeControlParams),
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				DocumentID      string `json:"documentId"`
+				Rows            int    `json:"rows"`
+				Bold            *bool  `json:"bold"`
+				BackgroundColor string `json:"backgroundColor"`
+				Pin             *bool  `json:"pin"`
+				TabID           string `json:"tabId"`
+				tableSelector
+				writeControl
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			params.Rows = max(params.Rows, 1)
+			var background *google.OptionalColor
+			if params.BackgroundColor != "" {
+				var err error
+				if background, err = parseHexColor(params.BackgroundColor); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("invalid backgroundColor: %v", err)), nil
+				}
+			}
+
+			doc, err := client.Docs.Get(params.DocumentID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read document: %v", err)), nil
+			}
+			body, err := documentBody(doc, params.TabID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to format header row: %v", err)), nil
+			}
+			if params.TableStartIndex != 0 {
+				if err := params.checkIndices(doc); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to format header row: %v", err)), nil
+				}
+			}
+			t, err := findTable(body, params.tableSelector)
+			if err == nil {
+				_, err = t.cell(params.Rows-1, 0)
+			}
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to format header row: %v", err)), nil
+			}
+
+			var requests []google.Request
+			for _, row := range t.TableRows[:params.Rows] {
+				for i := range row.TableCells {
+					start, end, err := cellRange(&row.TableCells[i])
+					if err != nil || end <= start {
+						continue
+					}
+					requests = append(requests, google.Request{UpdateTextStyle: &google.UpdateTextStyleRequest{
+						Range:     google.Range{StartIndex: start, EndIndex: end, TabID: params.TabID},
+						TextStyle: google.TextStyle{Bold: google.Bool(params.Bold == nil || *params.Bold)},
+						Fields:    "bold",
+					}})
+				}
+			}
+			if background != nil {
+				requests = append(requests, google.Request{UpdateTableCellStyle: &google.UpdateTableCellStyleRequest{
+					TableRange: google.TableRange{
+						TableCellLocation: t.cellLocation(params.TabID, 0, 0),
+						RowSpan:           params.Rows,
+						ColumnSpan:        t.Columns,
+					},
+					TableCellStyle: google.TableCellStyle{BackgroundColor: background},
+					Fields:         "backgroundColor",
+				}})
+			}
+			if params.Pin == nil || *params.Pin {
+				requests = append(requests, google.Request{PinTableHeaderRows: &google.PinTableHeaderRowsRequest{
+					TableStartLocation:    t.location(params.TabID),
+					PinnedHeaderRowsCount: params.Rows,
+				}})
+			}
+			if len(requests) == 0 {
+				return command.TextResult("Nothing to format: the header cells are empty and pin is false."), nil
+			}
+			if _, err := client.Docs.BatchUpdate(params.DocumentID, requests, params.control(doc.RevisionID)); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to format header row: %v", err)), nil
+			}
+
+			return command.TextResult(fmt.Sprintf("Successfully formatted %d header row(s) of table %d.\nApplied %s.", params.Rows, t.ordinal, summarizeRequests(requests))), nil
+		},
+	})
+}
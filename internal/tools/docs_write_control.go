@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// writeControl guards an edit against changes other editors made after
+// the caller read the document. Tools that edit an existing document embed
+// it in their params.
+type writeControl struct {
+	ExpectedRevisionID   string `json:"expectedRevisionId"`
+	MergeConcurrentEdits bool   `json:"mergeConcurrentEdits"`
+}
+
+var writeControlParams = []command.Param{
+	{Name: "expectedRevisionId", Type: command.String, Description: "The revisionId returned by readDocument when the indices for this edit were worked out. If the document has changed since, the edit fails with a conflict instead of landing in the wrong place."},
+	{Name: "mergeConcurrentEdits", Type: command.Bool, Description: "With expectedRevisionId, apply the edit on top of changes made since that revision, moving its indices to follow them, instead of failing with a conflict."},
+}
+
+// control returns the write control for a batchUpdate whose indices are
+// those of revision base, or nil when no revision is expected. base is the
+// document's revisionId when the tool worked the indices out from its own
+// read, and ExpectedRevisionID when the caller supplied them. Without
+// mergeConcurrentEdits the edit still requires the expected revision, so
+// changes since it fail with a conflict whichever read the indices came from.
+func (w writeControl) control(base string) *google.WriteControl {
+	switch {
+	case w.ExpectedRevisionID == "":
+		return nil
+	case w.MergeConcurrentEdits:
+		return &google.WriteControl{TargetRevisionID: base}
+	}
+	return &google.WriteControl{RequiredRevisionID: w.ExpectedRevisionID}
+}
+
+// applied moves the expected revision on to the one an edit's first
+// batchUpdate produced. Later batches of the edit work their indices out
+// against that revision, so they require it, or target it when merging.
+func (w *writeControl) applied(resp *google.BatchUpdateResponse) {
+	if w.ExpectedRevisionID != "" && resp.WriteControl != nil {
+		w.ExpectedRevisionID = resp.WriteControl.RequiredRevisionID
+	}
+}
+
+// checkIndices fails with a conflict when doc is not the expected revision.
+// Tools call it before using indices the caller gave to pick out content in
+// their own read of the document: the indices are those of the expected
+// revision, so once anyone else has edited they may pick out something
+// else, which merging the edit cannot put right.
+func (w writeControl) checkIndices(doc *google.Document) error {
+	if w.ExpectedRevisionID == "" || doc.RevisionID == w.ExpectedRevisionID {
+		return nil
+	}
+	return &google.ConflictError{DocumentID: doc.DocumentID, RequiredRevisionID: w.ExpectedRevisionID}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/markdown"
 	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
 )
 
@@ -397,7 +398,7 @@ func registerDriveCommands(app *command.App, client *google.Client) {
 
 	app.AddCommand(&command.Command{
 		Name:        "createDocument",
-		Description: command.Description{Short: "Creates a new empty Google Document. Optionally places it in a specific folder and adds initial text content."},
+		Description: command.Description{Short: "Creates a new Google Document. Optionally places it in a specific folder and adds initial content, converted from markdown by default."},
 		Params: []command.Param{
 			{Name: "title", Type: command.String, Description: "Title for the new document.", Required: true},
 			{Name: "parentFolderId", Type: command.String, Description: "ID of folder where document should be created. If not provided, creates in Drive root."},
@@ -425,36 +426,86 @@ func registerDriveCommands(app *command.App, client *google.Client) {
 				"name": doc.Title,
 				"url":  fmt.Sprintf("https://docs.google.com/document/d/%s/edit", doc.DocumentID),
 			}
+
+			if params.InitialContent != "" {
+				var requests []google.Request
+				if params.ContentFormat == "raw" {
+					requests = []google.Request{{InsertText: &google.InsertTextRequest{
+						Location: google.Location{Index: 1},
+						Text:     params.InitialContent,
+					}}}
+				} else {
+					requests = markdown.ToRequests(params.InitialContent, markdown.Options{
+						StartIndex:          1,
+						FirstHeadingAsTitle: true,
+					})
+				}
+				// The document already exists at this point, so a failed
+				// content write is reported alongside it rather than as an error.
+				if len(requests) > 0 {
+					if _, err := client.Docs.BatchUpdate(doc.DocumentID, requests, nil); err != nil {
+						result["warning"] = fmt.Sprintf("document created but initial content could not be added: %v", err)
+					}
+				}
+			}
+
 			return command.JSONResult(result), nil
 		},
 	})
 
 	app.AddCommand(&command.Command{
 		Name:        "createDocumentFromTemplate",
-		Description: command.Description{Short: "Creates a new document by copying an existing template and optionally replacing placeholder text."},
+		Description: command.Description{Short: "Creates a new document by copying a template and filling its placeholders from data. Templates use {{name}} for values (dotted paths reach into objects), {{image:name}} for images, {{#items}}...{{/items}} to repeat paragraphs or a table row for each item of a list or keep them when a value is true, and {{^name}}...{{/name}} for content kept only when a value is missing or empty. Reports any placeholders left unmatched."},
 		Params: []command.Param{
 			{Name: "templateId", Type: command.String, Description: "ID of the template document to copy from.", Required: true},
 			{Name: "newTitle", Type: command.String, Description: "Title for the new document.", Required: true},
 			{Name: "parentFolderId", Type: command.String, Description: "ID of folder where document should be created. If not provided, creates in Drive root."},
-			{Name: "replacements", Type: command.String, Description: "Key-value pairs for text replacements in the template (JSON object, e.g., {\"{{NAME}}\": \"John Doe\"})."},
+			{Name: "data", Type: command.String, Description: "Values for the template's placeholders (JSON object, e.g., {\"name\": \"Ada\", \"items\": [{\"sku\": \"A1\", \"qty\": 2}], \"logo\": \"https://example.com/logo.png\"})."},
+			{Name: "replacements", Type: command.String, Description: "Literal text replacements applied after the template is filled (JSON object, e.g., {\"[DATE]\": \"2024-01-01\"}). Matching ignores case."},
 		},
 		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
 			var params struct {
-				TemplateID     string            `json:"templateId"`
-				NewTitle       string            `json:"newTitle"`
-				ParentFolderID string            `json:"parentFolderId"`
-				Replacements   map[string]string `json:"replacements"`
+				TemplateID     string          `json:"templateId"`
+				NewTitle       string          `json:"newTitle"`
+				ParentFolderID string          `json:"parentFolderId"`
+				Data           json.RawMessage `json:"data"`
+				Replacements   json.RawMessage `json:"replacements"`
 			}
 			if err := json.Unmarshal(args, &params); err != nil {
 				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
 			}
+			var data map[string]any
+			if err := decodeJSONParam(params.Data, &data); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid data: %v", err)), nil
+			}
+			var replacements map[string]string
+			if err := decodeJSONParam(params.Replacements, &replacements); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid replacements: %v", err)), nil
+			}
 
 			file, err := client.Drive.CopyFile(params.TemplateID, params.NewTitle)
 			if err != nil {
 				return command.TextErrorResult(fmt.Sprintf("failed to create document from template: %v", err)), nil
 			}
 
-			return command.TextResult(fmt.Sprintf("Successfully created document \"%s\" from template (ID: %s)", file.Name, file.ID)), nil
+			created := fmt.Sprintf("Successfully created document \"%s\" from template (ID: %s)", file.Name, file.ID)
+			if data == nil && len(replacements) == 0 {
+				return command.TextResult(created), nil
+			}
+
+			// The copy exists at this point, so a failure to fill it is
+			// reported alongside its ID.
+			doc, err := client.Docs.Get(file.ID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("%s, but failed to read it to fill the template: %v", created, err)), nil
+			}
+			requests, report := renderTemplate(doc, data, replacements)
+			if len(requests) > 0 {
+				if _, err := client.Docs.BatchUpdate(file.ID, requests, nil); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("%s, but failed to fill the template: %v", created, err)), nil
+				}
+			}
+			return command.TextResult(fmt.Sprintf("%s.\nApplied %s.%s", created, summarizeRequests(requests), describeTemplateReport(report))), nil
 		},
 	})
 }
@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// maxInlineExportBytes caps exports returned as base64 rather than written
+// to disk, since the encoded content lands in the caller's context.
+const maxInlineExportBytes = 2 << 20
+
+// exportMimeTypes maps export formats to the MIME types Drive converts to.
+var exportMimeTypes = map[string]string{
+	"pdf":  "application/pdf",
+	"docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"odt":  "application/vnd.oasis.opendocument.text",
+	"rtf":  "application/rtf",
+	"html": "text/html",
+	"epub": "application/epub+zip",
+	"txt":  "text/plain",
+	"md":   "text/markdown",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"ods":  "application/x-vnd.oasis.opendocument.spreadsheet",
+	"csv":  "text/csv",
+	"pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"odp":  "application/vnd.oasis.opendocument.presentation",
+}
+
+// exportFormats lists the formats each Google file type can be exported
+// to, the first being the default.
+var exportFormats = map[string][]string{
+	"application/vnd.google-apps.document":     {"pdf", "docx", "odt", "rtf", "html", "epub", "txt", "md"},
+	"application/vnd.google-apps.spreadsheet":  {"xlsx", "csv", "ods", "pdf"},
+	"application/vnd.google-apps.presentation": {"pdf", "pptx", "odp", "txt"},
+}
+
+// exportedFile is one file produced by an export: written to path, or
+// returned inline as base64 when no path was given.
+type exportedFile struct {
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType"`
+	Size     int    `json:"size"`
+	Path     string `json:"path,omitempty"`
+	Base64   string `json:"base64,omitempty"`
+}
+
+// sheetCSV renders one sheet's values as CSV. Drive only exports the first
+// sheet of a spreadsheet to CSV, so each sheet is read through the Sheets
+// API instead.
+func sheetCSV(client *google.Client, spreadsheetID, title string) ([]byte, error) {
+	vr, err := client.Sheets.GetValues(spreadsheetID, "'"+strings.ReplaceAll(title, "'", "''")+"'")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, row := range vr.Values {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = fmt.Sprint(v)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// exportPath picks where an export is written. A directory gets the file's
+// own name; a file path is used as given. When a spreadsheet is split into
+// one CSV per sheet, the sheet title is appended to the name.
+func exportPath(outputPath, name, ext, sheet string) string {
+	dir, base := outputPath, name+"."+ext
+	if info, err := os.Stat(outputPath); err != nil || !info.IsDir() {
+		dir, base = filepath.Dir(outputPath), filepath.Base(outputPath)
+	}
+	if sheet != "" {
+		stem := strings.TrimSuffix(base, filepath.Ext(base))
+		base = stem + "-" + sheet + filepath.Ext(base)
+	}
+	return filepath.Join(dir, strings.ReplaceAll(base, string(filepath.Separator), "_"))
+}
+
+func registerDriveExportCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "exportFile",
+		Description: command.Description{Short: "Exports a Google Doc, Sheet or Slides deck to PDF, DOCX, ODT, RTF, HTML, EPUB, TXT, Markdown, XLSX, ODS, CSV, PPTX or ODP, writing it to a local path or returning it as base64. Spreadsheets exported to CSV give one file per sheet. Drive cannot export files over 10 MB."},
+		Params: []command.Param{
+			{Name: "fileId", Type: command.String, Description: "The file ID from a Google Drive URL or a previous tool result.", Required: true},
+			{Name: "format", Type: command.String, Description: "Export format, e.g. pdf, docx, xlsx, csv. Defaults to pdf for documents and presentations and xlsx for spreadsheets."},
+			{Name: "outputPath", Type: command.String, Description: "Local file or existing directory to write the export to. If not specified, the content is returned as base64 (up to 2 MB)."},
+			{Name: "sheetName", Type: command.String, Description: "For CSV exports of spreadsheets, export only this sheet instead of every sheet."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				FileID     string `json:"fileId"`
+				Format     string `json:"format"`
+				OutputPath string `json:"outputPath"`
+				SheetName  string `json:"sheetName"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			file, err := client.Drive.GetFile(params.FileID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to get file: %v", err)), nil
+			}
+			formats, ok := exportFormats[file.MimeType]
+			if !ok {
+				return command.TextErrorResult(fmt.Sprintf("%q has type %s; only Google Docs, Sheets and Slides files can be exported", file.Name, file.MimeType)), nil
+			}
+			format := strings.TrimPrefix(strings.ToLower(params.Format), ".")
+			if format == "" {
+				format = formats[0]
+			}
+			if !slices.Contains(formats, format) {
+				return command.TextErrorResult(fmt.Sprintf("cannot export %q to %s; choose one of %s", file.Name, format, strings.Join(formats, ", "))), nil
+			}
+			mimeType := exportMimeTypes[format]
+			if params.SheetName != "" && (format != "csv" || file.MimeType != "application/vnd.google-apps.spreadsheet") {
+				return command.TextErrorResult("sheetName only applies to CSV exports of spreadsheets"), nil
+			}
+
+			// Each part is one output file; only spreadsheet CSV exports
+			// have more than one.
+			type part struct {
+				sheet   string
+				content []byte
+			}
+			var parts []part
+			if format == "csv" {
+				ss, err := client.Sheets.GetSpreadsheet(file.ID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to read spreadsheet: %v", err)), nil
+				}
+				for _, sh := range ss.Sheets {
+					title := sh.Properties.Title
+					if params.SheetName != "" && title != params.SheetName {
+						continue
+					}
+					content, err := sheetCSV(client, file.ID, title)
+					if err != nil {
+						return command.TextErrorResult(fmt.Sprintf("failed to export sheet %q: %v", title, err)), nil
+					}
+					parts = append(parts, part{title, content})
+				}
+				if len(parts) == 0 {
+					return command.TextErrorResult(fmt.Sprintf("sheet %q not found in %q", params.SheetName, file.Name)), nil
+				}
+				if len(parts) == 1 {
+					parts[0].sheet = ""
+				}
+			} else {
+				content, err := client.Drive.ExportFile(file.ID, mimeType)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to export file: %v", err)), nil
+				}
+				parts = append(parts, part{"", content})
+			}
+
+			var results []exportedFile
+			total := 0
+			for _, p := range parts {
+				name := file.Name + "." + format
+				if p.sheet != "" {
+					name = file.Name + "-" + p.sheet + "." + format
+				}
+				result := exportedFile{Name: name, MimeType: mimeType, Size: len(p.content)}
+				total += len(p.content)
+				if params.OutputPath == "" {
+					result.Base64 = base64.StdEncoding.EncodeToString(p.content)
+				} else {
+					result.Path = exportPath(params.OutputPath, file.Name, format, p.sheet)
+					if err := os.WriteFile(result.Path, p.content, 0o644); err != nil {
+						return command.TextErrorResult(fmt.Sprintf("failed to write export: %v", err)), nil
+					}
+				}
+				results = append(results, result)
+			}
+			if params.OutputPath == "" && total > maxInlineExportBytes {
+				return command.TextErrorResult(fmt.Sprintf("export is %.1f MB, over the %d MB limit for returning it inline; pass outputPath to write it to disk", float64(total)/(1<<20), maxInlineExportBytes>>20)), nil
+			}
+			return command.JSONResult(results), nil
+		},
+	})
+}
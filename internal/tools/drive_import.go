@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/markdown"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// maxImportBytes is the largest local file importFile will upload.
+const maxImportBytes = 100 << 20
+
+// importType is how a local file type is converted on upload.
+type importType struct {
+	mimeType string
+	target   string
+}
+
+// importTypes maps file extensions to their MIME type and the Google type
+// Drive converts them to. Markdown is not listed: it goes through the
+// markdown package so headings, lists and tables come out as they do for
+// createDocument.
+var importTypes = map[string]importType{
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/vnd.google-apps.document"},
+	".doc":  {"application/msword", "application/vnd.google-apps.document"},
+	".odt":  {"application/vnd.oasis.opendocument.text", "application/vnd.google-apps.document"},
+	".rtf":  {"application/rtf", "application/vnd.google-apps.document"},
+	".html": {"text/html", "application/vnd.google-apps.document"},
+	".htm":  {"text/html", "application/vnd.google-apps.document"},
+	".txt":  {"text/plain", "application/vnd.google-apps.document"},
+	".csv":  {"text/csv", "application/vnd.google-apps.spreadsheet"},
+	".tsv":  {"text/tab-separated-values", "application/vnd.google-apps.spreadsheet"},
+	".xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/vnd.google-apps.spreadsheet"},
+	".xls":  {"application/vnd.ms-excel", "application/vnd.google-apps.spreadsheet"},
+	".ods":  {"application/vnd.oasis.opendocument.spreadsheet", "application/vnd.google-apps.spreadsheet"},
+	".pptx": {"application/vnd.openxmlformats-officedocument.presentationml.presentation", "application/vnd.google-apps.presentation"},
+	".odp":  {"application/vnd.oasis.opendocument.presentation", "application/vnd.google-apps.presentation"},
+}
+
+// importMarkdown creates a document from markdown and moves it into
+// parentID, since Docs always creates documents in the Drive root.
+func importMarkdown(client *google.Client, name, content, parentID string) (map[string]any, error) {
+	doc, err := client.Docs.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]any{
+		"id":       doc.DocumentID,
+		"name":     doc.Title,
+		"mimeType": "application/vnd.google-apps.document",
+		"url":      fmt.Sprintf("https://docs.google.com/document/d/%s/edit", doc.DocumentID),
+	}
+	// The document exists from here on, so later failures are reported
+	// alongside it rather than as an error.
+	var warnings []string
+	if requests := markdown.ToRequests(content, markdown.Options{StartIndex: 1, FirstHeadingAsTitle: true}); len(requests) > 0 {
+		if _, err := client.Docs.BatchUpdate(doc.DocumentID, requests, nil); err != nil {
+			warnings = append(warnings, fmt.Sprintf("content could not be added: %v", err))
+		}
+	}
+	if parentID != "" {
+		file, err := client.Drive.GetFile(doc.DocumentID)
+		if err == nil {
+			_, err = client.Drive.UpdateFile(doc.DocumentID, "", parentID, strings.Join(file.Parents, ","))
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("document could not be moved to folder %s: %v", parentID, err))
+		}
+	}
+	if len(warnings) > 0 {
+		result["warning"] = "document created but " + strings.Join(warnings, "; ")
+	}
+	return result, nil
+}
+
+func registerDriveImportCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "importFile",
+		Description: command.Description{Short: "Uploads a local file and converts it to a native Google file: DOCX, DOC, ODT, RTF, HTML and TXT become Docs, CSV, TSV, XLSX, XLS and ODS become Sheets, and PPTX and ODP become Slides. Markdown files are converted with the same formatting as createDocument. Returns the new file's ID and link."},
+		Params: []command.Param{
+			{Name: "localPath", Type: command.String, Description: "Path of the local file to import.", Required: true},
+			{Name: "parentFolderId", Type: command.String, Description: "ID of the folder to put the new file in. If not provided, uses Drive root."},
+			{Name: "name", Type: command.String, Description: "Name for the new file. Defaults to the local file name without its extension."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				LocalPath      string `json:"localPath"`
+				ParentFolderID string `json:"parentFolderId"`
+				Name           string `json:"name"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			info, err := os.Stat(params.LocalPath)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("file not found: %s", params.LocalPath)), nil
+			}
+			if info.IsDir() {
+				return command.TextErrorResult(fmt.Sprintf("%s is a directory", params.LocalPath)), nil
+			}
+			if info.Size() > maxImportBytes {
+				return command.TextErrorResult(fmt.Sprintf("file is %d MB; the limit is %d MB", info.Size()>>20, maxImportBytes>>20)), nil
+			}
+			ext := strings.ToLower(filepath.Ext(params.LocalPath))
+			isMarkdown := ext == ".md" || ext == ".markdown"
+			t, ok := importTypes[ext]
+			if !ok && !isMarkdown {
+				return command.TextErrorResult(fmt.Sprintf("cannot import %q files; supported types are .md, .markdown, .docx, .doc, .odt, .rtf, .html, .htm, .txt, .csv, .tsv, .xlsx, .xls, .ods, .pptx and .odp", ext)), nil
+			}
+			if params.Name == "" {
+				params.Name = strings.TrimSuffix(filepath.Base(params.LocalPath), filepath.Ext(params.LocalPath))
+			}
+			content, err := os.ReadFile(params.LocalPath)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read file: %v", err)), nil
+			}
+
+			if isMarkdown {
+				result, err := importMarkdown(client, params.Name, string(content), params.ParentFolderID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to import file: %v", err)), nil
+				}
+				return command.JSONResult(result), nil
+			}
+
+			file, err := client.Drive.ImportFile(params.Name, t.mimeType, t.target, params.ParentFolderID, content)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to import file: %v", err)), nil
+			}
+			return command.JSONResult(map[string]any{
+				"id":       file.ID,
+				"name":     file.Name,
+				"mimeType": file.MimeType,
+				"url":      file.WebViewLink,
+			}), nil
+		},
+	})
+}
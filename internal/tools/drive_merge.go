@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/amarbel-llc/piers/internal/doctemplate"
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+const (
+	defaultMergeConcurrency = 4
+	maxMergeConcurrency     = 10
+)
+
+var headerSeparators = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// mergeRow is one spreadsheet row to merge, numbered by its position in
+// the range with the header as row 1.
+type mergeRow struct {
+	number int
+	data   map[string]any
+}
+
+// mergeRows turns a range with a header row into template data. Header
+// names are usable as placeholders as written, and with runs of other
+// characters such as spaces replaced by underscores. Blank rows are
+// skipped.
+func mergeRows(values [][]any) ([]mergeRow, error) {
+	if len(values) < 2 {
+		return nil, fmt.Errorf("range needs a header row and at least one data row")
+	}
+	header := values[0]
+	var rows []mergeRow
+	for i, values := range values[1:] {
+		data := map[string]any{}
+		blank := true
+		for c, h := range header {
+			name := strings.TrimSpace(fmt.Sprint(h))
+			if name == "" {
+				continue
+			}
+			value := ""
+			if c < len(values) {
+				value = fmt.Sprint(values[c])
+			}
+			blank = blank && strings.TrimSpace(value) == ""
+			data[name] = value
+			data[strings.Trim(headerSeparators.ReplaceAllString(name, "_"), "_")] = value
+		}
+		if !blank {
+			rows = append(rows, mergeRow{number: i + 2, data: data})
+		}
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("range has no data rows")
+	}
+	return rows, nil
+}
+
+// copyTemplate copies the template under name, moving the copy into
+// folderID when one is given.
+func copyTemplate(client *google.Client, templateID, name, folderID string) (*google.DriveFile, error) {
+	file, err := client.Drive.CopyFile(templateID, name)
+	if err != nil {
+		return nil, err
+	}
+	if folderID == "" {
+		return file, nil
+	}
+	moved, err := client.Drive.UpdateFile(file.ID, "", folderID, strings.Join(file.Parents, ","))
+	if err != nil {
+		return file, fmt.Errorf("created %s but could not move it to the folder: %w", file.ID, err)
+	}
+	if moved.WebViewLink == "" {
+		moved.WebViewLink = file.WebViewLink
+	}
+	return moved, nil
+}
+
+type mergeResult struct {
+	Row        int      `json:"row"`
+	Name       string   `json:"name"`
+	DocumentID string   `json:"documentId,omitempty"`
+	URL        string   `json:"url,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	Unmatched  []string `json:"unmatched,omitempty"`
+}
+
+// mergeOne creates and fills the document for a single row.
+func mergeOne(client *google.Client, templateID, name, folderID string, row mergeRow) mergeResult {
+	result := mergeResult{Row: row.number, Name: name}
+	file, err := copyTemplate(client, templateID, name, folderID)
+	if file != nil {
+		result.DocumentID, result.URL = file.ID, file.WebViewLink
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	doc, err := client.Docs.Get(file.ID)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to read copy: %v", err)
+		return result
+	}
+	requests, report := renderTemplate(doc, row.data, nil)
+	result.Unmatched = report.Unmatched
+	if len(report.Errors) > 0 {
+		result.Error = strings.Join(report.Errors, "; ")
+	}
+	if len(requests) > 0 {
+		if _, err := client.Docs.BatchUpdate(file.ID, requests, nil); err != nil {
+			result.Error = fmt.Sprintf("failed to fill template: %v", err)
+		}
+	}
+	return result
+}
+
+// mergeTable renders per-row results as a markdown table.
+func mergeTable(results []mergeResult) string {
+	var sb strings.Builder
+	sb.WriteString("| Row | Document | ID | Status |\n| --- | --- | --- | --- |\n")
+	for _, r := range results {
+		status := "ok"
+		switch {
+		case r.Error != "":
+			status = "failed: " + r.Error
+		case len(r.Unmatched) > 0:
+			status = "unmatched: " + strings.Join(r.Unmatched, ", ")
+		}
+		name := r.Name
+		if r.URL != "" {
+			name = fmt.Sprintf("[%s](%s)", r.Name, r.URL)
+		}
+		fmt.Fprintf(&sb, "| %d | %s | %s | %s |\n", r.Row, name, r.DocumentID, strings.ReplaceAll(status, "|", "\\|"))
+	}
+	return sb.String()
+}
+
+func registerDriveMergeCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "mailMerge",
+		Description: command.Description{Short: "Generates documents from a template and spreadsheet rows. The range's first row holds the column names, which the template uses as placeholders such as {{Name}} (spaces in column names become underscores, e.g. {{First_Name}}); see createDocumentFromTemplate for the template syntax. Creates one document per row, or with combine=true one document with a page per row. Returns a table with the result for each row."},
+		Params: []command.Param{
+			{Name: "templateId", Type: command.String, Description: "ID of the template document to copy from.", Required: true},
+			{Name: "spreadsheetId", Type: command.String, Description: "The spreadsheet ID — the long string between /d/ and /edit in a Google Sheets URL.", Required: true},
+			{Name: "range", Type: command.String, Description: "A1 notation range holding a header row followed by one row per document (e.g., \"Sheet1!A1:D50\").", Required: true},
+			{Name: "filenamePattern", Type: command.String, Description: "Name for each document, with placeholders filled from its row (e.g., \"Invoice {{Number}} - {{Customer}}\"). Defaults to the template name followed by the row number. With combine, used as-is as the combined document's name."},
+			{Name: "folderId", Type: command.String, Description: "ID of the folder to create the documents in. If not provided, they are placed alongside the template copy's default location."},
+			{Name: "combine", Type: command.Bool, Description: "If true, creates a single document with one copy of the template per row, separated by page breaks. The template may then only contain paragraphs, not tables."},
+			{Name: "maxConcurrency", Type: command.Int, Description: "How many documents to create at once (1-10). Defaults to 4."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				TemplateID      string `json:"templateId"`
+				SpreadsheetID   string `json:"spreadsheetId"`
+				Range           string `json:"range"`
+				FilenamePattern string `json:"filenamePattern"`
+				FolderID        string `json:"folderId"`
+				Combine         bool   `json:"combine"`
+				MaxConcurrency  int    `json:"maxConcurrency"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			vr, err := client.Sheets.GetValues(params.SpreadsheetID, params.Range)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read spreadsheet: %v", err)), nil
+			}
+			rows, err := mergeRows(vr.Values)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to mail merge: %v", err)), nil
+			}
+
+			pattern := params.FilenamePattern
+			if pattern == "" {
+				template, err := client.Drive.GetFile(params.TemplateID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to read template: %v", err)), nil
+				}
+				pattern = template.Name + " - {{_row}}"
+				if params.Combine {
+					pattern = template.Name + " (merged)"
+				}
+			}
+
+			if params.Combine {
+				file, err := copyTemplate(client, params.TemplateID, pattern, params.FolderID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to create merged document: %v", err)), nil
+				}
+				doc, err := client.Docs.Get(file.ID)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to read merged document %s: %v", file.ID, err)), nil
+				}
+				tab, err := documentTab(doc, "")
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to mail merge: %v", err)), nil
+				}
+				items := make([]map[string]any, len(rows))
+				for i, row := range rows {
+					items[i] = row.data
+				}
+				requests, report := doctemplate.RenderEach(tab.Body, items, doctemplate.Options{Lists: tab.Lists})
+				if len(report.Errors) > 0 {
+					return command.TextErrorResult(fmt.Sprintf("failed to mail merge into %s: %s", file.ID, strings.Join(report.Errors, "; "))), nil
+				}
+				if _, err := client.Docs.BatchUpdate(file.ID, requests, nil); err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to mail merge into %s: %v", file.ID, err)), nil
+				}
+				return command.TextResult(fmt.Sprintf("Successfully merged %d rows into document \"%s\" (ID: %s).\nApplied %s.%s", len(rows), file.Name, file.ID, summarizeRequests(requests), describeTemplateReport(report))), nil
+			}
+
+			limit := params.MaxConcurrency
+			if limit <= 0 {
+				limit = defaultMergeConcurrency
+			}
+			limit = min(limit, maxMergeConcurrency)
+
+			results := make([]mergeResult, len(rows))
+			sem := make(chan struct{}, limit)
+			var wg sync.WaitGroup
+			for i, row := range rows {
+				data := map[string]any{"_row": row.number}
+				for k, v := range row.data {
+					data[k] = v
+				}
+				name, _ := doctemplate.Text(pattern, data)
+				if err := ctx.Err(); err != nil {
+					results[i] = mergeResult{Row: row.number, Name: name, Error: err.Error()}
+					continue
+				}
+				wg.Add(1)
+				sem <- struct{}{}
+				go func() {
+					defer wg.Done()
+					defer func() { <-sem }()
+					results[i] = mergeOne(client, params.TemplateID, name, params.FolderID, row)
+				}()
+			}
+			wg.Wait()
+
+			failed := 0
+			for _, r := range results {
+				if r.Error != "" {
+					failed++
+				}
+			}
+			summary := fmt.Sprintf("Created %d of %d documents.", len(rows)-failed, len(rows))
+			if failed > 0 {
+				summary = fmt.Sprintf("Created %d of %d documents; %d failed.", len(rows)-failed, len(rows), failed)
+			}
+			return command.TextResult(summary + "\n\n" + mergeTable(results)), nil
+		},
+	})
+}
@@ -0,0 +1,307 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amarbel-llc/piers/internal/google"
+	"github.com/amarbel-llc/piers/internal/textdiff"
+	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
+)
+
+// revisionFormat is a text format a revision can be read in.
+type revisionFormat struct {
+	name     string
+	mimeType string
+}
+
+// revisionFormats lists the text formats each Google file type's revisions
+// can be exported to, the first being the default. Drive exports only the
+// first sheet of a spreadsheet revision to CSV.
+var revisionFormats = map[string][]revisionFormat{
+	"application/vnd.google-apps.document":     {{"text", "text/plain"}, {"markdown", "text/markdown"}},
+	"application/vnd.google-apps.spreadsheet":  {{"csv", "text/csv"}},
+	"application/vnd.google-apps.presentation": {{"text", "text/plain"}},
+}
+
+// revisionMimeType picks the MIME type to export revisions of file in.
+// Files that are not Google files are read as stored, so only text files
+// qualify and format must be empty or "text".
+func revisionMimeType(file *google.DriveFile, format string) (string, error) {
+	formats, ok := revisionFormats[file.MimeType]
+	if !ok {
+		if !strings.HasPrefix(file.MimeType, "text/") && file.MimeType != "application/json" {
+			return "", fmt.Errorf("%q has type %s; only Google Docs, Sheets and Slides files and text files can be read", file.Name, file.MimeType)
+		}
+		if format != "" && format != "text" {
+			return "", fmt.Errorf("%q is a %s file and can only be read as text", file.Name, file.MimeType)
+		}
+		return file.MimeType, nil
+	}
+	if format == "" {
+		return formats[0].mimeType, nil
+	}
+	var names []string
+	for _, f := range formats {
+		if f.name == format {
+			return f.mimeType, nil
+		}
+		names = append(names, f.name)
+	}
+	return "", fmt.Errorf("cannot read revisions of %q as %s; choose %s", file.Name, format, strings.Join(names, " or "))
+}
+
+// revisionInfo is a revision as listed to callers.
+type revisionInfo struct {
+	ID             string `json:"id"`
+	ModifiedTime   string `json:"modifiedTime"`
+	LastModifiedBy string `json:"lastModifiedBy,omitempty"`
+	KeepForever    bool   `json:"keepForever,omitempty"`
+	Size           string `json:"size,omitempty"`
+}
+
+func newRevisionInfo(r google.Revision) revisionInfo {
+	info := revisionInfo{ID: r.ID, ModifiedTime: r.ModifiedTime, KeepForever: r.KeepForever, Size: r.Size}
+	if u := r.LastModifyingUser; u != nil {
+		info.LastModifiedBy = u.DisplayName
+		if info.LastModifiedBy == "" {
+			info.LastModifiedBy = u.EmailAddress
+		}
+	}
+	return info
+}
+
+// label names a revision in headers: its ID, time and author.
+func (r revisionInfo) label() string {
+	if r.LastModifiedBy == "" {
+		return fmt.Sprintf("revision %s (%s)", r.ID, r.ModifiedTime)
+	}
+	return fmt.Sprintf("revision %s (%s by %s)", r.ID, r.ModifiedTime, r.LastModifiedBy)
+}
+
+// findRevision returns the revision with the given ID, or the latest one
+// when id is empty.
+func findRevision(revs []google.Revision, id string) (google.Revision, error) {
+	if len(revs) == 0 {
+		return google.Revision{}, fmt.Errorf("the file has no revisions")
+	}
+	if id == "" {
+		return revs[len(revs)-1], nil
+	}
+	for _, r := range revs {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return google.Revision{}, fmt.Errorf("revision %q not found; use listRevisions to see the revisions Drive has kept", id)
+}
+
+// parseSince accepts an RFC 3339 time or a plain date, taken as midnight
+// UTC.
+func parseSince(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("since must be a date like 2025-01-13 or a time like 2025-01-13T09:00:00Z")
+	}
+	return t, nil
+}
+
+// revisionAt returns the latest revision modified at or before t: the
+// file as it stood at that time.
+func revisionAt(revs []google.Revision, t time.Time) (google.Revision, error) {
+	var found *google.Revision
+	for i, r := range revs {
+		modified, err := time.Parse(time.RFC3339, r.ModifiedTime)
+		if err != nil || modified.After(t) {
+			continue
+		}
+		found = &revs[i]
+	}
+	if found == nil {
+		oldest := "none"
+		if len(revs) > 0 {
+			oldest = revs[0].ModifiedTime
+		}
+		return google.Revision{}, fmt.Errorf("no revision kept from %s or earlier; the oldest is from %s", t.Format(time.RFC3339), oldest)
+	}
+	return *found, nil
+}
+
+func registerDriveRevisionCommands(app *command.App, client *google.Client) {
+	app.AddCommand(&command.Command{
+		Name:        "listRevisions",
+		Description: command.Description{Short: "Lists the revisions Drive has kept of a file, oldest first, with when and by whom each was made. Drive merges edits made close together and drops old revisions of Google files over time, so not every edit has its own revision."},
+		Params: []command.Param{
+			{Name: "fileId", Type: command.String, Description: "The file ID from a Google Drive URL or a previous tool result.", Required: true},
+			{Name: "maxResults", Type: command.Int, Description: "Return only the most recent revisions, up to this many. Defaults to 100."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				FileID     string `json:"fileId"`
+				MaxResults int    `json:"maxResults"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if params.MaxResults <= 0 {
+				params.MaxResults = 100
+			}
+
+			revs, err := client.Drive.ListRevisions(params.FileID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to list revisions: %v", err)), nil
+			}
+			infos := []revisionInfo{}
+			for _, r := range revs[max(len(revs)-params.MaxResults, 0):] {
+				infos = append(infos, newRevisionInfo(r))
+			}
+			return command.JSONResult(map[string]any{"total": len(revs), "revisions": infos}), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "readRevision",
+		Description: command.Description{Short: "Reads an earlier revision of a file as text. Docs can be read as text or markdown, Sheets as CSV (first sheet only) and Slides as text; other text files are returned as stored."},
+		Params: []command.Param{
+			{Name: "fileId", Type: command.String, Description: "The file ID from a Google Drive URL or a previous tool result.", Required: true},
+			{Name: "revisionId", Type: command.String, Description: "The revision to read, from listRevisions. Defaults to the latest revision."},
+			{Name: "format", Type: command.String, Description: "Output format: text or markdown for documents, csv for spreadsheets. Defaults to text, or csv for spreadsheets."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				FileID     string `json:"fileId"`
+				RevisionID string `json:"revisionId"`
+				Format     string `json:"format"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+
+			file, err := client.Drive.GetFile(params.FileID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to get file: %v", err)), nil
+			}
+			mimeType, err := revisionMimeType(file, params.Format)
+			if err != nil {
+				return command.TextErrorResult(err.Error()), nil
+			}
+			revs, err := client.Drive.ListRevisions(file.ID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to list revisions: %v", err)), nil
+			}
+			rev, err := findRevision(revs, params.RevisionID)
+			if err != nil {
+				return command.TextErrorResult(err.Error()), nil
+			}
+			content, err := client.Drive.ExportRevision(file.ID, rev.ID, mimeType)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to read revision: %v", err)), nil
+			}
+			return command.TextResult(fmt.Sprintf("%q at %s:\n---\n%s", file.Name, newRevisionInfo(rev).label(), content)), nil
+		},
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "diffRevisions",
+		Description: command.Description{Short: "Shows what changed in a file between two revisions, as a unified line diff or with changed words marked inline. Give fromRevisionId, or since to compare against the file as it stood at that time (e.g. \"what changed since Monday?\")."},
+		Params: []command.Param{
+			{Name: "fileId", Type: command.String, Description: "The file ID from a Google Drive URL or a previous tool result.", Required: true},
+			{Name: "fromRevisionId", Type: command.String, Description: "The earlier revision, from listRevisions. Provide this or since."},
+			{Name: "since", Type: command.String, Description: "Compare from the latest revision made at or before this date or time (e.g. \"2025-01-13\" or \"2025-01-13T09:00:00Z\")."},
+			{Name: "toRevisionId", Type: command.String, Description: "The later revision. Defaults to the latest revision."},
+			{Name: "format", Type: command.String, Description: "Format to compare in: text or markdown for documents, csv for spreadsheets. Defaults to text, or csv for spreadsheets."},
+			{Name: "mode", Type: command.String, Description: "unified (default) for a line diff, or words to mark changed words inline as [-removed-] and {+added+}."},
+			{Name: "contextLines", Type: command.Int, Description: "Unchanged lines to show around each change. Defaults to 3."},
+		},
+		Run: func(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+			var params struct {
+				FileID         string `json:"fileId"`
+				FromRevisionID string `json:"fromRevisionId"`
+				Since          string `json:"since"`
+				ToRevisionID   string `json:"toRevisionId"`
+				Format         string `json:"format"`
+				Mode           string `json:"mode"`
+				ContextLines   *int   `json:"contextLines"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+			if (params.FromRevisionID == "") == (params.Since == "") {
+				return command.TextErrorResult("provide exactly one of fromRevisionId or since"), nil
+			}
+			if params.Mode == "" {
+				params.Mode = "unified"
+			}
+			if params.Mode != "unified" && params.Mode != "words" {
+				return command.TextErrorResult(fmt.Sprintf("invalid mode %q; use unified or words", params.Mode)), nil
+			}
+			contextLines := 3
+			if params.ContextLines != nil {
+				if *params.ContextLines < 0 {
+					return command.TextErrorResult("contextLines cannot be negative"), nil
+				}
+				contextLines = *params.ContextLines
+			}
+
+			file, err := client.Drive.GetFile(params.FileID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to get file: %v", err)), nil
+			}
+			mimeType, err := revisionMimeType(file, params.Format)
+			if err != nil {
+				return command.TextErrorResult(err.Error()), nil
+			}
+			revs, err := client.Drive.ListRevisions(file.ID)
+			if err != nil {
+				return command.TextErrorResult(fmt.Sprintf("failed to list revisions: %v", err)), nil
+			}
+			var from google.Revision
+			if params.Since != "" {
+				since, err := parseSince(params.Since)
+				if err != nil {
+					return command.TextErrorResult(err.Error()), nil
+				}
+				from, err = revisionAt(revs, since)
+				if err != nil {
+					return command.TextErrorResult(err.Error()), nil
+				}
+			} else if from, err = findRevision(revs, params.FromRevisionID); err != nil {
+				return command.TextErrorResult(err.Error()), nil
+			}
+			to, err := findRevision(revs, params.ToRevisionID)
+			if err != nil {
+				return command.TextErrorResult(err.Error()), nil
+			}
+
+			fromInfo, toInfo := newRevisionInfo(from), newRevisionInfo(to)
+			if from.ID == to.ID {
+				return command.TextResult(fmt.Sprintf("No changes: both sides are %s of %q.", fromInfo.label(), file.Name)), nil
+			}
+			var texts [2][]string
+			for i, id := range []string{from.ID, to.ID} {
+				content, err := client.Drive.ExportRevision(file.ID, id, mimeType)
+				if err != nil {
+					return command.TextErrorResult(fmt.Sprintf("failed to read revision %s: %v", id, err)), nil
+				}
+				texts[i] = textdiff.Lines(string(content))
+			}
+
+			removed, added := textdiff.Count(textdiff.Diff(texts[0], texts[1]))
+			if removed == 0 && added == 0 {
+				return command.TextResult(fmt.Sprintf("No changes to %q between %s and %s.", file.Name, fromInfo.label(), toInfo.label())), nil
+			}
+			diff := textdiff.Unified
+			if params.Mode == "words" {
+				diff = textdiff.Words
+			}
+			return command.TextResult(fmt.Sprintf("%d line(s) removed and %d added in %q.\n\n%s",
+				removed, added, file.Name, diff(fromInfo.label(), toInfo.label(), texts[0], texts[1], contextLines))), nil
+		},
+	})
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/amarbel-llc/piers/internal/doctemplate"
+	"github.com/amarbel-llc/piers/internal/google"
+)
+
+// decodeJSONParam decodes a parameter declared as a JSON string into v.
+// Clients that follow the schema send the JSON encoded as a string; others
+// send the value itself, so both are accepted. A missing, null or empty
+// parameter leaves v as it is.
+func decodeJSONParam(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		if s == "" {
+			return nil
+		}
+		raw = json.RawMessage(s)
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// renderTemplate fills the template placeholders in every tab of doc, or in
+// its body when it has no tabs. Legacy literal replacements are applied
+// after the template has been rendered.
+func renderTemplate(doc *google.Document, data map[string]any, replacements map[string]string) ([]google.Request, doctemplate.Report) {
+	var requests []google.Request
+	var report doctemplate.Report
+	unmatched := map[string]bool{}
+	render := func(body *google.DocumentBody, opts doctemplate.Options) {
+		reqs, r := doctemplate.Render(body, data, opts)
+		requests = append(requests, reqs...)
+		for _, name := range r.Unmatched {
+			if !unmatched[name] {
+				unmatched[name] = true
+				report.Unmatched = append(report.Unmatched, name)
+			}
+		}
+		report.Errors = append(report.Errors, r.Errors...)
+	}
+
+	if len(doc.Tabs) == 0 {
+		render(doc.Body, doctemplate.Options{Lists: doc.Lists})
+	} else {
+		var walk func(tabs []google.Tab)
+		walk = func(tabs []google.Tab) {
+			for _, t := range tabs {
+				if t.DocumentTab != nil && t.DocumentTab.Body != nil {
+					render(t.DocumentTab.Body, doctemplate.Options{TabID: t.TabProperties.TabID, Lists: t.DocumentTab.Lists})
+				}
+				walk(t.ChildTabs)
+			}
+		}
+		walk(doc.Tabs)
+	}
+
+	keys := make([]string, 0, len(replacements))
+	for k := range replacements {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		requests = append(requests, google.Request{ReplaceAllText: &google.ReplaceAllTextRequest{
+			ContainsText: google.SubstringMatchCriteria{Text: k},
+			ReplaceText:  replacements[k],
+		}})
+	}
+	return requests, report
+}
+
+// describeTemplateReport summarizes what a template render left unfilled,
+// or returns "" when everything was filled.
+func describeTemplateReport(report doctemplate.Report) string {
+	var sb strings.Builder
+	if len(report.Unmatched) > 0 {
+		fmt.Fprintf(&sb, "\nUnmatched placeholders (left in place): %s", strings.Join(report.Unmatched, ", "))
+	}
+	for _, e := range report.Errors {
+		fmt.Fprintf(&sb, "\nTemplate error: %s", e)
+	}
+	return sb.String()
+}
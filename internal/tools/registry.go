@@ -11,11 +11,26 @@ func RegisterAll(client *google.Client) *command.App {
 
 	registerDocsCommands(app, client)
 	registerDriveCommands(app, client)
+	registerDriveMergeCommands(app, client)
+	registerDriveExportCommands(app, client)
+	registerDriveImportCommands(app, client)
+	registerDriveRevisionCommands(app, client)
 	registerSheetsCommands(app, client)
 	registerCommentCommands(app, client)
 	registerDocsStructureCommands(app, client)
 	registerDocsFormattingCommands(app, client)
 	registerDocsMarkdownCommands(app, client)
+	registerDocsSectionCommands(app, client)
+	registerDocsReplaceCommands(app, client)
+	registerDocsNamedRangeCommands(app, client)
+	registerDocsHeaderCommands(app, client)
+	registerDocsTableCommands(app, client)
+	registerDocsListCommands(app, client)
+	registerDocsOutlineCommands(app, client)
+	registerDocsSuggestionCommands(app, client)
+	registerDocsDocumentStyleCommands(app, client)
+	registerDocsInspectCommands(app, client)
+	registerDocsCompareCommands(app, client)
 
 	return app
 }
@@ -26,3 +26,397 @@ function read_document_returns_markdown_format { # @test
   assert_success
   assert_output --partial "Hello from the mock document."
 }
+
+function replace_document_with_markdown_converts_tables { # @test
+  run run_mcp_tool_call "replaceDocumentWithMarkdown" '{"documentId":"mock-doc-id-123","markdown":"# Title\n\n| a | b |\n|---|---|\n| 1 | 2 |"}'
+  assert_success
+  assert_output --partial "Successfully replaced document content"
+  assert_output --partial "1 insertTable"
+}
+
+function append_markdown_creates_bullets { # @test
+  run run_mcp_tool_call "appendMarkdown" '{"documentId":"mock-doc-id-123","markdown":"- one\n- two"}'
+  assert_success
+  assert_output --partial "Successfully appended"
+  assert_output --partial "1 createParagraphBullets"
+}
+
+function insert_text_resolves_after_text_anchor { # @test
+  run run_mcp_tool_call "insertText" '{"documentId":"mock-doc-id-123","text":"!","afterText":"Hello"}'
+  assert_success
+  assert_output --partial "at index 6"
+}
+
+function delete_range_resolves_text_anchors { # @test
+  run run_mcp_tool_call "deleteRange" '{"documentId":"mock-doc-id-123","afterText":"Hello","beforeText":"document"}'
+  assert_success
+  assert_output --partial "range 6-21"
+}
+
+function read_section_returns_markdown_under_heading { # @test
+  run run_mcp_tool_call "readSection" '{"documentId":"mock-doc-id-123","heading":"Overview"}'
+  assert_success
+  assert_output --partial "# Overview"
+  assert_output --partial "The overview section."
+}
+
+function replace_section_keeps_heading { # @test
+  run run_mcp_tool_call "replaceSection" '{"documentId":"mock-doc-id-123","headingId":"h.risks","markdown":"- a\n- b"}'
+  assert_success
+  assert_output --partial "indices 68-86"
+  assert_output --partial "createParagraphBullets"
+}
+
+function update_document_from_markdown_changes_only_edited_blocks { # @test
+  run run_mcp_tool_call "updateDocumentFromMarkdown" '{"documentId":"mock-doc-id-123","markdown":"Hello from the mock document.\n\n# Overview\n\nThe overview section, revised.\n\n# Risks\n\nNothing risky yet.","dryRun":true}'
+  assert_success
+  assert_output --partial "4 of 5 blocks unchanged, 1 removed, 1 added"
+  assert_output --partial "@@ indices 40-62"
+}
+
+function find_and_replace_previews_regex_matches { # @test
+  run run_mcp_tool_call "findAndReplace" '{"documentId":"mock-doc-id-123","find":"(\\w+) section","replace":"$1 part","useRegex":true,"preview":true}'
+  assert_success
+  assert_output --partial '"matchCount":1'
+  assert_output --partial "overview part"
+}
+
+function find_and_replace_reports_count { # @test
+  run run_mcp_tool_call "findAndReplace" '{"documentId":"mock-doc-id-123","find":"the","replace":"THE","wholeWord":true}'
+  assert_success
+  assert_output --partial "replaced 2 occurrence(s)"
+}
+
+function list_named_ranges { # @test
+  run run_mcp_tool_call "listNamedRanges" '{"documentId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial '"namedRangeId":"kix.mock-range"'
+}
+
+function read_named_range_by_name { # @test
+  run run_mcp_tool_call "readNamedRange" '{"documentId":"mock-doc-id-123","name":"exec-summary"}'
+  assert_success
+  assert_output --partial "The overview section."
+}
+
+function create_named_range_over_section { # @test
+  run run_mcp_tool_call "createNamedRange" '{"documentId":"mock-doc-id-123","name":"risks","section":"Risks"}'
+  assert_success
+  assert_output --partial "over indices 68-86"
+}
+
+function insert_text_after_named_range { # @test
+  run run_mcp_tool_call "insertText" '{"documentId":"mock-doc-id-123","text":"!","afterNamedRange":"exec-summary"}'
+  assert_success
+  assert_output --partial "at index 61"
+}
+
+function delete_range_of_named_range { # @test
+  run run_mcp_tool_call "deleteRange" '{"documentId":"mock-doc-id-123","namedRange":"kix.mock-range"}'
+  assert_success
+  assert_output --partial "range 40-61"
+}
+
+function read_document_markdown_notes_header { # @test
+  run run_mcp_tool_call "readDocument" '{"documentId":"mock-doc-id-123","format":"markdown"}'
+  assert_success
+  assert_output --partial "<!-- header: Mock header -->"
+}
+
+function list_headers_footers { # @test
+  run run_mcp_tool_call "listHeadersFooters" '{"documentId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial '"id":"kix.mock-header"'
+}
+
+function set_header_footer_replaces_text { # @test
+  run run_mcp_tool_call "setHeaderFooter" '{"documentId":"mock-doc-id-123","kind":"header","text":"Acme"}'
+  assert_success
+  assert_output --partial "set the default header (ID: kix.mock-header)"
+}
+
+function set_header_footer_rejects_missing_first_page { # @test
+  run run_mcp_tool_call "setHeaderFooter" '{"documentId":"mock-doc-id-123","kind":"footer","type":"firstPage","text":"Draft"}'
+  assert_success
+  assert_output --partial "cannot create one"
+}
+
+function read_table_as_csv { # @test
+  run run_mcp_tool_call "readTable" '{"documentId":"mock-table-doc-id","format":"csv"}'
+  assert_success
+  assert_output --partial "North,10"
+}
+
+function read_table_reports_missing_table { # @test
+  run run_mcp_tool_call "readTable" '{"documentId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial "document has no tables"
+}
+
+function set_table_cell_rejects_out_of_range_row { # @test
+  run run_mcp_tool_call "setTableCell" '{"documentId":"mock-table-doc-id","row":2,"column":0,"text":"x"}'
+  assert_success
+  assert_output --partial "table has 2 rows (0-based)"
+}
+
+function insert_table_with_data { # @test
+  run run_mcp_tool_call "insertTableWithData" '{"documentId":"mock-doc-id-123","data":[["a","b"],["c",1]],"afterHeading":"Risks"}'
+  assert_success
+  assert_output --partial "inserted a 2x2 table at index 68"
+}
+
+function insert_table_with_data_as_json_string { # @test
+  run run_mcp_tool_call "insertTableWithData" '{"documentId":"mock-doc-id-123","data":"[[\"a\",\"b\"],[\"c\",1]]","afterHeading":"Risks"}'
+  assert_success
+  assert_output --partial "inserted a 2x2 table at index 68"
+}
+
+function format_table_header_row { # @test
+  run run_mcp_tool_call "formatTableHeaderRow" '{"documentId":"mock-table-doc-id","backgroundColor":"#ddd"}'
+  assert_success
+  assert_output --partial "1 pinTableHeaderRows"
+}
+
+function detect_and_format_lists { # @test
+  run run_mcp_tool_call "detectAndFormatLists" '{"documentId":"mock-list-doc-id"}'
+  assert_success
+  assert_output --partial "converted 4 paragraphs into 2 lists (1 bulleted, 1 numbered)"
+}
+
+function create_list_rejects_unknown_preset { # @test
+  run run_mcp_tool_call "createList" '{"documentId":"mock-doc-id-123","textToFind":"overview section","bulletPreset":"STARS"}'
+  assert_success
+  assert_output --partial "invalid bulletPreset"
+}
+
+function change_list_nesting_outdents_item { # @test
+  run run_mcp_tool_call "changeListNesting" '{"documentId":"mock-list-doc-id","textToFind":"Passport","delta":-1}'
+  assert_success
+  assert_output --partial "moved 1 list items by -1 levels"
+}
+
+function change_list_nesting_requires_list { # @test
+  run run_mcp_tool_call "changeListNesting" '{"documentId":"mock-list-doc-id","textToFind":"apples","delta":1}'
+  assert_success
+  assert_output --partial "is not part of a list"
+}
+
+function insert_image_from_drive_keeps_aspect_ratio { # @test
+  run run_mcp_tool_call "insertImage" '{"documentId":"mock-doc-id-123","driveFileId":"mock-image-id","width":400,"index":5}'
+  assert_success
+  assert_output --partial "from Drive file chart.png at index 5 with size 400x300pt"
+}
+
+function insert_image_rejects_non_image_drive_file { # @test
+  run run_mcp_tool_call "insertImage" '{"documentId":"mock-doc-id-123","driveFileId":"mock-doc-id-123","index":5}'
+  assert_success
+  assert_output --partial "not a PNG, JPEG or GIF image"
+}
+
+function insert_image_requires_one_source { # @test
+  run run_mcp_tool_call "insertImage" '{"documentId":"mock-doc-id-123","imageUrl":"https://example.com/a.png","driveFileId":"mock-image-id","index":5}'
+  assert_success
+  assert_output --partial "provide exactly one of imageUrl, localPath or driveFileId"
+}
+
+function get_document_outline { # @test
+  run run_mcp_tool_call "getDocumentOutline" '{"documentId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial '"text":"Risks","headingId":"h.risks","startIndex":62'
+}
+
+function insert_table_of_contents { # @test
+  run run_mcp_tool_call "insertTableOfContents" '{"documentId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial "table of contents with 2 entries at index 1"
+}
+
+function refresh_table_of_contents_requires_one { # @test
+  run run_mcp_tool_call "refreshTableOfContents" '{"documentId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial "use insertTableOfContents to create one"
+}
+
+function list_suggestions { # @test
+  run run_mcp_tool_call "listSuggestions" '{"documentId":"mock-suggest-doc-id"}'
+  assert_success
+  assert_output --partial '"id":"suggest.del","kind":"deletion","text":"old ","startIndex":5,"endIndex":9'
+}
+
+function accept_all_suggestions { # @test
+  run run_mcp_tool_call "acceptSuggestions" '{"documentId":"mock-suggest-doc-id","all":true}'
+  assert_success
+  assert_output --partial "Successfully accepted 2 suggestion(s)"
+}
+
+function reject_unknown_suggestion { # @test
+  run run_mcp_tool_call "rejectSuggestions" '{"documentId":"mock-suggest-doc-id","suggestionIds":["missing"]}'
+  assert_success
+  assert_output --partial "not found: missing"
+}
+
+function get_document_style { # @test
+  run run_mcp_tool_call "getDocumentStyle" '{"documentId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial '"pageSize":"LETTER","pageWidth":612,"pageHeight":792,"orientation":"portrait"'
+}
+
+function update_document_style_landscape_a4 { # @test
+  run run_mcp_tool_call "updateDocumentStyle" '{"documentId":"mock-doc-id-123","pageSize":"A4","orientation":"landscape"}'
+  assert_success
+  assert_output --partial "Successfully updated document style (pageSize, flipPageOrientation)"
+}
+
+function get_named_styles { # @test
+  run run_mcp_tool_call "getNamedStyles" '{"documentId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial '"namedStyleType":"HEADING_1","fontSize":20'
+}
+
+function update_named_style { # @test
+  run run_mcp_tool_call "updateNamedStyle" '{"documentId":"mock-doc-id-123","namedStyleType":"HEADING_1","fontFamily":"Georgia"}'
+  assert_success
+  assert_output --partial "Successfully restyled 2 HEADING_1 paragraph(s) (weightedFontFamily)"
+}
+
+function read_document_pages_at_paragraph_boundaries { # @test
+  run run_mcp_tool_call "readDocument" '{"documentId":"mock-doc-id-123","maxLength":40}'
+  assert_success
+  assert_output --partial "Content (indices 1-40, 39 of 86 characters)"
+  assert_output --partial "Read on with cursor=40."
+}
+
+function read_document_resumes_from_cursor { # @test
+  run run_mcp_tool_call "readDocument" '{"documentId":"mock-doc-id-123","cursor":62}'
+  assert_success
+  assert_output --partial "Content (indices 62-87, 25 of 86 characters)"
+  refute_output --partial "overview section"
+}
+
+function read_document_chunks_by_heading { # @test
+  run run_mcp_tool_call "readDocument" '{"documentId":"mock-doc-id-123","chunkBy":"heading"}'
+  assert_success
+  assert_output --partial '"startIndex":31,"endIndex":62,"heading":"Overview"'
+}
+
+function get_text_style_resolves_named_styles { # @test
+  run run_mcp_tool_call "getTextStyle" '{"documentId":"mock-doc-id-123","textToFind":"Overview"}'
+  assert_success
+  assert_output --partial '"text":"Overview","namedStyleType":"HEADING_1","fontFamily":"Arial","fontSize":20,"bold":true'
+}
+
+function get_text_style_lets_runs_turn_off_named_style_bold { # @test
+  run run_mcp_tool_call "getTextStyle" '{"documentId":"mock-doc-id-123","textToFind":"Risks"}'
+  assert_success
+  assert_output --partial '"text":"Risks","namedStyleType":"HEADING_1","fontFamily":"Arial","fontSize":20}'
+}
+
+function get_paragraph_style { # @test
+  run run_mcp_tool_call "getParagraphStyle" '{"documentId":"mock-doc-id-123","startIndex":31,"endIndex":45}'
+  assert_success
+  assert_output --partial '"headingId":"h.overview","alignment":"START","spaceAbove":20'
+  assert_output --partial '"text":"The overview section.\n","namedStyleType":"NORMAL_TEXT"'
+}
+
+function find_paragraphs_by_named_style { # @test
+  run run_mcp_tool_call "findParagraphs" '{"documentId":"mock-doc-id-123","namedStyleType":"HEADING_1"}'
+  assert_success
+  assert_output --partial '{"startIndex":62,"endIndex":68,"text":"Risks","namedStyleType":"HEADING_1"}'
+  assert_output --partial '"total":2'
+}
+
+function find_paragraphs_by_explicitly_unbolded_heading { # @test
+  run run_mcp_tool_call "findParagraphs" '{"documentId":"mock-doc-id-123","namedStyleType":"HEADING_1","bold":false}'
+  assert_success
+  assert_output --partial '"text":"Risks"'
+  refute_output --partial '"text":"Overview"'
+}
+
+function find_paragraphs_by_list_and_pattern { # @test
+  run run_mcp_tool_call "findParagraphs" '{"documentId":"mock-list-doc-id","inList":true,"textPattern":"^Pass"}'
+  assert_success
+  assert_output --partial '"text":"Passport","namedStyleType":"NORMAL_TEXT","listId":"kix.mock-list"'
+}
+
+function find_paragraphs_rejects_bad_pattern { # @test
+  run run_mcp_tool_call "findParagraphs" '{"documentId":"mock-doc-id-123","textPattern":"("}'
+  assert_success
+  assert_output --partial "invalid textPattern"
+}
+
+function compare_documents_with_markdown_file { # @test
+  printf 'Hello from the **mock** document.\n\n# Overview\n\nThe overview section, now longer.\n\n# Risks\n\nNothing risky yet.\n' >"$BATS_TEST_TMPDIR/edited.md"
+  run run_mcp_tool_call "compareDocuments" "{\"documentId\":\"mock-doc-id-123\",\"localPath\":\"$BATS_TEST_TMPDIR/edited.md\"}"
+  assert_success
+  assert_output --partial '"type":"formatting","before":"Hello from the mock document.","after":"Hello from the **mock** document."'
+  assert_output --partial '"heading":"Overview","status":"changed"'
+  assert_output --partial '"inline":"The overview section{+, now longer+}."'
+  refute_output --partial '"heading":"Risks"'
+}
+
+function compare_documents_creates_redline { # @test
+  run run_mcp_tool_call "compareDocuments" '{"documentId":"mock-doc-id-123","otherDocumentId":"mock-list-doc-id","redlineTitle":"Redline"}'
+  assert_success
+  assert_output --partial '"heading":"Risks","status":"removed"'
+  assert_output --partial '"redline":{"id":'
+}
+
+function compare_documents_requires_one_target { # @test
+  run run_mcp_tool_call "compareDocuments" '{"documentId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial "provide exactly one of otherDocumentId or localPath"
+}
+
+function read_document_returns_revision_id { # @test
+  run run_mcp_tool_call "readDocument" '{"documentId":"mock-doc-id-123","format":"markdown"}'
+  assert_success
+  assert_output --partial "<!-- revisionId: mock-revision-1 -->"
+}
+
+function insert_text_at_expected_revision { # @test
+  run run_mcp_tool_call "insertText" '{"documentId":"mock-doc-id-123","text":"x","index":1,"expectedRevisionId":"mock-revision-1"}'
+  assert_success
+  assert_output --partial "Successfully inserted text"
+}
+
+function insert_text_conflicts_with_stale_revision { # @test
+  run run_mcp_tool_call "insertText" '{"documentId":"mock-doc-id-123","text":"x","index":1,"expectedRevisionId":"stale-rev"}'
+  assert_success
+  assert_output --partial "conflict: document mock-doc-id-123 has changed since revision stale-rev"
+}
+
+function insert_text_merges_past_stale_revision { # @test
+  run run_mcp_tool_call "insertText" '{"documentId":"mock-doc-id-123","text":"x","index":1,"expectedRevisionId":"stale-rev","mergeConcurrentEdits":true}'
+  assert_success
+  assert_output --partial "Successfully inserted text"
+}
+
+function delete_range_mixing_index_and_anchor_conflicts_with_stale_revision { # @test
+  run run_mcp_tool_call "deleteRange" '{"documentId":"mock-doc-id-123","startIndex":1,"beforeText":"mock","expectedRevisionId":"stale-rev","mergeConcurrentEdits":true}'
+  assert_success
+  assert_output --partial "conflict: document mock-doc-id-123 has changed since revision stale-rev"
+}
+
+function append_text_inserts_at_end_of_body { # @test
+  run run_mcp_tool_call "appendText" '{"documentId":"mock-doc-id-123","text":"more","addNewlineIfNeeded":true}'
+  assert_success
+  assert_output --partial "Successfully appended 5 characters at index 86"
+}
+
+function apply_paragraph_style_to_paragraph_around_index { # @test
+  run run_mcp_tool_call "applyParagraphStyle" '{"documentId":"mock-doc-id-123","indexWithinParagraph":5,"namedStyleType":"heading_2"}'
+  assert_success
+  assert_output --partial "Successfully applied paragraph style (namedStyleType) to the paragraphs in range 1-31"
+}
+
+function insert_image_from_url_with_lone_width { # @test
+  run run_mcp_tool_call "insertImage" '{"documentId":"mock-doc-id-123","imageUrl":"https://example.invalid/a.png","width":400,"index":5}'
+  assert_success
+  assert_output --partial "Successfully inserted image at index 5 with width 400pt"
+}
+
+function find_and_replace_preview_without_matches_lists_none { # @test
+  run run_mcp_tool_call "findAndReplace" '{"documentId":"mock-doc-id-123","find":"absent","replace":"x","preview":true}'
+  assert_success
+  assert_output --partial '"matches":[]'
+}
@@ -32,3 +32,110 @@ function list_documents_includes_urls { # @test
   url=$(echo "$output" | jq -r '.documents[0].url')
   assert_output --partial "docs.google.com"
 }
+
+function create_document_from_template_fills_data { # @test
+  run run_mcp_tool_call "createDocumentFromTemplate" '{"templateId":"mock-doc-id","newTitle":"Filled","data":{"name":"Ada"},"replacements":{"mock":"sample"}}'
+  assert_success
+  assert_output --partial "from template (ID: mock-copy-id)"
+  assert_output --partial "1 replaceAllText"
+}
+
+function create_document_from_template_accepts_json_strings { # @test
+  run run_mcp_tool_call "createDocumentFromTemplate" '{"templateId":"mock-doc-id","newTitle":"Filled","data":"{\"name\":\"Ada\"}","replacements":"{\"mock\":\"sample\"}"}'
+  assert_success
+  assert_output --partial "1 replaceAllText"
+}
+
+function mail_merge_creates_document_per_row { # @test
+  run run_mcp_tool_call "mailMerge" '{"templateId":"mock-doc-id","spreadsheetId":"mock-sheet-id","range":"A1:B3","filenamePattern":"Report {{Name}}"}'
+  assert_success
+  assert_output --partial "Created 2 of 2 documents."
+  assert_output --partial "| 3 | [Report Bob]"
+}
+
+function mail_merge_combines_rows { # @test
+  run run_mcp_tool_call "mailMerge" '{"templateId":"mock-doc-id","spreadsheetId":"mock-sheet-id","range":"A1:B3","combine":true}'
+  assert_success
+  assert_output --partial "merged 2 rows into document"
+  assert_output --partial "1 insertPageBreak"
+}
+
+function export_file_returns_base64_pdf { # @test
+  run run_mcp_tool_call "exportFile" '{"fileId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial '"name":"Mock Document.pdf","mimeType":"application/pdf"'
+}
+
+function export_file_writes_sheet_csv { # @test
+  run run_mcp_tool_call "exportFile" "{\"fileId\":\"mock-sheet-id-456\",\"format\":\"csv\",\"outputPath\":\"$BATS_TEST_TMPDIR\"}"
+  assert_success
+  assert_output --partial "Mock Spreadsheet.csv"
+  run cat "$BATS_TEST_TMPDIR/Mock Spreadsheet.csv"
+  assert_output --partial "Alice,95"
+}
+
+function export_file_rejects_unsupported_format { # @test
+  run run_mcp_tool_call "exportFile" '{"fileId":"mock-doc-id-123","format":"xlsx"}'
+  assert_success
+  assert_output --partial "choose one of pdf, docx"
+}
+
+function import_file_converts_csv_to_sheet { # @test
+  printf 'a,b\n1,2\n' >"$BATS_TEST_TMPDIR/data.csv"
+  run run_mcp_tool_call "importFile" "{\"localPath\":\"$BATS_TEST_TMPDIR/data.csv\"}"
+  assert_success
+  assert_output --partial '"mimeType":"application/vnd.google-apps.spreadsheet","name":"data"'
+}
+
+function import_file_converts_markdown { # @test
+  printf '# Plan\n\n- one\n' >"$BATS_TEST_TMPDIR/plan.md"
+  run run_mcp_tool_call "importFile" "{\"localPath\":\"$BATS_TEST_TMPDIR/plan.md\"}"
+  assert_success
+  assert_output --partial '"mimeType":"application/vnd.google-apps.document"'
+}
+
+function import_file_rejects_unknown_type { # @test
+  printf 'x' >"$BATS_TEST_TMPDIR/blob.bin"
+  run run_mcp_tool_call "importFile" "{\"localPath\":\"$BATS_TEST_TMPDIR/blob.bin\"}"
+  assert_success
+  assert_output --partial 'cannot import ".bin" files'
+}
+
+function list_revisions_oldest_first { # @test
+  run run_mcp_tool_call "listRevisions" '{"fileId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial '"revisions":[{"id":"rev-1","modifiedTime":"2025-01-10T09:00:00.000Z","lastModifiedBy":"Test User"},{"id":"rev-2"'
+  assert_output --partial '"total":2'
+}
+
+function read_revision_returns_text { # @test
+  run run_mcp_tool_call "readRevision" '{"fileId":"mock-doc-id-123","revisionId":"rev-1"}'
+  assert_success
+  assert_output --partial "A first draft of the overview."
+}
+
+function read_revision_rejects_format_for_type { # @test
+  run run_mcp_tool_call "readRevision" '{"fileId":"mock-sheet-id-456","format":"markdown"}'
+  assert_success
+  assert_output --partial "choose csv"
+}
+
+function diff_revisions_since_date { # @test
+  run run_mcp_tool_call "diffRevisions" '{"fileId":"mock-doc-id-123","since":"2025-01-13"}'
+  assert_success
+  assert_output --partial "1 line(s) removed and 3 added"
+  assert_output --partial "-A first draft of the overview."
+  assert_output --partial "+Nothing risky yet."
+}
+
+function diff_revisions_marks_words { # @test
+  run run_mcp_tool_call "diffRevisions" '{"fileId":"mock-doc-id-123","fromRevisionId":"rev-1","mode":"words"}'
+  assert_success
+  assert_output --partial "[-A-]{+The+}"
+}
+
+function diff_revisions_requires_one_start { # @test
+  run run_mcp_tool_call "diffRevisions" '{"fileId":"mock-doc-id-123"}'
+  assert_success
+  assert_output --partial "provide exactly one of fromRevisionId or since"
+}